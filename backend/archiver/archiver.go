@@ -0,0 +1,212 @@
+package archiver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"buttonmania.win/bot"
+	"buttonmania.win/conf"
+	"buttonmania.win/db"
+	"buttonmania.win/protocol"
+	"buttonmania.win/webhook"
+)
+
+// ContextKey is used for context keys.
+type ContextKey string
+
+const (
+	// Context keys for configuration.
+	KeyArchiverInterval ContextKey = ContextKey("archiverinterval")
+	// Default lifecycle policy of custom rooms
+	defaultInactiveDays = 30
+	defaultWarnDays     = 3
+	// Name of the lock held by the leading archiver
+	leaderLockName = "archiver:leader"
+	day            = 24 * 60 * 60
+)
+
+// policy defines when inactive custom rooms of the client are archived.
+type policy struct {
+	inactive int64
+	warn     int64
+}
+
+// Archiver periodically archives inactive custom rooms and warns their owners beforehand.
+type Archiver struct {
+	ctx      context.Context
+	db       *db.DB
+	bot      *bot.Bot
+	hooks    *webhook.Dispatcher
+	token    string
+	interval time.Duration
+	policies map[protocol.ClientID]policy
+}
+
+// valueOrDefault returns the value if positive, otherwise the default.
+func valueOrDefault(value int, defaultValue int) int64 {
+	if value > 0 {
+		return int64(value)
+	}
+	return int64(defaultValue)
+}
+
+// NewArchiver creates a new instance of Archiver.
+func NewArchiver(
+	ctx context.Context,
+	conf conf.Conf,
+	db *db.DB,
+	bot *bot.Bot,
+	hooks *webhook.Dispatcher,
+) (*Archiver, error) {
+	intervalSeconds := ctx.Value(KeyArchiverInterval).(int)
+	if intervalSeconds <= 0 {
+		return nil, fmt.Errorf("invalid archiver interval: %d", intervalSeconds)
+	}
+
+	policies := make(map[protocol.ClientID]policy)
+	for _, c := range conf.Clients {
+		p := policy{
+			inactive: valueOrDefault(c.CustomRooms.InactiveDays, defaultInactiveDays),
+			warn:     valueOrDefault(c.CustomRooms.WarnDays, defaultWarnDays),
+		}
+		if p.warn >= p.inactive {
+			return nil, fmt.Errorf("invalid warn days of client %s: %d", c.ClientId, p.warn)
+		}
+		policies[c.ClientId] = p
+	}
+
+	// Identify this instance in leader election
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, err
+	}
+
+	return &Archiver{
+		ctx:      ctx,
+		db:       db,
+		bot:      bot,
+		hooks:    hooks,
+		token:    hex.EncodeToString(tokenBytes),
+		interval: time.Duration(intervalSeconds) * time.Second,
+		policies: policies,
+	}, nil
+}
+
+// policy returns the lifecycle policy of the client, rooms of removed clients get defaults.
+func (a *Archiver) policy(clientId protocol.ClientID) policy {
+	if p, exists := a.policies[clientId]; exists {
+		return p
+	}
+	return policy{inactive: defaultInactiveDays, warn: defaultWarnDays}
+}
+
+// lead acquires or prolongs leadership, only the leader sweeps rooms.
+func (a *Archiver) lead() bool {
+	leader, err := a.db.AcquireLock(leaderLockName, a.token, 3*a.interval)
+	if err != nil {
+		log.Println("Archiver failed to acquire leadership:", err)
+		return false
+	}
+	return leader
+}
+
+// warn notifies the owner about the upcoming archival once per inactivity period.
+func (a *Archiver) warn(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	meta protocol.RoomMeta,
+	days int64,
+	now int64,
+) error {
+	profiles, err := a.db.GetUserProfiles([]protocol.UserID{meta.Owner})
+	if err != nil {
+		return err
+	}
+	locale := meta.Locale
+	if len(profiles) > 0 {
+		locale = protocol.NewUserLocale(profiles[0].Language)
+	}
+	// Owner may have blocked the bot, the warning is not repeated anyway
+	if err := a.bot.SendRoomExpiryWarning(meta.Owner, locale, meta.Title, days); err != nil {
+		log.Println("Archiver failed to warn room owner:", err)
+	}
+	return a.db.SetRoomWarned(clientId, roomId, now)
+}
+
+// archive moves the room to the archive and closes it on every instance,
+// webhooks of the client see the room deleted.
+func (a *Archiver) archive(clientId protocol.ClientID, roomId protocol.RoomID) error {
+	if err := a.db.ArchiveCustomGameRoom(clientId, roomId); err != nil {
+		return err
+	}
+	a.hooks.Emit(protocol.WebhookEvent{
+		Type:     protocol.WebhookRoomDeleted,
+		ClientID: clientId,
+		RoomID:   roomId,
+	})
+	return a.db.PublishRoomControl(clientId, roomId, protocol.RoomControl{
+		Action: protocol.ControlClose,
+	})
+}
+
+// sweep archives custom rooms inactive longer than the client policy allows.
+func (a *Archiver) sweep() error {
+	rooms, err := a.db.ListCustomGameRooms()
+	if err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	activityByClient := make(map[protocol.ClientID]map[protocol.RoomID]protocol.RoomActivity)
+	for _, roomKey := range rooms {
+		clientId, roomId := roomKey.V1, roomKey.V2
+		activity, exists := activityByClient[clientId]
+		if !exists {
+			activity, err = a.db.GetRoomsActivity(clientId)
+			if err != nil {
+				return err
+			}
+			activityByClient[clientId] = activity
+		}
+		meta, metaErr := a.db.GetRoomMeta(clientId, roomId)
+		if metaErr != nil {
+			err = errors.Join(err, metaErr)
+			continue
+		}
+		// Rooms without records are active since creation
+		lastActivity := max(activity[roomId].LastActivity, meta.Created)
+		p := a.policy(clientId)
+		inactiveDays := (now - lastActivity) / day
+		if inactiveDays >= p.inactive {
+			err = errors.Join(err, a.archive(clientId, roomId))
+		} else if inactiveDays >= p.inactive-p.warn && meta.Warned < lastActivity {
+			err = errors.Join(err, a.warn(clientId, roomId, meta, p.inactive-inactiveDays, now))
+		}
+	}
+	return err
+}
+
+// Run sweeps custom rooms on interval while this instance is the leader.
+func (a *Archiver) Run() error {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	defer func() {
+		_ = a.db.ReleaseLock(leaderLockName, a.token)
+	}()
+	for {
+		if a.lead() {
+			if err := a.sweep(); err != nil {
+				log.Println("Archiver failed to sweep rooms:", err)
+			}
+		}
+		select {
+		case <-a.ctx.Done():
+			return a.ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -2,8 +2,10 @@ package bot
 
 import (
 	"context"
+	"html"
 	"log"
 	"net/url"
+	"strconv"
 
 	"buttonmania.win/db"
 	"buttonmania.win/localization"
@@ -103,6 +105,23 @@ func (b *Bot) handleDonateCommand(bot *telego.Bot, update telego.Update) {
 	_, _ = b.bot.SendMessage(message)
 }
 
+// SendRoomExpiryWarning warns the owner the custom room will be archived in given days.
+func (b *Bot) SendRoomExpiryWarning(userID protocol.UserID, locale protocol.UserLocale, title string, days int64) error {
+	chatID, err := strconv.ParseInt(string(userID), 10, 64)
+	if err != nil {
+		return err
+	}
+	warningString := b.loc.LocalizedCommandString(locale, "room_expiry")
+
+	message := telegoutil.MessageWithEntities(
+		telegoutil.ID(chatID),
+		telegoutil.Entityf(warningString, html.EscapeString(title), days),
+	).WithParseMode("HTML")
+
+	_, err = b.bot.SendMessage(message)
+	return err
+}
+
 // handleUnknownCommand handles unknown commands.
 func (b *Bot) handleUnknownCommand(bot *telego.Bot, update telego.Update) {
 	_, _ = b.bot.SendMessage(telegoutil.Message(
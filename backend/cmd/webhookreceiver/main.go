@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"buttonmania.win/protocol"
+	"buttonmania.win/webhook"
+	"github.com/alecthomas/kingpin"
+)
+
+var (
+	port   = kingpin.Flag("port", "Receiver port.").Envar("WEBHOOK_RECEIVER_PORT").Default("9090").Int()
+	secret = kingpin.Flag("secret", "Webhook secret from the client config.").Envar("WEBHOOK_SECRET").Required().String()
+	status = kingpin.Flag("status", "Response status, non-2xx statuses make the server retry.").Envar("WEBHOOK_RECEIVER_STATUS").Default("200").Int()
+)
+
+// Local test receiver printing webhook events of the development server.
+func main() {
+	kingpin.Version("0.0.1")
+	kingpin.Parse()
+
+	http.HandleFunc("/", receive)
+	addr := fmt.Sprintf(":%d", *port)
+	log.Println("Receiving webhooks on", addr)
+	log.Fatal(http.ListenAndServe(addr, nil))
+}
+
+// receive verifies the signature of the webhook request and prints the event.
+func receive(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+	timestamp := r.Header.Get(webhook.HeaderTimestamp)
+	signature := r.Header.Get(webhook.HeaderSignature)
+	if !webhook.Verify(*secret, timestamp, body, signature) {
+		log.Println("Rejected event with invalid signature:", r.Header.Get(webhook.HeaderDelivery))
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+	var event protocol.WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		http.Error(w, "Invalid event", http.StatusBadRequest)
+		return
+	}
+	log.Printf("%s %s/%s user=%s id=%s body=%s", event.Type, event.ClientID, event.RoomID, event.UserID, event.ID, body)
+	w.WriteHeader(*status)
+}
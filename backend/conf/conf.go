@@ -9,9 +9,64 @@ const (
 	KeyConfigPath ContextKey = "configpath"
 )
 
+// ChatConf defines chat moderation policy of the client,
+// zero values fall back to defaults.
+type ChatConf struct {
+	MaxLength     int                 `config:"maxLength"`
+	RateLimit     int                 `config:"rateLimit"`
+	RatePeriod    int                 `config:"ratePeriod"`
+	AllowLinks    bool                `config:"allowLinks"`
+	WordFilters   map[string][]string `config:"wordFilters"`
+	ReportsToMute int                 `config:"reportsToMute"`
+	ReportsPeriod int                 `config:"reportsPeriod"`
+	MuteDuration  int                 `config:"muteDuration"`
+}
+
+// ReactionsConf defines quick reactions available to holders of the client rooms,
+// zero values fall back to defaults.
+type ReactionsConf struct {
+	Set    []string `config:"set"`
+	Window int      `config:"window"`
+}
+
+// RoomsConf defines the lifecycle policy and reserved ids of custom rooms of the client,
+// zero values fall back to defaults.
+type RoomsConf struct {
+	MaxPerUser   int               `config:"maxPerUser"`
+	InactiveDays int               `config:"inactiveDays"`
+	WarnDays     int               `config:"warnDays"`
+	Reserved     []protocol.RoomID `config:"reserved"`
+}
+
+// RoomModeConf defines the game mode of a predefined room, empty mode is classic.
+type RoomModeConf struct {
+	Mode  protocol.RoomMode `config:"mode"`
+	Teams []string          `config:"teams"`
+}
+
+// EventConf defines the window a predefined room is open for the event, times are in RFC 3339.
+type EventConf struct {
+	Opens  string `config:"opens"`
+	Closes string `config:"closes"`
+}
+
+// WebhookConf defines the webhook of the client, empty events or rooms subscribe to all of them.
+type WebhookConf struct {
+	URL    string                      `config:"url"`
+	Secret string                      `config:"secret"`
+	Events []protocol.WebhookEventType `config:"events"`
+	Rooms  []protocol.RoomID           `config:"rooms"`
+}
+
 type ClientConf struct {
-	ClientId protocol.ClientID `config:"clientId"`
-	Rooms    []protocol.RoomID `config:"rooms"`
+	ClientId    protocol.ClientID                `config:"clientId"`
+	Rooms       []protocol.RoomID                `config:"rooms"`
+	Chat        ChatConf                         `config:"chat"`
+	Reactions   ReactionsConf                    `config:"reactions"`
+	CustomRooms RoomsConf                        `config:"customRooms"`
+	Modes       map[protocol.RoomID]RoomModeConf `config:"modes"`
+	Events      map[protocol.RoomID][]EventConf  `config:"events"`
+	Webhooks    []WebhookConf                    `config:"webhooks"`
 }
 
 type Conf struct {
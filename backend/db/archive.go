@@ -0,0 +1,177 @@
+package db
+
+import (
+	"time"
+
+	"buttonmania.win/protocol"
+	"github.com/jackc/pgx/v5"
+)
+
+// Define the count of the best holds kept in the archive
+const archivedLeaderboardSize = 100
+
+// stores the custom room metadata in the archive along with the snapshot of its leaderboard,
+// the room archived again is updated.
+func (p *Postgres) archiveRoom(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	meta protocol.RoomMeta,
+) error {
+	tx, err := p.pool.Begin(p.ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(p.ctx)
+	if _, err := tx.Exec(
+		p.ctx,
+		`INSERT INTO archived_rooms(client_id, room_id, owner_id, title, description, icon, locale, private, created_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (client_id, room_id) DO UPDATE SET
+			owner_id = EXCLUDED.owner_id,
+			title = EXCLUDED.title,
+			description = EXCLUDED.description,
+			icon = EXCLUDED.icon,
+			locale = EXCLUDED.locale,
+			private = EXCLUDED.private,
+			created_at = EXCLUDED.created_at,
+			archived_at = current_timestamp`,
+		clientId,
+		roomId,
+		meta.Owner,
+		meta.Title,
+		meta.Description,
+		meta.Icon,
+		meta.Locale,
+		meta.Private,
+		time.Unix(meta.Created, 0),
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		p.ctx,
+		`DELETE FROM archived_leaderboards WHERE client_id=$1 AND room_id=$2`,
+		clientId,
+		roomId,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		p.ctx,
+		`INSERT INTO archived_leaderboards(client_id, room_id, user_id, duration)
+		SELECT client_id, room_id, user_id, MAX(duration)
+		FROM records
+		WHERE client_id=$1 AND room_id=$2 AND duration > 0
+		GROUP BY client_id, room_id, user_id
+		ORDER BY MAX(duration) DESC
+		LIMIT $3`,
+		clientId,
+		roomId,
+		archivedLeaderboardSize,
+	); err != nil {
+		return err
+	}
+	return tx.Commit(p.ctx)
+}
+
+// retrieves users with the best holds of the archived room along with their profiles.
+func (p *Postgres) getArchivedLeaderboard(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	count int64,
+) ([]protocol.LeaderboardEntry, error) {
+	rows, err := p.queryReadOnly(
+		`SELECT l.user_id, l.duration, u.name, u.username, u.photo_url, u.premium, u.language, u.privacy
+		FROM archived_leaderboards l
+		LEFT JOIN users u ON u.user_id = l.user_id
+		WHERE l.client_id=$1 AND l.room_id=$2
+		ORDER BY l.duration DESC
+		LIMIT $3`,
+		clientId,
+		roomId,
+		count,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (protocol.LeaderboardEntry, error) {
+		var entry protocol.LeaderboardEntry
+		err := scanUserProfile(row, &entry.User, &entry.User.UserID, &entry.Duration)
+		return entry, err
+	})
+}
+
+// checks if the room has been archived, private rooms included.
+func (p *Postgres) isRoomArchived(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (bool, error) {
+	count, err := p.queryReadOnlyInt64(
+		`SELECT count(*) FROM archived_rooms WHERE client_id=$1 AND room_id=$2`,
+		clientId,
+		roomId,
+	)
+	return count > 0, err
+}
+
+// scans the archived room row.
+func scanArchivedRoom(row pgx.CollectableRow) (protocol.ArchivedRoom, error) {
+	var room protocol.ArchivedRoom
+	var created, archived time.Time
+	err := row.Scan(
+		&room.ClientID,
+		&room.RoomID,
+		&room.Owner,
+		&room.Title,
+		&room.Description,
+		&room.Icon,
+		&room.Locale,
+		&room.Private,
+		&created,
+		&archived,
+	)
+	room.Created = created.Unix()
+	room.Archived = archived.Unix()
+	return room, err
+}
+
+// retrieves public archived rooms of the client, most recently archived first.
+// Empty owner retrieves rooms of every user.
+func (p *Postgres) getArchivedRooms(
+	clientId protocol.ClientID,
+	owner protocol.UserID,
+) ([]protocol.ArchivedRoom, error) {
+	rows, err := p.queryReadOnly(
+		`SELECT client_id, room_id, owner_id, title, description, icon, locale, private, created_at, archived_at
+		FROM archived_rooms
+		WHERE client_id=$1 AND NOT private AND ($2 = '' OR owner_id=$2)
+		ORDER BY archived_at DESC`,
+		clientId,
+		owner,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, scanArchivedRoom)
+}
+
+// retrieves the public archived room, nil if the room is not archived.
+func (p *Postgres) getArchivedRoom(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (*protocol.ArchivedRoom, error) {
+	rows, err := p.queryReadOnly(
+		`SELECT client_id, room_id, owner_id, title, description, icon, locale, private, created_at, archived_at
+		FROM archived_rooms
+		WHERE client_id=$1 AND room_id=$2 AND NOT private`,
+		clientId,
+		roomId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	rooms, err := pgx.CollectRows(rows, scanArchivedRoom)
+	if err != nil || len(rooms) == 0 {
+		return nil, err
+	}
+	return &rooms[0], nil
+}
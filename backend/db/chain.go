@@ -0,0 +1,160 @@
+package db
+
+import (
+	"strconv"
+	"time"
+
+	"buttonmania.win/protocol"
+	"github.com/go-redis/redis/v8"
+)
+
+// build keys of the room chain state in the order expected by chain scripts.
+func chainKeys(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) []string {
+	return []string{
+		roomTaggedKey(clientId, roomId, RedisKeyChain),
+		roomTaggedKey(clientId, roomId, RedisKeyChainHolders),
+		roomTaggedKey(clientId, roomId, RedisKeyChainPlayers),
+	}
+}
+
+// add the holder to the chain of the room, the first holder starts a new chain.
+func (r *Redis) joinChain(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+	now int64,
+) error {
+	return joinChainScript.Run(
+		r.ctx,
+		r.client,
+		chainKeys(clientId, roomId),
+		string(userID),
+		now,
+	).Err()
+}
+
+// remove the holder from the chain of the room, returns the record of the chain
+// broken by the last holder or nil while the chain lasts.
+func (r *Redis) leaveChain(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+	now int64,
+) (*protocol.ChainRecord, error) {
+	result, err := leaveChainScript.Run(
+		r.ctx,
+		r.client,
+		chainKeys(clientId, roomId),
+		string(userID),
+	).Int64Slice()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &protocol.ChainRecord{
+		Started:      result[0],
+		Duration:     now - result[0],
+		Participants: result[1],
+		Peak:         result[2],
+	}, nil
+}
+
+// get the live chain of the room, nil if nobody is holding.
+func (r *Redis) getChain(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	now int64,
+) (*protocol.ChainState, error) {
+	keys := chainKeys(clientId, roomId)
+	pipe := r.client.Pipeline()
+	fieldsCmd := pipe.HMGet(r.ctx, keys[0], "started", "peak")
+	holdersCmd := pipe.SCard(r.ctx, keys[1])
+	participantsCmd := pipe.SCard(r.ctx, keys[2])
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return nil, err
+	}
+	fields := fieldsCmd.Val()
+	startedStr, _ := fields[0].(string)
+	peakStr, _ := fields[1].(string)
+	if holdersCmd.Val() == 0 || startedStr == "" {
+		return nil, nil
+	}
+	started, _ := strconv.ParseInt(startedStr, 10, 64)
+	peak, _ := strconv.ParseInt(peakStr, 10, 64)
+	return &protocol.ChainState{
+		Started:      started,
+		Length:       now - started,
+		Holders:      holdersCmd.Val(),
+		Participants: participantsCmd.Val(),
+		Peak:         peak,
+	}, nil
+}
+
+// remove the chain state of the room.
+func (r *Redis) removeChain(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) error {
+	return r.client.Del(
+		r.ctx,
+		chainKeys(clientId, roomId)...,
+	).Err()
+}
+
+// add the collective record of the broken chain.
+func (p *Postgres) addChainRecord(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	record protocol.ChainRecord,
+) error {
+	_, err := p.pool.Exec(
+		p.ctx,
+		`INSERT INTO chain_records(client_id, room_id, started, duration, participants, peak)
+		VALUES($1, $2, $3, $4, $5, $6)
+		ON CONFLICT DO NOTHING`,
+		clientId,
+		roomId,
+		time.Unix(record.Started, 0),
+		record.Duration,
+		record.Participants,
+		record.Peak,
+	)
+	return err
+}
+
+// retrieves the longest chains of the room.
+func (p *Postgres) getChainRecords(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	count int64,
+) ([]protocol.ChainRecord, error) {
+	rows, err := p.queryReadOnly(
+		`SELECT started, duration, participants, peak
+		FROM chain_records
+		WHERE client_id=$1 AND room_id=$2
+		ORDER BY duration DESC, started
+		LIMIT $3`,
+		clientId,
+		roomId,
+		count,
+	)
+	if err != nil {
+		return nil, err
+	}
+	records := []protocol.ChainRecord{}
+	for rows.Next() {
+		var started time.Time
+		var record protocol.ChainRecord
+		if err := rows.Scan(&started, &record.Duration, &record.Participants, &record.Peak); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		record.Started = started.Unix()
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
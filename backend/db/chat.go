@@ -0,0 +1,135 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"buttonmania.win/protocol"
+)
+
+// publish user's chat message to the room channel.
+func (r *Redis) publishChatMessage(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	chatMessage protocol.ChatMessage,
+) error {
+	channel := roomTaggedKey(clientId, roomId, RedisKeyChat)
+	return r.client.Publish(
+		r.ctx,
+		channel,
+		chatMessage,
+	).Err()
+}
+
+// subscribe to chat messages published to the room channel.
+func (r *Redis) subscribeChatMessages(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (<-chan protocol.ChatMessage, func() error) {
+	payloads, stop := r.subscribeRoomChannel(clientId, roomId, RedisKeyChat)
+	messages := make(chan protocol.ChatMessage)
+	go func() {
+		defer close(messages)
+		for payload := range payloads {
+			var chatMessage protocol.ChatMessage
+			if err := json.Unmarshal([]byte(payload), &chatMessage); err != nil {
+				log.Println("Failed to decode chat message:", err)
+				continue
+			}
+			messages <- chatMessage
+		}
+	}()
+	return messages, stop
+}
+
+// build chat key of the user within the client slot.
+func userChatKey(
+	clientId protocol.ClientID,
+	userID protocol.UserID,
+	key RedisKey,
+) string {
+	return fmt.Sprintf(
+		"%s:%s",
+		clientTaggedKey(clientId, key),
+		userID,
+	)
+}
+
+// count user's chat message in the current rate limiting window.
+func (r *Redis) incrementChatRate(
+	clientId protocol.ClientID,
+	userID protocol.UserID,
+	period time.Duration,
+) (int64, error) {
+	rateKey := userChatKey(clientId, userID, RedisKeyChatRate)
+	return rateLimitScript.Run(
+		r.ctx,
+		r.client,
+		[]string{rateKey},
+		period.Milliseconds(),
+	).Int64()
+}
+
+// check if user is muted in the chat.
+func (r *Redis) isChatMuted(
+	clientId protocol.ClientID,
+	userID protocol.UserID,
+) (bool, error) {
+	muteKey := userChatKey(clientId, userID, RedisKeyChatMute)
+	count, err := r.client.Exists(r.ctx, muteKey).Result()
+	return count > 0, err
+}
+
+// report user's chat behaviour, mutes the user once reported enough times.
+func (r *Redis) reportChatUser(
+	clientId protocol.ClientID,
+	reporterID protocol.UserID,
+	userID protocol.UserID,
+	reportsToMute int64,
+	reportsPeriod time.Duration,
+	muteDuration time.Duration,
+) (bool, error) {
+	reportsKey := userChatKey(clientId, userID, RedisKeyChatReports)
+	muteKey := userChatKey(clientId, userID, RedisKeyChatMute)
+	return reportUserScript.Run(
+		r.ctx,
+		r.client,
+		[]string{reportsKey, muteKey},
+		string(reporterID),
+		reportsPeriod.Milliseconds(),
+		reportsToMute,
+		muteDuration.Milliseconds(),
+	).Bool()
+}
+
+// publish user's reaction to the room channel.
+func (r *Redis) publishReaction(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	reaction string,
+) error {
+	channel := roomTaggedKey(clientId, roomId, RedisKeyReactions)
+	return r.client.Publish(
+		r.ctx,
+		channel,
+		reaction,
+	).Err()
+}
+
+// subscribe to reactions published to the room channel.
+func (r *Redis) subscribeReactions(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (<-chan string, func() error) {
+	payloads, stop := r.subscribeRoomChannel(clientId, roomId, RedisKeyReactions)
+	reactions := make(chan string)
+	go func() {
+		defer close(reactions)
+		for payload := range payloads {
+			reactions <- payload
+		}
+	}()
+	return reactions, stop
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"log"
+	"slices"
+	"time"
+
+	"buttonmania.win/protocol"
+	"github.com/jackc/pgx/v5"
+)
+
+// persists user's chat message in the room history, returns the message id.
+func (p *Postgres) addChatMessage(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	chatMessage protocol.ChatMessage,
+) (int64, error) {
+	var id int64
+	err := p.pool.QueryRow(
+		p.ctx,
+		`INSERT INTO chat_messages(user_id, client_id, room_id, ts, message)
+		VALUES($1, $2, $3, $4, $5)
+		RETURNING id`,
+		chatMessage.UserID,
+		clientId,
+		roomId,
+		time.Unix(chatMessage.Timestamp, 0),
+		chatMessage.Message,
+	).Scan(&id)
+	return id, err
+}
+
+// retrieves the page of room chat messages sent before given message id,
+// zero id retrieves the most recent messages.
+func (p *Postgres) getChatHistory(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	before int64,
+	limit int64,
+) ([]protocol.ChatMessage, error) {
+	rows, err := p.queryReadOnly(
+		`SELECT m.id, m.ts, m.message, m.user_id, u.name, u.username, u.photo_url, u.premium, u.language, u.privacy
+		FROM chat_messages m
+		LEFT JOIN users u ON u.user_id = m.user_id
+		WHERE m.client_id=$1 AND m.room_id=$2 AND ($3 = 0 OR m.id < $3)
+		ORDER BY m.id DESC
+		LIMIT $4`,
+		clientId,
+		roomId,
+		before,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (protocol.ChatMessage, error) {
+		var chatMessage protocol.ChatMessage
+		var profile protocol.UserProfile
+		var ts time.Time
+		err := scanUserProfile(row, &profile, &chatMessage.ID, &ts, &chatMessage.Message, &chatMessage.UserID)
+		profile.UserID = chatMessage.UserID
+		chatMessage.User = &profile
+		chatMessage.Timestamp = ts.Unix()
+		return chatMessage, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Return the page in chronological order
+	slices.Reverse(messages)
+	return messages, nil
+}
+
+// removes chat messages older than the retention period.
+func (p *Postgres) removeExpiredChatMessages() (int64, error) {
+	tag, err := p.pool.Exec(
+		p.ctx,
+		`DELETE FROM chat_messages WHERE ts < $1`,
+		time.Now().Add(-p.chatRetention),
+	)
+	return tag.RowsAffected(), err
+}
+
+// periodically removes expired chat history until the context is done.
+func (p *Postgres) maintainChatHistory() {
+	ticker := time.NewTicker(chatRetentionInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := p.removeExpiredChatMessages(); err != nil {
+			log.Println("Failed to remove expired chat messages:", err)
+		}
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
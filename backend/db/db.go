@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"errors"
+	"time"
 
 	"buttonmania.win/protocol"
 )
@@ -17,8 +18,41 @@ const (
 	KeyRedisPassword ContextKey = "redispassword"
 	KeyRedisDatabase ContextKey = "redisdatabase"
 	KeyRedisTLS      ContextKey = "redistls"
+	KeyRedisUrl      ContextKey = "redisurl"
+	KeyRedisMode     ContextKey = "redismode"
+	// Context keys for postgres pool and replica configuration
+	KeyPostgresReplicaUrl        ContextKey = "postgresreplicaurl"
+	KeyPostgresMaxReplicaLag     ContextKey = "postgresmaxreplicalag"
+	KeyPostgresMaxConns          ContextKey = "postgresmaxconns"
+	KeyPostgresMinConns          ContextKey = "postgresminconns"
+	KeyPostgresMaxConnLifetime   ContextKey = "postgresmaxconnlifetime"
+	KeyPostgresMaxConnIdleTime   ContextKey = "postgresmaxconnidletime"
+	KeyPostgresHealthCheckPeriod ContextKey = "postgreshealthcheckperiod"
+	KeyPostgresConnectTimeout    ContextKey = "postgresconnecttimeout"
+	// Context keys for redis sentinel configuration
+	KeyRedisMasterName       ContextKey = "redismastername"
+	KeyRedisSentinelPassword ContextKey = "redissentinelpassword"
+	// Context key for chat history retention
+	KeyChatRetention ContextKey = "chatretention"
 )
 
+// ReapedSession represents an expired active session removed by the reaper,
+// the timestamp is the push time of the hold. The stale session has left only
+// the state of the room mode, its hold is unknown.
+type ReapedSession struct {
+	UserID    protocol.UserID
+	Duration  int64
+	Timestamp int64
+	Stale     bool
+}
+
+// WebhookRetry represents the failed webhook delivery waiting for its next attempt.
+type WebhookRetry struct {
+	Event   protocol.WebhookEvent
+	URL     string
+	Attempt int
+}
+
 // DB represents the database client.
 type DB struct {
 	redis    *Redis
@@ -94,6 +128,44 @@ func (db *DB) GetUsersCountInLeaderboard(
 	)
 }
 
+// GetDirectoryRooms retrieves metadata of public custom rooms of the client.
+func (db *DB) GetDirectoryRooms(
+	clientId protocol.ClientID,
+) (map[protocol.RoomID]protocol.RoomMeta, error) {
+	return db.redis.getDirectoryRooms(
+		clientId,
+	)
+}
+
+// GetRoomsActivity retrieves the count of active holders and players and the last activity
+// in each room of given client, rooms held right now are active at the moment.
+func (db *DB) GetRoomsActivity(
+	clientId protocol.ClientID,
+) (map[protocol.RoomID]protocol.RoomActivity, error) {
+	activity, err := db.postgres.getRoomsActivity(
+		clientId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	online, err := db.redis.getOnlineUsersCountByRoom(
+		clientId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	for roomId, count := range online {
+		roomActivity := activity[roomId]
+		roomActivity.Active = count
+		if count > 0 {
+			roomActivity.LastActivity = now
+		}
+		activity[roomId] = roomActivity
+	}
+	return activity, nil
+}
+
 // GetBestOverallDurationInLeaderboard retrieves the best duration achieved by a player in the leaderboard.
 func (db *DB) GetBestOverallDurationInLeaderboard(
 	clientId protocol.ClientID,
@@ -116,19 +188,6 @@ func (db *DB) GetTodaysDurationInLeaderboard(
 	)
 }
 
-// GetUserPlaceInActiveSessions retrieves the user's place in active sessions.
-func (db *DB) GetUserPlaceInActiveSessions(
-	clientId protocol.ClientID,
-	roomId protocol.RoomID,
-	userID protocol.UserID,
-) (int64, error) {
-	return db.redis.getUserPlaceInActiveSessions(
-		clientId,
-		roomId,
-		userID,
-	)
-}
-
 // GetUsersCountInActiveSessions retrieves the count of users in active sessions.
 func (db *DB) GetUsersCountInActiveSessions(
 	clientId protocol.ClientID,
@@ -149,69 +208,399 @@ func (db *DB) GetOnlineUsersCount(
 	)
 }
 
-// SetUserDurationToActiveSessions sets the user's duration in active sessions.
-func (db *DB) SetUserDurationToActiveSessions(
+// GetOnlineUsersCountByRoom retrieves the count of online users in each room of given client.
+func (db *DB) GetOnlineUsersCountByRoom(
+	clientId protocol.ClientID,
+) (map[protocol.RoomID]int64, error) {
+	return db.redis.getOnlineUsersCountByRoom(
+		clientId,
+	)
+}
+
+// StartUserActiveSession atomically adds the user pushed the button to active sessions
+// and retrieves the user's place along with the count of users in active sessions.
+func (db *DB) StartUserActiveSession(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+	push int64,
+) (int64, int64, error) {
+	return db.redis.updateActiveSession(
+		clientId,
+		roomId,
+		userID,
+		0,
+		push,
+		push,
+		true,
+	)
+}
+
+// UpdateUserActiveSession atomically sets the user's duration and heartbeat in active sessions
+// and retrieves the user's place along with the count of users in active sessions.
+// Returns ErrSessionReaped if the session has been reaped meanwhile.
+func (db *DB) UpdateUserActiveSession(
 	clientId protocol.ClientID,
 	roomId protocol.RoomID,
 	userID protocol.UserID,
 	duration int64,
 	timestamp int64,
-) error {
-	return db.redis.setUserDurationToActiveSessions(
+	push int64,
+) (int64, int64, error) {
+	return db.redis.updateActiveSession(
 		clientId,
 		roomId,
 		userID,
 		duration,
 		timestamp,
+		push,
+		false,
 	)
 }
 
-// RemoveUserDurationFromActiveSessions removes the user's duration from active sessions.
+// RemoveUserDurationFromActiveSessions removes the user's duration from active sessions,
+// returns false if the session has been already removed, e.g. by the reaper.
 func (db *DB) RemoveUserDurationFromActiveSessions(
 	clientId protocol.ClientID,
 	roomId protocol.RoomID,
 	userID protocol.UserID,
-	timestamp int64,
-) error {
+) (bool, error) {
 	return db.redis.removeUserDurationFromActiveSessions(
 		clientId,
 		roomId,
 		userID,
+	)
+}
+
+// ListActiveSessionRooms retrieves keys of rooms which have active sessions, payloads or holders of the room mode.
+func (db *DB) ListActiveSessionRooms() ([]protocol.RoomKey, error) {
+	return db.redis.listActiveSessionRooms()
+}
+
+// ReapActiveSessions removes expired active sessions and orphaned payloads of the room,
+// users left holding in the room mode are reaped as stale. Returns removed sessions and
+// the count of removed payloads.
+func (db *DB) ReapActiveSessions(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	mode protocol.RoomMode,
+	timestamp int64,
+) ([]ReapedSession, int64, error) {
+	return db.redis.reapActiveSessions(
+		clientId,
+		roomId,
+		mode,
 		timestamp,
 	)
 }
 
+// ReconcilePresence rebuilds online counters of every client from active sessions,
+// counters of clients with joins or leaves in the meantime are kept.
+func (db *DB) ReconcilePresence() error {
+	return db.redis.reconcilePresence()
+}
+
+// AcquireLock acquires or prolongs the named lock owned by given token.
+func (db *DB) AcquireLock(
+	name string,
+	token string,
+	ttl time.Duration,
+) (bool, error) {
+	return db.redis.acquireLock(
+		name,
+		token,
+		ttl,
+	)
+}
+
+// ReleaseLock releases the named lock owned by given token.
+func (db *DB) ReleaseLock(
+	name string,
+	token string,
+) error {
+	return db.redis.releaseLock(
+		name,
+		token,
+	)
+}
+
 // ListCustomGameRooms returs identifiers of custom game rooms
 func (db *DB) ListCustomGameRooms() ([]protocol.RoomKey, error) {
 	return db.redis.listCustomGameRooms()
 }
 
-// AddCustomGameRoom add new custom game room.
+// AddCustomGameRoom add new custom game room with its metadata, the count of rooms owned by the user is limited.
 func (db *DB) AddCustomGameRoom(
 	clientId protocol.ClientID,
 	roomId protocol.RoomID,
 	userID protocol.UserID,
+	meta protocol.RoomMeta,
+	maxRooms int64,
 ) error {
-	return db.redis.createCustomRoom(
+	if err := db.redis.createCustomRoom(
 		clientId,
 		roomId,
 		userID,
+		maxRooms,
+	); err != nil {
+		return err
+	}
+	return db.redis.createRoomMeta(
+		clientId,
+		roomId,
+		meta,
 	)
 }
 
-// AddCustomGameRoom add new custom game room.
+// RemoveCustomGameRoom removes custom game room with its metadata.
 func (db *DB) RemoveCustomGameRoom(
 	clientId protocol.ClientID,
 	roomId protocol.RoomID,
 	userID protocol.UserID,
 ) error {
-	return db.redis.removeCustomRoom(
+	if err := db.redis.removeCustomRoom(
+		clientId,
+		roomId,
+		userID,
+	); err != nil {
+		return err
+	}
+	return errors.Join(
+		db.redis.removeRoomMeta(clientId, roomId),
+		db.redis.removeRoomInvite(clientId, roomId),
+		db.redis.removeRoomMembers(clientId, roomId),
+		db.redis.removeRoomModerators(clientId, roomId),
+		db.redis.removeTournament(clientId, roomId),
+		db.redis.removeTeams(clientId, roomId),
+		db.redis.removeChain(clientId, roomId),
+		db.redis.removeRoomSessions(clientId, roomId),
+		db.redis.removeRoomRestrictions(clientId, roomId),
+		db.postgres.removeRoomMessages(clientId, roomId),
+	)
+}
+
+// ArchiveCustomGameRoom moves the custom game room to the archive along with the snapshot of its leaderboard,
+// the id of the archived room is not given to new rooms.
+func (db *DB) ArchiveCustomGameRoom(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) error {
+	meta, err := db.redis.getRoomMeta(
+		clientId,
+		roomId,
+	)
+	if err != nil {
+		return err
+	}
+	if meta.Owner == "" {
+		return ErrRoomNotExist
+	}
+	if err := db.postgres.archiveRoom(
+		clientId,
+		roomId,
+		meta,
+	); err != nil {
+		return err
+	}
+	return db.RemoveCustomGameRoom(
+		clientId,
+		roomId,
+		meta.Owner,
+	)
+}
+
+// GetArchivedRooms retrieves public archived rooms of the client, empty owner retrieves rooms of every user.
+func (db *DB) GetArchivedRooms(
+	clientId protocol.ClientID,
+	owner protocol.UserID,
+) ([]protocol.ArchivedRoom, error) {
+	return db.postgres.getArchivedRooms(
+		clientId,
+		owner,
+	)
+}
+
+// GetArchivedRoom retrieves the public archived room, nil if the room is not archived.
+func (db *DB) GetArchivedRoom(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (*protocol.ArchivedRoom, error) {
+	return db.postgres.getArchivedRoom(
+		clientId,
+		roomId,
+	)
+}
+
+// GetArchivedLeaderboard retrieves the best holds of the archived room kept at archiving.
+func (db *DB) GetArchivedLeaderboard(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	count int64,
+) ([]protocol.LeaderboardEntry, error) {
+	return db.postgres.getArchivedLeaderboard(
+		clientId,
+		roomId,
+		count,
+	)
+}
+
+// IsRoomArchived checks if the room has been archived, private rooms included.
+func (db *DB) IsRoomArchived(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (bool, error) {
+	return db.postgres.isRoomArchived(
+		clientId,
+		roomId,
+	)
+}
+
+// SetRoomWarned stores the time the owner was warned about the room expiry.
+func (db *DB) SetRoomWarned(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	warned int64,
+) error {
+	return db.redis.setRoomWarned(
+		clientId,
+		roomId,
+		warned,
+	)
+}
+
+// SetRoomInvite issues the room invite replacing the previous one.
+func (db *DB) SetRoomInvite(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	invite protocol.RoomInvite,
+) error {
+	return db.redis.setRoomInvite(
+		clientId,
+		roomId,
+		invite,
+	)
+}
+
+// GetRoomInvite retrieves the room invite, nil if not issued.
+func (db *DB) GetRoomInvite(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (*protocol.RoomInvite, error) {
+	return db.redis.getRoomInvite(
+		clientId,
+		roomId,
+	)
+}
+
+// RemoveRoomInvite revokes the room invite.
+func (db *DB) RemoveRoomInvite(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) error {
+	return db.redis.removeRoomInvite(
+		clientId,
+		roomId,
+	)
+}
+
+// ResolveInvite finds the room the invite code was issued for, empty if the code is unknown.
+func (db *DB) ResolveInvite(
+	clientId protocol.ClientID,
+	code string,
+) (protocol.RoomID, error) {
+	return db.redis.resolveInvite(
+		clientId,
+		code,
+	)
+}
+
+// RedeemRoomInvite redeems the room invite code making the user a room member.
+func (db *DB) RedeemRoomInvite(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	code string,
+	userID protocol.UserID,
+) error {
+	return db.redis.redeemRoomInvite(
+		clientId,
+		roomId,
+		code,
+		userID,
+	)
+}
+
+// IsRoomMember checks if the user has joined the room by invite.
+func (db *DB) IsRoomMember(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+) (bool, error) {
+	return db.redis.isRoomMember(
 		clientId,
 		roomId,
 		userID,
 	)
 }
 
+// SetRoomMessages replaces the message pack of the custom room.
+func (db *DB) SetRoomMessages(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	pack map[protocol.UserLocale][]string,
+) error {
+	return db.postgres.setRoomMessages(
+		clientId,
+		roomId,
+		pack,
+	)
+}
+
+// GetRoomMessages retrieves the message pack of the custom room, empty if not uploaded.
+func (db *DB) GetRoomMessages(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (map[protocol.UserLocale][]string, error) {
+	return db.postgres.getRoomMessages(
+		clientId,
+		roomId,
+	)
+}
+
+// RemoveRoomMessages removes the message pack of the custom room.
+func (db *DB) RemoveRoomMessages(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) error {
+	return db.postgres.removeRoomMessages(
+		clientId,
+		roomId,
+	)
+}
+
+// GetRoomMeta retrieves metadata of the game room.
+func (db *DB) GetRoomMeta(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (protocol.RoomMeta, error) {
+	return db.redis.getRoomMeta(
+		clientId,
+		roomId,
+	)
+}
+
+// UpdateRoomMeta updates provided fields of the custom game room metadata.
+func (db *DB) UpdateRoomMeta(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	update protocol.RoomMetaUpdate,
+) error {
+	return db.redis.updateRoomMeta(
+		clientId,
+		roomId,
+		update,
+	)
+}
+
 // GetBestUsersPayloads get payloads from redis
 func (db *DB) GetBestUsersPayloads(
 	clientId protocol.ClientID,
@@ -240,65 +629,729 @@ func (db *DB) AddUserPayload(
 	)
 }
 
-// RemoveUserPayload remove payload from redis
-func (db *DB) RemoveUserPayload(
+// JoinTeam stores the team the user holds the button for.
+func (db *DB) JoinTeam(
 	clientId protocol.ClientID,
 	roomId protocol.RoomID,
 	userID protocol.UserID,
+	team string,
 ) error {
-	return db.redis.removeUserPayload(
+	return db.redis.joinTeam(
 		clientId,
 		roomId,
 		userID,
+		team,
 	)
 }
 
-// InitChatConsumerGroup initialize consumer group for chat stream
-func (db *DB) InitChatConsumerGroup(
+// GetUserTeam retrieves the team the user holds the button for, empty if the user is not holding.
+func (db *DB) GetUserTeam(
 	clientId protocol.ClientID,
 	roomId protocol.RoomID,
-) error {
-	return db.redis.initChatConsumerGroup(
+	userID protocol.UserID,
+) (string, error) {
+	return db.redis.getUserTeam(
 		clientId,
 		roomId,
+		userID,
 	)
 }
 
-// AddConsumerToGroup add new consumer to group
-func (db *DB) AddConsumerToGroup(
+// LeaveTeam removes the user from the team, the finished hold is added to the team leaderboard.
+func (db *DB) LeaveTeam(
 	clientId protocol.ClientID,
 	roomId protocol.RoomID,
-	userId protocol.UserID,
+	userID protocol.UserID,
+	team string,
+	record *protocol.GameplayRecord,
 ) error {
-	return db.redis.addChatConsumerToGroup(
+	if record == nil {
+		return db.redis.leaveTeam(clientId, roomId, userID, team, 0)
+	}
+	return errors.Join(
+		db.redis.leaveTeam(clientId, roomId, userID, team, record.Duration),
+		db.postgres.addTeamRecord(clientId, roomId, team, userID, *record),
+	)
+}
+
+// GetTeamStandings retrieves live standings of the room teams.
+func (db *DB) GetTeamStandings(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) ([]protocol.TeamStanding, error) {
+	return db.redis.getTeamStandings(
 		clientId,
 		roomId,
-		userId,
 	)
 }
 
-// PushChatMessage push user's chat message.
-func (db *DB) PushChatMessage(
+// GetTeamLeaderboard retrieves teams of the room with the longest total hold time.
+func (db *DB) GetTeamLeaderboard(
 	clientId protocol.ClientID,
 	roomId protocol.RoomID,
-	chatMessage protocol.ChatMessage,
+) ([]protocol.TeamLeaderboardEntry, error) {
+	return db.postgres.getTeamLeaderboard(
+		clientId,
+		roomId,
+	)
+}
+
+// JoinChain adds the holder to the chain of the room.
+func (db *DB) JoinChain(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+	now int64,
 ) error {
-	return db.redis.pushChatMessage(
+	return db.redis.joinChain(
 		clientId,
 		roomId,
-		chatMessage,
+		userID,
+		now,
+	)
+}
+
+// LeaveChain removes the holder from the chain of the room, the record of the chain
+// broken by the last holder is stored and returned.
+func (db *DB) LeaveChain(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+	now int64,
+) (*protocol.ChainRecord, error) {
+	record, err := db.redis.leaveChain(
+		clientId,
+		roomId,
+		userID,
+		now,
+	)
+	if err != nil || record == nil {
+		return record, err
+	}
+	return record, db.postgres.addChainRecord(
+		clientId,
+		roomId,
+		*record,
+	)
+}
+
+// GetChain retrieves the live chain of the room, nil if nobody is holding.
+func (db *DB) GetChain(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	now int64,
+) (*protocol.ChainState, error) {
+	return db.redis.getChain(
+		clientId,
+		roomId,
+		now,
+	)
+}
+
+// GetChainRecords retrieves the longest chains of the room.
+func (db *DB) GetChainRecords(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	count int64,
+) ([]protocol.ChainRecord, error) {
+	return db.postgres.getChainRecords(
+		clientId,
+		roomId,
+		count,
 	)
 }
 
-// PopChatMessage pop user's chat message
-func (db *DB) PopChatMessage(
+// RemoveUserPayload remove payload from redis
+func (db *DB) RemoveUserPayload(
 	clientId protocol.ClientID,
 	roomId protocol.RoomID,
 	userID protocol.UserID,
-) (protocol.ChatMessage, error) {
-	return db.redis.popChatMessage(
+) error {
+	return db.redis.removeUserPayload(
 		clientId,
 		roomId,
 		userID,
 	)
 }
+
+// PushChatMessage persists user's chat message in the room history
+// and publishes it to every holder of the room.
+func (db *DB) PushChatMessage(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	chatMessage protocol.ChatMessage,
+) error {
+	id, err := db.postgres.addChatMessage(
+		clientId,
+		roomId,
+		chatMessage,
+	)
+	if err != nil {
+		return err
+	}
+	chatMessage.ID = id
+	return db.redis.publishChatMessage(
+		clientId,
+		roomId,
+		chatMessage,
+	)
+}
+
+// GetChatHistory retrieves the page of room chat messages sent before given message id.
+func (db *DB) GetChatHistory(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	before int64,
+	limit int64,
+) ([]protocol.ChatMessage, error) {
+	return db.postgres.getChatHistory(
+		clientId,
+		roomId,
+		before,
+		limit,
+	)
+}
+
+// SubscribeChatMessages subscribes to chat messages of the room.
+// Returned function must be called to unsubscribe.
+func (db *DB) SubscribeChatMessages(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (<-chan protocol.ChatMessage, func() error) {
+	return db.redis.subscribeChatMessages(
+		clientId,
+		roomId,
+	)
+}
+
+// PublishReaction publishes user's reaction to every holder of the room.
+func (db *DB) PublishReaction(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	reaction string,
+) error {
+	return db.redis.publishReaction(
+		clientId,
+		roomId,
+		reaction,
+	)
+}
+
+// SubscribeReactions subscribes to reactions of the room.
+// Returned function must be called to unsubscribe.
+func (db *DB) SubscribeReactions(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (<-chan string, func() error) {
+	return db.redis.subscribeReactions(
+		clientId,
+		roomId,
+	)
+}
+
+// IncrementChatRate counts user's chat message in the current rate limiting window.
+func (db *DB) IncrementChatRate(
+	clientId protocol.ClientID,
+	userID protocol.UserID,
+	period time.Duration,
+) (int64, error) {
+	return db.redis.incrementChatRate(
+		clientId,
+		userID,
+		period,
+	)
+}
+
+// IsChatMuted checks if user is muted in the chat.
+func (db *DB) IsChatMuted(
+	clientId protocol.ClientID,
+	userID protocol.UserID,
+) (bool, error) {
+	return db.redis.isChatMuted(
+		clientId,
+		userID,
+	)
+}
+
+// ReportChatUser reports user's chat behaviour, returns true if the user got muted.
+func (db *DB) ReportChatUser(
+	clientId protocol.ClientID,
+	reporterID protocol.UserID,
+	userID protocol.UserID,
+	reportsToMute int64,
+	reportsPeriod time.Duration,
+	muteDuration time.Duration,
+) (bool, error) {
+	return db.redis.reportChatUser(
+		clientId,
+		reporterID,
+		userID,
+		reportsToMute,
+		reportsPeriod,
+		muteDuration,
+	)
+}
+
+// UpdateUserProfile stores user's profile, returns the profile with user's privacy setting.
+func (db *DB) UpdateUserProfile(
+	profile protocol.UserProfile,
+) (protocol.UserProfile, error) {
+	return db.postgres.upsertUserProfile(
+		profile,
+	)
+}
+
+// SetUserPrivacy updates user's profile privacy setting.
+func (db *DB) SetUserPrivacy(
+	userID protocol.UserID,
+	privacy protocol.UserPrivacy,
+) error {
+	return db.postgres.setUserPrivacy(
+		userID,
+		privacy,
+	)
+}
+
+// GetUserProfiles retrieves profiles of given users in the same order.
+func (db *DB) GetUserProfiles(
+	userIDs []protocol.UserID,
+) ([]protocol.UserProfile, error) {
+	return db.postgres.getUserProfiles(
+		userIDs,
+	)
+}
+
+// GetLeaderboard retrieves users with the best records in the leaderboard.
+func (db *DB) GetLeaderboard(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	count int64,
+) ([]protocol.LeaderboardEntry, error) {
+	return db.postgres.getLeaderboard(
+		clientId,
+		roomId,
+		count,
+	)
+}
+
+// GetEventLeaderboard retrieves users with the best holds started within the event window.
+func (db *DB) GetEventLeaderboard(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	opens time.Time,
+	closes time.Time,
+	count int64,
+) ([]protocol.LeaderboardEntry, error) {
+	return db.postgres.getEventLeaderboard(
+		clientId,
+		roomId,
+		opens,
+		closes,
+		count,
+	)
+}
+
+// GetBestActiveUsers retrieves users holding the button longest in the room.
+func (db *DB) GetBestActiveUsers(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	count int64,
+) ([]protocol.UserID, error) {
+	return db.redis.getBestActiveUsers(
+		clientId,
+		roomId,
+		count,
+	)
+}
+
+// GetCustomGameRoomOwner retrieves the owner of the custom game room, empty for predefined rooms.
+func (db *DB) GetCustomGameRoomOwner(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (protocol.UserID, error) {
+	return db.redis.getCustomRoomOwner(
+		clientId,
+		roomId,
+	)
+}
+
+// GetRoomRole retrieves the role of the user in the custom game room, empty for users without a role.
+func (db *DB) GetRoomRole(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+) (protocol.RoomRole, error) {
+	return db.redis.getRoomRole(
+		clientId,
+		roomId,
+		userID,
+	)
+}
+
+// AddRoomModerator adds the moderator of the custom game room.
+func (db *DB) AddRoomModerator(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+) error {
+	return db.redis.addRoomModerator(
+		clientId,
+		roomId,
+		userID,
+	)
+}
+
+// RemoveRoomModerator removes the moderator of the custom game room.
+func (db *DB) RemoveRoomModerator(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+) error {
+	return db.redis.removeRoomModerator(
+		clientId,
+		roomId,
+		userID,
+	)
+}
+
+// GetRoomModerators retrieves moderators of the custom game room.
+func (db *DB) GetRoomModerators(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) ([]protocol.UserID, error) {
+	return db.redis.getRoomModerators(
+		clientId,
+		roomId,
+	)
+}
+
+// TransferCustomGameRoom transfers the custom game room to the new owner, the previous owner becomes a moderator.
+// The count of rooms owned by the new owner is limited.
+func (db *DB) TransferCustomGameRoom(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	ownerID protocol.UserID,
+	newOwnerID protocol.UserID,
+	maxRooms int64,
+) error {
+	return db.redis.transferCustomRoom(
+		clientId,
+		roomId,
+		ownerID,
+		newOwnerID,
+		maxRooms,
+	)
+}
+
+// ScheduleTournament schedules a new tournament of the room, the previous tournament must be finished.
+func (db *DB) ScheduleTournament(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	starts int64,
+	grace int64,
+) error {
+	return db.redis.scheduleTournament(
+		clientId,
+		roomId,
+		starts,
+		grace,
+	)
+}
+
+// GetTournament retrieves the state of the room tournament, nil if no tournament was scheduled.
+func (db *DB) GetTournament(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (*protocol.Tournament, error) {
+	return db.redis.getTournament(
+		clientId,
+		roomId,
+	)
+}
+
+// RegisterTournamentPlayer registers the player of the room tournament.
+func (db *DB) RegisterTournamentPlayer(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+	now int64,
+) error {
+	return db.redis.registerTournamentPlayer(
+		clientId,
+		roomId,
+		userID,
+		now,
+	)
+}
+
+// PushTournament starts holding of the registered player within the grace period of the tournament.
+func (db *DB) PushTournament(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+	now int64,
+) error {
+	return db.redis.pushTournament(
+		clientId,
+		roomId,
+		userID,
+		now,
+	)
+}
+
+// EliminateTournamentPlayer eliminates the player released the button,
+// returns the count of remaining holders and false if the player was not holding.
+func (db *DB) EliminateTournamentPlayer(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+	now int64,
+) (int64, bool, error) {
+	return db.redis.eliminateTournamentPlayer(
+		clientId,
+		roomId,
+		userID,
+		now,
+	)
+}
+
+// FinishTournament finishes the tournament if it is over and stores its final standing,
+// returns false if the tournament is not finished by this call.
+func (db *DB) FinishTournament(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	tournamentId int64,
+	now int64,
+) ([]protocol.TournamentStanding, bool, error) {
+	standings, finished, err := db.redis.finishTournament(
+		clientId,
+		roomId,
+		now,
+	)
+	if err != nil || !finished || len(standings) == 0 {
+		return standings, finished, err
+	}
+	return standings, finished, db.postgres.addTournamentResult(
+		clientId,
+		roomId,
+		tournamentId,
+		standings,
+	)
+}
+
+// GetTournamentResults retrieves final standings of the latest finished tournaments of the room.
+func (db *DB) GetTournamentResults(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	count int64,
+) ([]protocol.TournamentResult, error) {
+	return db.postgres.getTournamentResults(
+		clientId,
+		roomId,
+		count,
+	)
+}
+
+// PublishTournamentUpdate publishes the tournament event to every instance.
+func (db *DB) PublishTournamentUpdate(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	update protocol.TournamentUpdate,
+) error {
+	return db.redis.publishTournamentUpdate(
+		clientId,
+		roomId,
+		update,
+	)
+}
+
+// SubscribeTournamentUpdates subscribes to tournament events of the room.
+func (db *DB) SubscribeTournamentUpdates(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (<-chan protocol.TournamentUpdate, func() error) {
+	return db.redis.subscribeTournamentUpdates(
+		clientId,
+		roomId,
+	)
+}
+
+// BanRoomUser bans the user from the room, zero duration bans forever.
+func (db *DB) BanRoomUser(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+	duration time.Duration,
+) error {
+	return db.redis.restrictRoomUser(
+		clientId,
+		roomId,
+		RedisKeyRoomBans,
+		userID,
+		duration,
+	)
+}
+
+// UnbanRoomUser lifts the user's ban in the room.
+func (db *DB) UnbanRoomUser(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+) error {
+	return db.redis.unrestrictRoomUser(
+		clientId,
+		roomId,
+		RedisKeyRoomBans,
+		userID,
+	)
+}
+
+// IsRoomUserBanned checks if the user is banned from the room.
+func (db *DB) IsRoomUserBanned(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+) (bool, error) {
+	return db.redis.isRoomUserRestricted(
+		clientId,
+		roomId,
+		RedisKeyRoomBans,
+		userID,
+	)
+}
+
+// MuteRoomUser mutes the user in the room chat, zero duration mutes forever.
+func (db *DB) MuteRoomUser(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+	duration time.Duration,
+) error {
+	return db.redis.restrictRoomUser(
+		clientId,
+		roomId,
+		RedisKeyRoomMutes,
+		userID,
+		duration,
+	)
+}
+
+// UnmuteRoomUser lifts the user's mute in the room chat.
+func (db *DB) UnmuteRoomUser(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+) error {
+	return db.redis.unrestrictRoomUser(
+		clientId,
+		roomId,
+		RedisKeyRoomMutes,
+		userID,
+	)
+}
+
+// IsRoomUserMuted checks if the user is muted in the room chat.
+func (db *DB) IsRoomUserMuted(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+) (bool, error) {
+	return db.redis.isRoomUserRestricted(
+		clientId,
+		roomId,
+		RedisKeyRoomMutes,
+		userID,
+	)
+}
+
+// PublishRoomControl publishes room owner's action to every instance.
+func (db *DB) PublishRoomControl(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	control protocol.RoomControl,
+) error {
+	return db.redis.publishRoomControl(
+		clientId,
+		roomId,
+		control,
+	)
+}
+
+// SubscribeRoomControl subscribes to room owner's actions.
+// Returned function must be called to unsubscribe.
+func (db *DB) SubscribeRoomControl(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (<-chan protocol.RoomControl, func() error) {
+	return db.redis.subscribeRoomControl(
+		clientId,
+		roomId,
+	)
+}
+
+// GetActiveSessions retrieves users holding the button in the room with their durations.
+func (db *DB) GetActiveSessions(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) ([]protocol.RoomHolder, error) {
+	return db.redis.getActiveSessions(
+		clientId,
+		roomId,
+	)
+}
+
+// AddWebhookDelivery logs the webhook delivery attempt.
+func (db *DB) AddWebhookDelivery(
+	event protocol.WebhookEvent,
+	url string,
+	attempt int,
+	status int,
+	deliveryErr string,
+) error {
+	return db.postgres.addWebhookDelivery(
+		event,
+		url,
+		attempt,
+		status,
+		deliveryErr,
+	)
+}
+
+// RemoveExpiredWebhookDeliveries removes webhook delivery log entries older than the retention period.
+func (db *DB) RemoveExpiredWebhookDeliveries(retention time.Duration) (int64, error) {
+	return db.postgres.removeExpiredWebhookDeliveries(retention)
+}
+
+// GetWebhookDeliveries retrieves the latest webhook delivery attempts of the room, newest first.
+func (db *DB) GetWebhookDeliveries(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	count int64,
+) ([]protocol.WebhookDelivery, error) {
+	return db.postgres.getWebhookDeliveries(
+		clientId,
+		roomId,
+		count,
+	)
+}
+
+// AddWebhookRetry schedules the next attempt of the failed webhook delivery.
+func (db *DB) AddWebhookRetry(
+	event protocol.WebhookEvent,
+	url string,
+	attempt int,
+	due time.Time,
+) error {
+	return db.postgres.addWebhookRetry(
+		event,
+		url,
+		attempt,
+		due,
+	)
+}
+
+// ClaimWebhookRetries removes and returns webhook retries due by now, every retry is claimed
+// by one instance only.
+func (db *DB) ClaimWebhookRetries(count int64) ([]WebhookRetry, error) {
+	return db.postgres.claimWebhookRetries(count)
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"time"
+
+	"buttonmania.win/protocol"
+	"github.com/jackc/pgx/v5"
+)
+
+// retrieves users with the best holds started within the event window along with their profiles,
+// records are stamped with the end of the hold.
+func (p *Postgres) getEventLeaderboard(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	opens time.Time,
+	closes time.Time,
+	count int64,
+) ([]protocol.LeaderboardEntry, error) {
+	rows, err := p.queryReadOnly(
+		`SELECT r.user_id, r.duration, u.name, u.username, u.photo_url, u.premium, u.language, u.privacy
+		FROM (
+			SELECT user_id, MAX(duration) AS duration
+			FROM records
+			WHERE client_id=$1 AND room_id=$2 AND ts >= $3 AND duration > 0
+				AND ts - duration * INTERVAL '1 second' >= $3
+				AND ts - duration * INTERVAL '1 second' < $4
+			GROUP BY user_id
+			ORDER BY duration DESC
+			LIMIT $5
+		) r
+		LEFT JOIN users u ON u.user_id = r.user_id
+		ORDER BY r.duration DESC`,
+		clientId,
+		roomId,
+		opens,
+		closes,
+		count,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (protocol.LeaderboardEntry, error) {
+		var entry protocol.LeaderboardEntry
+		err := scanUserProfile(row, &entry.User, &entry.User.UserID, &entry.Duration)
+		return entry, err
+	})
+}
@@ -0,0 +1,179 @@
+package db
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"buttonmania.win/protocol"
+	redis "github.com/go-redis/redis/v8"
+)
+
+// Define room invite errors
+var (
+	ErrInviteInvalid   = errors.New("invite code is invalid")
+	ErrInviteExpired   = errors.New("invite code has expired")
+	ErrInviteExhausted = errors.New("invite code has been used up")
+)
+
+// Define fields of the room invite hash
+const (
+	roomInviteCode    = "code"
+	roomInviteExpires = "expires"
+	roomInviteMaxUses = "maxuses"
+	roomInviteUses    = "uses"
+)
+
+// issue the room invite replacing the previous one, the invite code is indexed within the client.
+func (r *Redis) setRoomInvite(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	invite protocol.RoomInvite,
+) error {
+	if err := r.removeRoomInvite(clientId, roomId); err != nil {
+		return err
+	}
+	inviteKey := roomTaggedKey(clientId, roomId, RedisKeyRoomInvite)
+	if err := r.client.HSet(
+		r.ctx,
+		inviteKey,
+		roomInviteCode, invite.Code,
+		roomInviteExpires, invite.Expires,
+		roomInviteMaxUses, invite.MaxUses,
+		roomInviteUses, 0,
+	).Err(); err != nil {
+		return err
+	}
+	invitesKey := clientTaggedKey(clientId, RedisKeyInvites)
+	return r.client.HSet(
+		r.ctx,
+		invitesKey,
+		invite.Code,
+		string(roomId),
+	).Err()
+}
+
+// get the room invite, nil if not issued.
+func (r *Redis) getRoomInvite(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (*protocol.RoomInvite, error) {
+	inviteKey := roomTaggedKey(clientId, roomId, RedisKeyRoomInvite)
+	fields, err := r.client.HGetAll(
+		r.ctx,
+		inviteKey,
+	).Result()
+	if err != nil || fields[roomInviteCode] == "" {
+		return nil, err
+	}
+	expires, _ := strconv.ParseInt(fields[roomInviteExpires], 10, 64)
+	maxUses, _ := strconv.ParseInt(fields[roomInviteMaxUses], 10, 64)
+	uses, _ := strconv.ParseInt(fields[roomInviteUses], 10, 64)
+	return &protocol.RoomInvite{
+		Code:    fields[roomInviteCode],
+		Expires: expires,
+		MaxUses: maxUses,
+		Uses:    uses,
+	}, nil
+}
+
+// revoke the room invite and remove its code from the client index.
+func (r *Redis) removeRoomInvite(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) error {
+	inviteKey := roomTaggedKey(clientId, roomId, RedisKeyRoomInvite)
+	code, err := r.client.HGet(
+		r.ctx,
+		inviteKey,
+		roomInviteCode,
+	).Result()
+	if err == redis.Nil {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err := r.client.Del(r.ctx, inviteKey).Err(); err != nil {
+		return err
+	}
+	invitesKey := clientTaggedKey(clientId, RedisKeyInvites)
+	return r.client.HDel(
+		r.ctx,
+		invitesKey,
+		code,
+	).Err()
+}
+
+// find the room the invite code was issued for, empty if the code is unknown.
+func (r *Redis) resolveInvite(
+	clientId protocol.ClientID,
+	code string,
+) (protocol.RoomID, error) {
+	invitesKey := clientTaggedKey(clientId, RedisKeyInvites)
+	roomId, err := r.client.HGet(
+		r.ctx,
+		invitesKey,
+		code,
+	).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return protocol.RoomID(roomId), err
+}
+
+// redeem the room invite code making the user a room member.
+func (r *Redis) redeemRoomInvite(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	code string,
+	userID protocol.UserID,
+) error {
+	inviteKey := roomTaggedKey(clientId, roomId, RedisKeyRoomInvite)
+	membersKey := roomTaggedKey(clientId, roomId, RedisKeyRoomMembers)
+	result, err := redeemInviteScript.Run(
+		r.ctx,
+		r.client,
+		[]string{inviteKey, membersKey},
+		code,
+		string(userID),
+		time.Now().Unix(),
+	).Int64()
+	if err != nil {
+		return err
+	}
+	switch result {
+	case 0:
+		return ErrInviteInvalid
+	case -1:
+		return ErrInviteExpired
+	case -2:
+		return ErrInviteExhausted
+	}
+	return nil
+}
+
+// check if the user has joined the room by invite.
+func (r *Redis) isRoomMember(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+) (bool, error) {
+	membersKey := roomTaggedKey(clientId, roomId, RedisKeyRoomMembers)
+	return r.client.SIsMember(
+		r.ctx,
+		membersKey,
+		string(userID),
+	).Result()
+}
+
+// remove members of the room.
+func (r *Redis) removeRoomMembers(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) error {
+	membersKey := roomTaggedKey(clientId, roomId, RedisKeyRoomMembers)
+	return r.client.Del(
+		r.ctx,
+		membersKey,
+	).Err()
+}
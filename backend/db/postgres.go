@@ -3,6 +3,10 @@ package db
 import (
 	"context"
 	"errors"
+	"log"
+	"maps"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"buttonmania.win/protocol"
@@ -10,16 +14,79 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const (
+	// Replica health check interval
+	replicaCheckInterval = 5 * time.Second
+	// Expired chat history removal interval
+	chatRetentionInterval = time.Hour
+	// Lifetime of the cached activity of client rooms
+	roomsActivityTtl = time.Minute
+)
+
+// roomsActivity keeps the activity of client rooms aggregated from records until it expires.
+type roomsActivity struct {
+	rooms   map[protocol.RoomID]protocol.RoomActivity
+	expires time.Time
+}
+
 // Postgres represents the postgres client.
 type Postgres struct {
-	ctx  context.Context
-	pool *pgxpool.Pool
+	ctx            context.Context
+	pool           *pgxpool.Pool
+	replica        *pgxpool.Pool
+	replicaHealthy atomic.Bool
+	maxReplicaLag  time.Duration
+	chatRetention  time.Duration
+	activity       map[protocol.ClientID]roomsActivity
+	activityMu     sync.Mutex
+}
+
+// newPostgresPool creates a connection pool configured by context values.
+func newPostgresPool(ctx context.Context, postgresurl string) (*pgxpool.Pool, error) {
+	maxConns, _ := ctx.Value(KeyPostgresMaxConns).(int)
+	minConns, _ := ctx.Value(KeyPostgresMinConns).(int)
+	maxConnLifetime, _ := ctx.Value(KeyPostgresMaxConnLifetime).(int)
+	maxConnIdleTime, _ := ctx.Value(KeyPostgresMaxConnIdleTime).(int)
+	healthCheckPeriod, _ := ctx.Value(KeyPostgresHealthCheckPeriod).(int)
+	connectTimeout, _ := ctx.Value(KeyPostgresConnectTimeout).(int)
+
+	config, err := pgxpool.ParseConfig(postgresurl)
+	if err != nil {
+		return nil, err
+	}
+	// Zero values keep defaults of the url or the driver
+	if maxConns > 0 {
+		config.MaxConns = int32(maxConns)
+	}
+	if minConns > 0 {
+		config.MinConns = int32(minConns)
+	}
+	if maxConnLifetime > 0 {
+		config.MaxConnLifetime = time.Duration(maxConnLifetime) * time.Second
+	}
+	if maxConnIdleTime > 0 {
+		config.MaxConnIdleTime = time.Duration(maxConnIdleTime) * time.Second
+	}
+	if healthCheckPeriod > 0 {
+		config.HealthCheckPeriod = time.Duration(healthCheckPeriod) * time.Second
+	}
+	if connectTimeout > 0 {
+		config.ConnConfig.ConnectTimeout = time.Duration(connectTimeout) * time.Second
+	}
+	return pgxpool.NewWithConfig(ctx, config)
 }
 
 // NewPostgres creates a new postgres instance.
 func NewPostgres(ctx context.Context) (*Postgres, error) {
 	postgresurl, _ := ctx.Value(KeyPostgresUrl).(string)
-	pool, err := pgxpool.New(ctx, postgresurl)
+	replicaurl, _ := ctx.Value(KeyPostgresReplicaUrl).(string)
+	maxReplicaLag, _ := ctx.Value(KeyPostgresMaxReplicaLag).(int)
+	chatRetention, _ := ctx.Value(KeyChatRetention).(int)
+
+	pool, err := newPostgresPool(ctx, postgresurl)
+	if err != nil {
+		return nil, err
+	}
 
 	// create records table
 	_, createTableErr := pool.Exec(
@@ -42,6 +109,159 @@ func NewPostgres(ctx context.Context) (*Postgres, error) {
 	_, createTsIdxErr := pool.Exec(ctx, "CREATE INDEX IF NOT EXISTS idx_ts ON records(ts)")
 	_, createDurationIdxErr := pool.Exec(ctx, "CREATE INDEX IF NOT EXISTS idx_duration ON records(duration)")
 
+	// create chat messages table
+	_, createChatTableErr := pool.Exec(
+		ctx,
+		`CREATE TABLE IF NOT EXISTS chat_messages (
+			id BIGSERIAL PRIMARY KEY,
+			user_id VARCHAR(36) NOT NULL,
+			client_id VARCHAR(36) NOT NULL,
+			room_id VARCHAR(36) NOT NULL,
+			ts TIMESTAMP NOT NULL DEFAULT current_timestamp,
+			message TEXT NOT NULL
+		);`,
+	)
+	_, createChatRoomIdxErr := pool.Exec(ctx, "CREATE INDEX IF NOT EXISTS idx_chat_room ON chat_messages(client_id, room_id, id)")
+	_, createChatTsIdxErr := pool.Exec(ctx, "CREATE INDEX IF NOT EXISTS idx_chat_ts ON chat_messages(ts)")
+
+	// create user profiles table
+	_, createUsersTableErr := pool.Exec(
+		ctx,
+		`CREATE TABLE IF NOT EXISTS users (
+			user_id VARCHAR(36) PRIMARY KEY,
+			name VARCHAR(64) NOT NULL DEFAULT '',
+			username VARCHAR(32) NOT NULL DEFAULT '',
+			photo_url TEXT NOT NULL DEFAULT '',
+			premium BOOLEAN NOT NULL DEFAULT false,
+			language VARCHAR(35) NOT NULL DEFAULT '',
+			privacy VARCHAR(16) NOT NULL DEFAULT 'name',
+			updated_at TIMESTAMP NOT NULL DEFAULT current_timestamp
+		);`,
+	)
+
+	// create custom room message packs table
+	_, createRoomMessagesTableErr := pool.Exec(
+		ctx,
+		`CREATE TABLE IF NOT EXISTS room_messages (
+			client_id VARCHAR(36) NOT NULL,
+			room_id VARCHAR(36) NOT NULL,
+			locale VARCHAR(8) NOT NULL,
+			messages TEXT[] NOT NULL,
+			updated_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+			PRIMARY KEY (client_id, room_id, locale)
+		);`,
+	)
+
+	// create archived custom rooms table, records of archived rooms are kept
+	_, createArchivedRoomsTableErr := pool.Exec(
+		ctx,
+		`CREATE TABLE IF NOT EXISTS archived_rooms (
+			client_id VARCHAR(36) NOT NULL,
+			room_id VARCHAR(36) NOT NULL,
+			owner_id VARCHAR(36) NOT NULL,
+			title VARCHAR(64) NOT NULL DEFAULT '',
+			description TEXT NOT NULL DEFAULT '',
+			icon VARCHAR(32) NOT NULL DEFAULT '',
+			locale VARCHAR(8) NOT NULL DEFAULT '',
+			private BOOLEAN NOT NULL DEFAULT false,
+			created_at TIMESTAMP NOT NULL,
+			archived_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+			PRIMARY KEY (client_id, room_id)
+		);`,
+	)
+
+	// create archived leaderboards table, the best holds of the room are kept at archiving
+	_, createArchivedLeaderboardsTableErr := pool.Exec(
+		ctx,
+		`CREATE TABLE IF NOT EXISTS archived_leaderboards (
+			client_id VARCHAR(36) NOT NULL,
+			room_id VARCHAR(36) NOT NULL,
+			user_id VARCHAR(36) NOT NULL,
+			duration INTEGER NOT NULL,
+			PRIMARY KEY (client_id, room_id, user_id)
+		);`,
+	)
+
+	// create tournament results table, the tournament is identified by its start time
+	_, createTournamentResultsTableErr := pool.Exec(
+		ctx,
+		`CREATE TABLE IF NOT EXISTS tournament_results (
+			client_id VARCHAR(36) NOT NULL,
+			room_id VARCHAR(36) NOT NULL,
+			tournament_id BIGINT NOT NULL,
+			user_id VARCHAR(36) NOT NULL,
+			place INTEGER NOT NULL,
+			duration BIGINT NOT NULL,
+			finished_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+			PRIMARY KEY (client_id, room_id, tournament_id, user_id)
+		);`,
+	)
+
+	// create team records table, holds of team rooms are kept apart from personal records
+	_, createTeamRecordsTableErr := pool.Exec(
+		ctx,
+		`CREATE TABLE IF NOT EXISTS team_records (
+			id BIGSERIAL PRIMARY KEY,
+			client_id VARCHAR(36) NOT NULL,
+			room_id VARCHAR(36) NOT NULL,
+			team VARCHAR(24) NOT NULL,
+			user_id VARCHAR(36) NOT NULL,
+			ts TIMESTAMP NOT NULL DEFAULT current_timestamp,
+			duration BIGINT NOT NULL,
+			UNIQUE (client_id, room_id, team, user_id, ts)
+		);`,
+	)
+	_, createTeamRoomIdxErr := pool.Exec(ctx, "CREATE INDEX IF NOT EXISTS idx_team_room ON team_records(client_id, room_id, team)")
+
+	// create chain records table with collective holds of chain rooms
+	_, createChainRecordsTableErr := pool.Exec(
+		ctx,
+		`CREATE TABLE IF NOT EXISTS chain_records (
+			id BIGSERIAL PRIMARY KEY,
+			client_id VARCHAR(36) NOT NULL,
+			room_id VARCHAR(36) NOT NULL,
+			started TIMESTAMP NOT NULL,
+			duration BIGINT NOT NULL,
+			participants INTEGER NOT NULL,
+			peak INTEGER NOT NULL,
+			UNIQUE (client_id, room_id, started)
+		);`,
+	)
+	_, createChainRoomIdxErr := pool.Exec(ctx, "CREATE INDEX IF NOT EXISTS idx_chain_room ON chain_records(client_id, room_id, duration)")
+
+	// create webhook delivery log, every delivery attempt is logged
+	_, createWebhookDeliveriesTableErr := pool.Exec(
+		ctx,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id BIGSERIAL PRIMARY KEY,
+			delivery_id VARCHAR(36) NOT NULL,
+			client_id VARCHAR(36) NOT NULL,
+			room_id VARCHAR(36) NOT NULL,
+			event VARCHAR(32) NOT NULL,
+			url TEXT NOT NULL,
+			attempt INTEGER NOT NULL,
+			status INTEGER NOT NULL,
+			error TEXT NOT NULL DEFAULT '',
+			ts TIMESTAMP NOT NULL DEFAULT current_timestamp
+		);`,
+	)
+	_, createWebhookTsIdxErr := pool.Exec(ctx, "CREATE INDEX IF NOT EXISTS idx_webhook_ts ON webhook_deliveries(ts)")
+	_, createWebhookClientIdxErr := pool.Exec(ctx, "CREATE INDEX IF NOT EXISTS idx_webhook_client ON webhook_deliveries(client_id, id)")
+	_, createWebhookRoomIdxErr := pool.Exec(ctx, "CREATE INDEX IF NOT EXISTS idx_webhook_room ON webhook_deliveries(client_id, room_id, id)")
+
+	// create webhook retries table, failed deliveries wait there for the next attempt
+	_, createWebhookRetriesTableErr := pool.Exec(
+		ctx,
+		`CREATE TABLE IF NOT EXISTS webhook_retries (
+			id BIGSERIAL PRIMARY KEY,
+			url TEXT NOT NULL,
+			attempt INTEGER NOT NULL,
+			event TEXT NOT NULL,
+			due TIMESTAMP NOT NULL
+		);`,
+	)
+	_, createWebhookRetriesDueIdxErr := pool.Exec(ctx, "CREATE INDEX IF NOT EXISTS idx_webhook_retries_due ON webhook_retries(due)")
+
 	err = errors.Join(
 		err,
 		createTableErr,
@@ -50,20 +270,130 @@ func NewPostgres(ctx context.Context) (*Postgres, error) {
 		createRoomIdxErr,
 		createTsIdxErr,
 		createDurationIdxErr,
+		createChatTableErr,
+		createChatRoomIdxErr,
+		createChatTsIdxErr,
+		createUsersTableErr,
+		createRoomMessagesTableErr,
+		createArchivedRoomsTableErr,
+		createArchivedLeaderboardsTableErr,
+		createTournamentResultsTableErr,
+		createTeamRecordsTableErr,
+		createTeamRoomIdxErr,
+		createChainRecordsTableErr,
+		createChainRoomIdxErr,
+		createWebhookDeliveriesTableErr,
+		createWebhookTsIdxErr,
+		createWebhookClientIdxErr,
+		createWebhookRoomIdxErr,
+		createWebhookRetriesTableErr,
+		createWebhookRetriesDueIdxErr,
 	)
 
-	return &Postgres{
-		ctx:  ctx,
-		pool: pool,
-	}, err
+	p := &Postgres{
+		ctx:           ctx,
+		pool:          pool,
+		maxReplicaLag: time.Duration(maxReplicaLag) * time.Second,
+		chatRetention: time.Duration(chatRetention) * time.Hour,
+		activity:      make(map[protocol.ClientID]roomsActivity),
+	}
+
+	// Zero retention keeps chat history forever
+	if p.chatRetention > 0 {
+		go p.maintainChatHistory()
+	}
+
+	// Read-only queries are routed to the replica while it is healthy
+	if len(replicaurl) > 0 {
+		replica, replicaErr := newPostgresPool(ctx, replicaurl)
+		if replicaErr != nil {
+			p.close()
+			return nil, errors.Join(err, replicaErr)
+		}
+		p.replica = replica
+		p.checkReplica()
+		go p.maintainReplica()
+	}
+
+	return p, err
 }
 
 // closes the postgres connection.
 func (p *Postgres) close() error {
 	defer p.pool.Close()
+	if p.replica != nil {
+		defer p.replica.Close()
+	}
 	return nil
 }
 
+// checkReplica marks the replica healthy if it is reachable and not lagging behind.
+func (p *Postgres) checkReplica() {
+	var lag float64
+	err := p.replica.QueryRow(
+		p.ctx,
+		`SELECT CASE
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+		END`,
+	).Scan(&lag)
+	healthy := err == nil && time.Duration(lag*float64(time.Second)) <= p.maxReplicaLag
+	if healthy != p.replicaHealthy.Swap(healthy) {
+		if healthy {
+			log.Println("Postgres replica is healthy, routing reads to replica")
+		} else {
+			log.Println("Postgres replica is unhealthy, routing reads to primary:", err, lag)
+		}
+	}
+}
+
+// periodically checks the replica health until the context is done.
+func (p *Postgres) maintainReplica() {
+	ticker := time.NewTicker(replicaCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.checkReplica()
+		}
+	}
+}
+
+// queryReadOnlyInt64 runs read-only query returning single number on the replica,
+// falls back to the primary when the replica is unhealthy or fails.
+func (p *Postgres) queryReadOnlyInt64(sql string, args ...any) (int64, error) {
+	var value int64
+	if p.replica != nil && p.replicaHealthy.Load() {
+		err := p.replica.QueryRow(p.ctx, sql, args...).Scan(&value)
+		if err == nil || err == pgx.ErrNoRows {
+			return value, nil
+		}
+		p.replicaHealthy.Store(false)
+		log.Println("Postgres replica query failed, routing reads to primary:", err)
+	}
+	err := p.pool.QueryRow(p.ctx, sql, args...).Scan(&value)
+	if err == pgx.ErrNoRows {
+		return 0, nil
+	}
+	return value, err
+}
+
+// queryReadOnly runs read-only query on the replica,
+// falls back to the primary when the replica is unhealthy or fails.
+func (p *Postgres) queryReadOnly(sql string, args ...any) (pgx.Rows, error) {
+	if p.replica != nil && p.replicaHealthy.Load() {
+		rows, err := p.replica.Query(p.ctx, sql, args...)
+		if err == nil {
+			return rows, nil
+		}
+		p.replicaHealthy.Store(false)
+		log.Println("Postgres replica query failed, routing reads to primary:", err)
+	}
+	return p.pool.Query(p.ctx, sql, args...)
+}
+
 // adds a gameplay record to the leaderboard.
 func (p *Postgres) addRecordToLeaderboard(
 	clientId protocol.ClientID,
@@ -91,20 +421,14 @@ func (p *Postgres) getDurationPlaceInLeaderboard(
 	roomId protocol.RoomID,
 	duration int64,
 ) (int64, error) {
-	var count int64
-	err := p.pool.QueryRow(
-		p.ctx,
+	return p.queryReadOnlyInt64(
 		`SELECT COALESCE(count(DISTINCT duration), 0) 
 		FROM records 
 		WHERE client_id=$1 AND room_id=$2 AND duration > $3`,
 		clientId,
 		roomId,
 		duration,
-	).Scan(&count)
-	if err == pgx.ErrNoRows {
-		return 0, nil
-	}
-	return count, err
+	)
 }
 
 // retrieves the user's place in the leaderboard.
@@ -113,9 +437,7 @@ func (p *Postgres) getUserPlaceInLeaderboard(
 	roomId protocol.RoomID,
 	userID protocol.UserID,
 ) (int64, error) {
-	var count int64
-	err := p.pool.QueryRow(
-		p.ctx,
+	return p.queryReadOnlyInt64(
 		`SELECT COALESCE(count(*), 0)
 		FROM records 
 		WHERE duration > (
@@ -126,11 +448,7 @@ func (p *Postgres) getUserPlaceInLeaderboard(
 		clientId,
 		roomId,
 		userID,
-	).Scan(&count)
-	if err == pgx.ErrNoRows {
-		return 0, nil
-	}
-	return count, err
+	)
 }
 
 // retrieves the count of users in the leaderboard.
@@ -138,19 +456,55 @@ func (p *Postgres) getUsersCountInLeaderboard(
 	clientId protocol.ClientID,
 	roomId protocol.RoomID,
 ) (int64, error) {
-	var count int64
-	err := p.pool.QueryRow(
-		p.ctx,
+	return p.queryReadOnlyInt64(
 		`SELECT COALESCE(count(DISTINCT user_id), 0)
 		FROM records 
 		WHERE client_id=$1 AND room_id=$2 AND duration > 0`,
 		clientId,
 		roomId,
-	).Scan(&count)
-	if err == pgx.ErrNoRows {
-		return 0, nil
+	)
+}
+
+// retrieves the count of players and the last record time in each room of given client,
+// the aggregate is cached for a minute as it scans every record of the client.
+func (p *Postgres) getRoomsActivity(
+	clientId protocol.ClientID,
+) (map[protocol.RoomID]protocol.RoomActivity, error) {
+	p.activityMu.Lock()
+	defer p.activityMu.Unlock()
+	now := time.Now()
+	if cached, ok := p.activity[clientId]; ok && now.Before(cached.expires) {
+		return maps.Clone(cached.rooms), nil
+	}
+	rows, err := p.queryReadOnly(
+		`SELECT room_id, count(DISTINCT user_id), MAX(ts)
+		FROM records
+		WHERE client_id=$1 AND duration > 0
+		GROUP BY room_id`,
+		clientId,
+	)
+	if err != nil {
+		return nil, err
 	}
-	return count, err
+	activity := make(map[protocol.RoomID]protocol.RoomActivity)
+	var roomId protocol.RoomID
+	var players int64
+	var ts time.Time
+	_, err = pgx.ForEachRow(rows, []any{&roomId, &players, &ts}, func() error {
+		activity[roomId] = protocol.RoomActivity{
+			Players:      players,
+			LastActivity: ts.Unix(),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.activity[clientId] = roomsActivity{
+		rooms:   activity,
+		expires: now.Add(roomsActivityTtl),
+	}
+	return maps.Clone(activity), nil
 }
 
 // retrieves the best duration achieved by a player in the leaderboard.
@@ -158,19 +512,13 @@ func (p *Postgres) getBestOverallDurationInLeaderboard(
 	clientId protocol.ClientID,
 	roomId protocol.RoomID,
 ) (int64, error) {
-	var duration int64
-	err := p.pool.QueryRow(
-		p.ctx,
+	return p.queryReadOnlyInt64(
 		`SELECT COALESCE(MAX(duration), 0)
 		FROM records 
 		WHERE client_id=$1 AND room_id=$2 AND duration > 0`,
 		clientId,
 		roomId,
-	).Scan(&duration)
-	if err == pgx.ErrNoRows {
-		return 0, nil
-	}
-	return duration, err
+	)
 }
 
 // retrieves today's best duration from the leaderboard.
@@ -178,17 +526,11 @@ func (p *Postgres) getTodaysDurationInLeaderboard(
 	clientId protocol.ClientID,
 	roomId protocol.RoomID,
 ) (int64, error) {
-	var duration int64
-	err := p.pool.QueryRow(
-		p.ctx,
+	return p.queryReadOnlyInt64(
 		`SELECT COALESCE(MAX(duration), 0)
 		FROM records 
 		WHERE client_id=$1 AND room_id=$2 AND ts >= now()::date AND duration > 0`,
 		clientId,
 		roomId,
-	).Scan(&duration)
-	if err == pgx.ErrNoRows {
-		return 0, nil
-	}
-	return duration, err
+	)
 }
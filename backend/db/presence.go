@@ -0,0 +1,161 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"buttonmania.win/protocol"
+	redis "github.com/go-redis/redis/v8"
+)
+
+// presenceCounters holds online counters of a single client.
+type presenceCounters struct {
+	users map[string]int64
+	rooms map[string]int64
+}
+
+// newPresenceCounters creates empty presence counters.
+func newPresenceCounters() *presenceCounters {
+	return &presenceCounters{
+		users: make(map[string]int64),
+		rooms: make(map[string]int64),
+	}
+}
+
+// increments presence counters after the user joined active sessions of the room.
+func (r *Redis) presenceJoin(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+) error {
+	onlineUsersKey := clientTaggedKey(clientId, RedisKeyOnlineUsers)
+	onlineRoomsKey := clientTaggedKey(clientId, RedisKeyOnlineRooms)
+	presenceKey := clientTaggedKey(clientId, RedisKeyPresence)
+	pipe := r.client.TxPipeline()
+	pipe.HIncrBy(r.ctx, onlineUsersKey, string(userID), 1)
+	pipe.HIncrBy(r.ctx, onlineRoomsKey, string(roomId), 1)
+	pipe.Incr(r.ctx, presenceKey)
+	_, err := pipe.Exec(r.ctx)
+	return err
+}
+
+// decrements presence counters after the user left active sessions of the room.
+func (r *Redis) presenceLeave(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+) error {
+	onlineUsersKey := clientTaggedKey(clientId, RedisKeyOnlineUsers)
+	onlineRoomsKey := clientTaggedKey(clientId, RedisKeyOnlineRooms)
+	presenceKey := clientTaggedKey(clientId, RedisKeyPresence)
+	return presenceLeaveScript.Run(
+		r.ctx,
+		r.client,
+		[]string{onlineUsersKey, onlineRoomsKey, presenceKey},
+		string(userID),
+		string(roomId),
+	).Err()
+}
+
+// retrieves the count of online users of given client.
+func (r *Redis) getOnlineUsersCount(
+	clientId protocol.ClientID,
+) (int64, error) {
+	onlineUsersKey := clientTaggedKey(clientId, RedisKeyOnlineUsers)
+	return r.client.HLen(r.ctx, onlineUsersKey).Result()
+}
+
+// retrieves the count of online users in each room of given client.
+func (r *Redis) getOnlineUsersCountByRoom(
+	clientId protocol.ClientID,
+) (map[protocol.RoomID]int64, error) {
+	counts := make(map[protocol.RoomID]int64)
+	onlineRoomsKey := clientTaggedKey(clientId, RedisKeyOnlineRooms)
+	result, err := r.client.HGetAll(r.ctx, onlineRoomsKey).Result()
+	if err != nil {
+		return counts, err
+	}
+	for roomIdStr, countStr := range result {
+		count, err := strconv.ParseInt(countStr, 10, 64)
+		if err != nil || count <= 0 {
+			continue
+		}
+		counts[protocol.RoomID(roomIdStr)] = count
+	}
+	return counts, nil
+}
+
+// rebuilds presence counters of all clients from active sessions sorted sets.
+func (r *Redis) reconcilePresence() error {
+	// Collect active sessions sets of every client
+	roomsByClient := make(map[protocol.ClientID][]protocol.RoomID)
+	pattern := roomTaggedKey("*", "*", RedisKeyActiveSessions)
+	err := r.scanKeys(pattern, func(key string) {
+		clientId, roomId, ok := parseRoomTaggedKey(key, RedisKeyActiveSessions)
+		if ok {
+			roomsByClient[clientId] = append(roomsByClient[clientId], roomId)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	// Clients which have counters but no active sessions must be reset too
+	pattern = clientTaggedKey("*", RedisKeyOnlineUsers)
+	trimStr := fmt.Sprintf("}:%s", RedisKeyOnlineUsers)
+	err = r.scanKeys(pattern, func(key string) {
+		clientId := protocol.ClientID(strings.TrimPrefix(strings.TrimSuffix(key, trimStr), "{"))
+		if _, exists := roomsByClient[clientId]; !exists {
+			roomsByClient[clientId] = nil
+		}
+	})
+	for clientId, roomIds := range roomsByClient {
+		err = errors.Join(err, r.reconcileClientPresence(clientId, roomIds))
+	}
+	return err
+}
+
+// rebuilds presence counters of the client from active sessions of given rooms, counters
+// changed by joins or leaves in the meantime are left for the next reconciliation.
+func (r *Redis) reconcileClientPresence(
+	clientId protocol.ClientID,
+	roomIds []protocol.RoomID,
+) error {
+	onlineUsersKey := clientTaggedKey(clientId, RedisKeyOnlineUsers)
+	onlineRoomsKey := clientTaggedKey(clientId, RedisKeyOnlineRooms)
+	presenceKey := clientTaggedKey(clientId, RedisKeyPresence)
+	version, err := r.client.Get(r.ctx, presenceKey).Int64()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	// Count members of every active sessions set
+	c := newPresenceCounters()
+	for _, roomId := range roomIds {
+		sessionsKey := roomTaggedKey(clientId, roomId, RedisKeyActiveSessions)
+		users, err := r.client.ZRange(r.ctx, sessionsKey, 0, -1).Result()
+		if err != nil {
+			return err
+		}
+		for _, u := range users {
+			c.users[u]++
+		}
+		if len(users) > 0 {
+			c.rooms[string(roomId)] += int64(len(users))
+		}
+	}
+	args := make([]interface{}, 0, 2+2*len(c.users)+2*len(c.rooms))
+	args = append(args, version, len(c.users))
+	for userID, count := range c.users {
+		args = append(args, userID, count)
+	}
+	for roomId, count := range c.rooms {
+		args = append(args, roomId, count)
+	}
+	return reconcilePresenceScript.Run(
+		r.ctx,
+		r.client,
+		[]string{onlineUsersKey, onlineRoomsKey, presenceKey},
+		args...,
+	).Err()
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"buttonmania.win/protocol"
+	"github.com/jackc/pgx/v5"
+)
+
+// inserts or refreshes user's profile, keeps the privacy setting chosen by the user.
+// Returns the stored profile.
+func (p *Postgres) upsertUserProfile(
+	profile protocol.UserProfile,
+) (protocol.UserProfile, error) {
+	err := p.pool.QueryRow(
+		p.ctx,
+		`INSERT INTO users(user_id, name, username, photo_url, premium, language)
+		VALUES($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE SET
+			name = EXCLUDED.name,
+			username = EXCLUDED.username,
+			photo_url = EXCLUDED.photo_url,
+			premium = EXCLUDED.premium,
+			language = EXCLUDED.language,
+			updated_at = current_timestamp
+		RETURNING privacy`,
+		profile.UserID,
+		profile.Name,
+		profile.Username,
+		profile.PhotoURL,
+		profile.Premium,
+		profile.Language,
+	).Scan(&profile.Privacy)
+	return profile, err
+}
+
+// updates user's privacy setting, creates an empty profile if the user is unknown.
+func (p *Postgres) setUserPrivacy(
+	userID protocol.UserID,
+	privacy protocol.UserPrivacy,
+) error {
+	_, err := p.pool.Exec(
+		p.ctx,
+		`INSERT INTO users(user_id, privacy)
+		VALUES($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET privacy = EXCLUDED.privacy`,
+		userID,
+		privacy,
+	)
+	return err
+}
+
+// scanUserProfile scans the profile columns, unknown users have null columns.
+func scanUserProfile(
+	row pgx.CollectableRow,
+	profile *protocol.UserProfile,
+	dest ...any,
+) error {
+	var name, username, photoURL, language, privacy *string
+	var premium *bool
+	dest = append(dest, &name, &username, &photoURL, &premium, &language, &privacy)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	profile.Privacy = protocol.PrivacyName
+	if name != nil {
+		profile.Name = *name
+		profile.Username = *username
+		profile.PhotoURL = *photoURL
+		profile.Premium = *premium
+		profile.Language = *language
+		profile.Privacy = protocol.UserPrivacy(*privacy)
+	}
+	return nil
+}
+
+// retrieves profiles of given users, unknown users get profiles with id only.
+func (p *Postgres) getUserProfiles(
+	userIDs []protocol.UserID,
+) ([]protocol.UserProfile, error) {
+	ids := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		ids[i] = string(userID)
+	}
+	rows, err := p.queryReadOnly(
+		`SELECT ids.user_id, u.name, u.username, u.photo_url, u.premium, u.language, u.privacy
+		FROM unnest($1::VARCHAR[]) WITH ORDINALITY AS ids(user_id, n)
+		LEFT JOIN users u ON u.user_id = ids.user_id
+		ORDER BY ids.n`,
+		ids,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (protocol.UserProfile, error) {
+		var profile protocol.UserProfile
+		err := scanUserProfile(row, &profile, &profile.UserID)
+		return profile, err
+	})
+}
+
+// retrieves users with the best records in the leaderboard along with their profiles.
+func (p *Postgres) getLeaderboard(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	count int64,
+) ([]protocol.LeaderboardEntry, error) {
+	rows, err := p.queryReadOnly(
+		`SELECT r.user_id, r.duration, u.name, u.username, u.photo_url, u.premium, u.language, u.privacy
+		FROM (
+			SELECT user_id, MAX(duration) AS duration
+			FROM records
+			WHERE client_id=$1 AND room_id=$2 AND duration > 0
+			GROUP BY user_id
+			ORDER BY duration DESC
+			LIMIT $3
+		) r
+		LEFT JOIN users u ON u.user_id = r.user_id
+		ORDER BY r.duration DESC`,
+		clientId,
+		roomId,
+		count,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (protocol.LeaderboardEntry, error) {
+		var entry protocol.LeaderboardEntry
+		err := scanUserProfile(row, &entry.User, &entry.User.UserID, &entry.Duration)
+		return entry, err
+	})
+}
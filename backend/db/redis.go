@@ -2,12 +2,12 @@ package db
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
 	"fmt"
-	"math/rand"
+	"log"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"buttonmania.win/protocol"
@@ -21,112 +21,185 @@ type RedisKey string
 // Redis represents the redis client.
 type Redis struct {
 	ctx    context.Context
-	client *redis.Client
+	client redis.UniversalClient
+	bus    *roomBus
 }
 
 const (
 	// RedisKey represents Redis custom keys.
 	RedisKeyActiveSessions RedisKey = "sessions"
 	RedisKeySessionTs      RedisKey = "sessionts"
+	RedisKeySessionPush    RedisKey = "sessionpush"
 	RedisKeyCustomRooms    RedisKey = "rooms"
+	RedisKeyRoomDirectory  RedisKey = "directory"
+	RedisKeyOwnedRooms     RedisKey = "owned"
 	RedisKeyPayloads       RedisKey = "payloads"
 	RedisKeyChat           RedisKey = "chat"
+	RedisKeyOnlineUsers    RedisKey = "online"
+	RedisKeyOnlineRooms    RedisKey = "onlinerooms"
+	RedisKeyPresence       RedisKey = "presence"
+	RedisKeyChatRate       RedisKey = "chatrate"
+	RedisKeyChatReports    RedisKey = "chatreports"
+	RedisKeyChatMute       RedisKey = "chatmute"
+	RedisKeyReactions      RedisKey = "reactions"
+	RedisKeyRoomControl    RedisKey = "control"
+	RedisKeyRoomBans       RedisKey = "bans"
+	RedisKeyRoomMutes      RedisKey = "mutes"
+	RedisKeyRoomMeta       RedisKey = "meta"
+	RedisKeyRoomInvite     RedisKey = "invite"
+	RedisKeyRoomMembers    RedisKey = "members"
+	RedisKeyInvites        RedisKey = "invites"
+	RedisKeyRoomModerators RedisKey = "moderators"
+	// Tournament state, registered players, holding players with push time,
+	// eliminated players in order and their hold durations
+	RedisKeyTournament          RedisKey = "tournament"
+	RedisKeyTournamentPlayers   RedisKey = "tplayers"
+	RedisKeyTournamentAlive     RedisKey = "talive"
+	RedisKeyTournamentOut       RedisKey = "tout"
+	RedisKeyTournamentDurations RedisKey = "tdurations"
+	RedisKeyTournamentEvents    RedisKey = "tevents"
+	// Teams of holding players and total hold time of finished holds per team
+	RedisKeyTeamMembers RedisKey = "teammembers"
+	RedisKeyTeamTime    RedisKey = "teamtime"
+	// Chain state, its current holders and every participant
+	RedisKeyChain        RedisKey = "chain"
+	RedisKeyChainHolders RedisKey = "chainholders"
+	RedisKeyChainPlayers RedisKey = "chainplayers"
 	// Session ttl handling constants
-	cleanupRandChance      = 5
-	sessionTtlSeconds      = 40
-	maxChatMessageInStream = 5
+	sessionTtlSeconds       = 40
+	maxExpiredSessionsBatch = 100
+)
+
+// Define active sessions errors
+var ErrSessionReaped = errors.New("session expired")
+
+// Define custom rooms errors
+var (
+	ErrRoomExists        = errors.New("room exist")
+	ErrRoomQuotaExceeded = errors.New("user owns too many rooms")
+	ErrRoomNotExist      = errors.New("room not exist")
+	ErrRoomNotOwned      = errors.New("room does not belong to the user")
 )
 
 // NewRedis creates a new redis instance.
 func NewRedis(ctx context.Context) (*Redis, error) {
-	redisaddress, _ := ctx.Value(KeyRedisAddress).(string)
-	redisusername, _ := ctx.Value(KeyRedisUsername).(string)
-	redispassword, _ := ctx.Value(KeyRedisPassword).(string)
-	redisdatabase, _ := ctx.Value(KeyRedisDatabase).(int)
-	redistls, _ := ctx.Value(KeyRedisTLS).(bool)
-
-	var tlsConfig *tls.Config
-	if redistls {
-		tlsConfig = &tls.Config{
-			MinVersion: tls.VersionTLS12,
-		}
+	mode, opts, err := newRedisOptions(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	client := redis.NewClient(&redis.Options{
-		Addr:      redisaddress,
-		Username:  redisusername,
-		Password:  redispassword,
-		DB:        redisdatabase,
-		TLSConfig: tlsConfig,
-	})
+	client := newRedisClient(mode, opts)
 	if err := client.Ping(ctx).Err(); err != nil {
 		return nil, err
 	}
 
-	return &Redis{
+	r := &Redis{
 		ctx:    ctx,
 		client: client,
-	}, nil
+		bus:    newRoomBus(),
+	}
+	// Owned rooms of custom rooms created before owned rooms were tracked
+	if err := r.reconcileOwnedRooms(); err != nil {
+		log.Println("Failed to reconcile owned rooms:", err)
+	}
+	// Directory of public custom rooms created before the directory was kept
+	if err := r.reconcileRoomDirectory(); err != nil {
+		log.Println("Failed to reconcile room directory:", err)
+	}
+	go r.receiveRoomChannels()
+	return r, nil
 }
 
-// closes the redis connection.
-func (r *Redis) close() error {
-	return r.client.Close()
+// roomTaggedKey builds key of the room, the hash tag keeps all keys
+// of the same client's room in one cluster slot.
+func roomTaggedKey(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	key RedisKey,
+) string {
+	return fmt.Sprintf(
+		"{%s:%s}:%s",
+		clientId,
+		roomId,
+		key,
+	)
 }
 
-// cleanup expired user sessions
-func (r *Redis) cleanupExpiredSessions(
-	activeSessionsKey string,
-	sessionTsKey string,
-	now int64,
-) error {
-	var err error
-	var expiredTtlsMembers []interface{}
-	expiredTtlScore := strconv.FormatInt(now-sessionTtlSeconds, 10)
-	expiredTtlsResult, zrgageTsErr := r.client.ZRangeByScore(
-		r.ctx,
-		sessionTsKey,
-		&redis.ZRangeBy{
-			Min: "-inf",
-			Max: expiredTtlScore,
-		},
-	).Result()
-	for _, v := range expiredTtlsResult {
-		expiredTtlsMembers = append(expiredTtlsMembers, v)
+// parseRoomTaggedKey extracts client and room identifiers from the room key.
+func parseRoomTaggedKey(taggedKey string, key RedisKey) (protocol.ClientID, protocol.RoomID, bool) {
+	tag, found := strings.CutSuffix(taggedKey, fmt.Sprintf("}:%s", key))
+	if !found || !strings.HasPrefix(tag, "{") {
+		return "", "", false
 	}
-	if len(expiredTtlsMembers) > 0 {
-		remExpiredSessionsErr := r.client.ZRem(
-			r.ctx,
-			activeSessionsKey,
-			expiredTtlsMembers...,
-		).Err()
-		remExpiredTsErr := r.client.ZRemRangeByScore(
-			r.ctx,
-			sessionTsKey,
-			"-inf",
-			expiredTtlScore,
-		).Err()
-		err = errors.Join(
-			zrgageTsErr,
-			remExpiredSessionsErr,
-			remExpiredTsErr,
-		)
+	clientIdStr, roomIdStr, found := strings.Cut(tag[1:], ":")
+	if !found {
+		return "", "", false
+	}
+	return protocol.ClientID(clientIdStr), protocol.RoomID(roomIdStr), true
+}
+
+// clientTaggedKey builds key of the client, the hash tag keeps all keys
+// of the same client in one cluster slot.
+func clientTaggedKey(
+	clientId protocol.ClientID,
+	key RedisKey,
+) string {
+	return fmt.Sprintf(
+		"{%s}:%s",
+		clientId,
+		key,
+	)
+}
+
+// ownedRoomsKey builds key of custom rooms owned by the user, the hash tag keeps it
+// in the cluster slot of the client's custom rooms hash, which key has no hash tag.
+func ownedRoomsKey(
+	clientId protocol.ClientID,
+	userID protocol.UserID,
+) string {
+	return fmt.Sprintf(
+		"{%s:%s}:%s:%s",
+		clientId,
+		RedisKeyCustomRooms,
+		RedisKeyOwnedRooms,
+		userID,
+	)
+}
+
+// iterates over keys matching the pattern, on each master node in cluster mode.
+func (r *Redis) scanKeys(pattern string, fn func(key string)) error {
+	var mu sync.Mutex
+	scanNode := func(ctx context.Context, client *redis.Client) error {
+		iter := client.Scan(ctx, 0, pattern, 0).Iterator()
+		for iter.Next(ctx) {
+			mu.Lock()
+			fn(iter.Val())
+			mu.Unlock()
+		}
+		return iter.Err()
+	}
+	switch client := r.client.(type) {
+	case *redis.ClusterClient:
+		return client.ForEachMaster(r.ctx, scanNode)
+	case *redis.Client:
+		return scanNode(r.ctx, client)
+	default:
+		return fmt.Errorf("unsupported redis client: %T", client)
 	}
-	return err
 }
 
-// retrieves the user's place in active sessions.
+// closes the redis connection.
+func (r *Redis) close() error {
+	return r.client.Close()
+}
+
+// retrieves the user's place in active sessions, only the separate calls benchmark uses it.
 func (r *Redis) getUserPlaceInActiveSessions(
 	clientId protocol.ClientID,
 	roomId protocol.RoomID,
 	userID protocol.UserID,
 ) (int64, error) {
-	activeSessionsKey := fmt.Sprintf(
-		"%s:%s:%s",
-		clientId,
-		RedisKeyActiveSessions,
-		roomId,
-	)
+	activeSessionsKey := roomTaggedKey(clientId, roomId, RedisKeyActiveSessions)
 	count, zCountErr := r.client.ZCount(
 		r.ctx,
 		activeSessionsKey,
@@ -146,50 +219,15 @@ func (r *Redis) getUsersCountInActiveSessions(
 	clientId protocol.ClientID,
 	roomId protocol.RoomID,
 ) (int64, error) {
-	activeSessionsKey := fmt.Sprintf(
-		"%s:%s:%s",
-		clientId,
-		RedisKeyActiveSessions,
-		roomId,
-	)
-	return r.client.ZCount(
+	activeSessionsKey := roomTaggedKey(clientId, roomId, RedisKeyActiveSessions)
+	return r.client.ZCard(
 		r.ctx,
 		activeSessionsKey,
-		"-inf",
-		"+inf",
 	).Result()
 }
 
-// retrieves the count of online users of gived client.
-func (r *Redis) getOnlineUsersCount(
-	clientId protocol.ClientID,
-) (int64, error) {
-	var total int64
-	pattern := fmt.Sprintf(
-		"%s:%s:*",
-		clientId,
-		RedisKeyActiveSessions,
-	)
-	keys, err := r.client.Keys(r.ctx, pattern).Result()
-	if err != nil {
-		return total, err
-	}
-	for _, key := range keys {
-		count, err := r.client.ZCount(
-			r.ctx,
-			key,
-			"-inf",
-			"+inf",
-		).Result()
-		if err != nil {
-			return total, err
-		}
-		total += count
-	}
-	return total, nil
-}
-
-// sets the user's duration in active sessions.
+// sets the user's duration in active sessions without the push timestamp, only the separate
+// calls benchmark uses it, sessions are updated by the sessions script.
 func (r *Redis) setUserDurationToActiveSessions(
 	clientId protocol.ClientID,
 	roomId protocol.RoomID,
@@ -198,26 +236,20 @@ func (r *Redis) setUserDurationToActiveSessions(
 	now int64,
 ) error {
 	var err error
-	activeSessionsKey := fmt.Sprintf(
-		"%s:%s:%s",
-		clientId,
-		RedisKeyActiveSessions,
-		roomId,
-	)
-	sessionTsKey := fmt.Sprintf(
-		"%s:%s:%s",
-		clientId,
-		RedisKeySessionTs,
-		roomId,
-	)
-	addActiveSessionsErr := r.client.ZAdd(
+	activeSessionsKey := roomTaggedKey(clientId, roomId, RedisKeyActiveSessions)
+	sessionTsKey := roomTaggedKey(clientId, roomId, RedisKeySessionTs)
+	added, addActiveSessionsErr := r.client.ZAdd(
 		r.ctx,
 		activeSessionsKey,
 		&redis.Z{
 			Score:  float64(duration),
 			Member: string(userID),
 		},
-	).Err()
+	).Result()
+	// Count user as online once joined the room
+	if added > 0 {
+		err = r.presenceJoin(clientId, roomId, userID)
+	}
 	addSessionTsErr := r.client.ZAdd(
 		r.ctx,
 		sessionTsKey,
@@ -226,13 +258,6 @@ func (r *Redis) setUserDurationToActiveSessions(
 			Member: string(userID),
 		},
 	).Err()
-	// Remove expired sessions
-	if rand.Intn(cleanupRandChance) == 0 {
-		err = errors.Join(
-			err,
-			r.cleanupExpiredSessions(activeSessionsKey, sessionTsKey, now),
-		)
-	}
 	return errors.Join(
 		err,
 		addActiveSessionsErr,
@@ -240,48 +265,197 @@ func (r *Redis) setUserDurationToActiveSessions(
 	)
 }
 
-// removes the user's duration from active sessions.
-func (r *Redis) removeUserDurationFromActiveSessions(
+// updates the user's duration, heartbeat and push timestamp in active sessions, returns the user's
+// place and the count of users in active sessions. Only the starting session joins active sessions.
+func (r *Redis) updateActiveSession(
 	clientId protocol.ClientID,
 	roomId protocol.RoomID,
 	userID protocol.UserID,
+	duration int64,
 	now int64,
-) error {
+	push int64,
+	start bool,
+) (int64, int64, error) {
+	activeSessionsKey := roomTaggedKey(clientId, roomId, RedisKeyActiveSessions)
+	sessionTsKey := roomTaggedKey(clientId, roomId, RedisKeySessionTs)
+	sessionPushKey := roomTaggedKey(clientId, roomId, RedisKeySessionPush)
+	result, err := updateActiveSessionScript.Run(
+		r.ctx,
+		r.client,
+		[]string{activeSessionsKey, sessionTsKey, sessionPushKey},
+		string(userID),
+		duration,
+		now,
+		push,
+		start,
+	).Int64Slice()
+	if err == redis.Nil {
+		return 0, 0, ErrSessionReaped
+	} else if err != nil {
+		return 0, 0, err
+	}
+	if len(result) != 3 {
+		return 0, 0, fmt.Errorf("unexpected update session script result: %v", result)
+	}
+	// Count user as online once joined the room
+	if result[2] > 0 {
+		err = r.presenceJoin(clientId, roomId, userID)
+	}
+	return result[0], result[1], err
+}
+
+// removes the user's duration from active sessions, returns whether the user was present.
+func (r *Redis) removeUserDurationFromActiveSessions(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+) (bool, error) {
+	activeSessionsKey := roomTaggedKey(clientId, roomId, RedisKeyActiveSessions)
+	sessionTsKey := roomTaggedKey(clientId, roomId, RedisKeySessionTs)
+	sessionPushKey := roomTaggedKey(clientId, roomId, RedisKeySessionPush)
+	removed, err := removeActiveSessionScript.Run(
+		r.ctx,
+		r.client,
+		[]string{activeSessionsKey, sessionTsKey, sessionPushKey},
+		string(userID),
+	).Int64()
+	// Stop counting user as online once left the room
+	if err == nil && removed > 0 {
+		err = r.presenceLeave(clientId, roomId, userID)
+	}
+	return removed > 0, err
+}
+
+// list rooms which have active sessions, payloads or holders of the room mode
+func (r *Redis) listActiveSessionRooms() ([]protocol.RoomKey, error) {
 	var err error
-	activeSessionsKey := fmt.Sprintf(
-		"%s:%s:%s",
-		clientId,
+	var roomList []protocol.RoomKey
+	seen := make(map[protocol.RoomKey]bool)
+	for _, key := range []RedisKey{
 		RedisKeyActiveSessions,
-		roomId,
-	)
-	sessionTsKey := fmt.Sprintf(
-		"%s:%s:%s",
-		clientId,
 		RedisKeySessionTs,
-		roomId,
-	)
-	remActiveSessionsErr := r.client.ZRem(
+		RedisKeySessionPush,
+		RedisKeyPayloads,
+		RedisKeyTournamentAlive,
+		RedisKeyTeamMembers,
+		RedisKeyChainHolders,
+	} {
+		pattern := roomTaggedKey("*", "*", key)
+		scanErr := r.scanKeys(pattern, func(taggedKey string) {
+			clientId, roomId, ok := parseRoomTaggedKey(taggedKey, key)
+			if !ok {
+				return
+			}
+			roomKey := protocol.RoomKey(tuple.New2(clientId, roomId))
+			if !seen[roomKey] {
+				seen[roomKey] = true
+				roomList = append(roomList, roomKey)
+			}
+		})
+		err = errors.Join(err, scanErr)
+	}
+	return roomList, err
+}
+
+// remove expired active sessions and orphaned payloads of the room, only holders of the room mode
+// are checked for stale users
+func (r *Redis) reapActiveSessions(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	mode protocol.RoomMode,
+	now int64,
+) ([]ReapedSession, int64, error) {
+	var reaped []ReapedSession
+	keys := []string{
+		roomTaggedKey(clientId, roomId, RedisKeyActiveSessions),
+		roomTaggedKey(clientId, roomId, RedisKeySessionTs),
+		roomTaggedKey(clientId, roomId, RedisKeySessionPush),
+		roomTaggedKey(clientId, roomId, RedisKeyPayloads),
+	}
+	holdersType := "hash"
+	switch mode {
+	case protocol.ModeTeams:
+		keys = append(keys, roomTaggedKey(clientId, roomId, RedisKeyTeamMembers))
+	case protocol.ModeChain:
+		keys = append(keys, roomTaggedKey(clientId, roomId, RedisKeyChainHolders))
+		holdersType = "set"
+	case protocol.ModeTournament:
+		keys = append(keys, roomTaggedKey(clientId, roomId, RedisKeyTournamentAlive))
+	}
+	result, err := reapActiveSessionsScript.Run(
 		r.ctx,
-		activeSessionsKey,
-		string(userID),
-	).Err()
-	remSessionTsErr := r.client.ZRem(
+		r.client,
+		keys,
+		now-sessionTtlSeconds,
+		maxExpiredSessionsBatch,
+		now,
+		holdersType,
+	).Slice()
+	if err != nil {
+		return reaped, 0, err
+	}
+	if len(result) != 3 {
+		return reaped, 0, fmt.Errorf("unexpected reap sessions script result: %v", result)
+	}
+	orphaned, _ := result[0].(int64)
+	values, _ := result[1].([]interface{})
+	for i := 0; i+2 < len(values); i += 3 {
+		userIdStr, _ := values[i].(string)
+		durationStr, _ := values[i+1].(string)
+		timestampStr, _ := values[i+2].(string)
+		duration, parseDurationErr := strconv.ParseFloat(durationStr, 64)
+		timestamp, parseTimestampErr := strconv.ParseFloat(timestampStr, 64)
+		if parseDurationErr != nil || parseTimestampErr != nil {
+			err = errors.Join(err, parseDurationErr, parseTimestampErr)
+			continue
+		}
+		reaped = append(reaped, ReapedSession{
+			UserID:    protocol.UserID(userIdStr),
+			Duration:  int64(duration),
+			Timestamp: int64(timestamp),
+		})
+		// Reaped users are not online anymore
+		err = errors.Join(err, r.presenceLeave(clientId, roomId, protocol.UserID(userIdStr)))
+	}
+	// Stale users have already left active sessions, only the state of the mode is left
+	stale, _ := result[2].([]interface{})
+	for _, value := range stale {
+		userIdStr, _ := value.(string)
+		reaped = append(reaped, ReapedSession{
+			UserID:    protocol.UserID(userIdStr),
+			Timestamp: now,
+			Stale:     true,
+		})
+	}
+	return reaped, orphaned, err
+}
+
+// acquire or prolong the lock owned by given token
+func (r *Redis) acquireLock(
+	name string,
+	token string,
+	ttl time.Duration,
+) (bool, error) {
+	return acquireLockScript.Run(
 		r.ctx,
-		sessionTsKey,
-		string(userID),
+		r.client,
+		[]string{name},
+		token,
+		ttl.Milliseconds(),
+	).Bool()
+}
+
+// release the lock owned by given token
+func (r *Redis) releaseLock(
+	name string,
+	token string,
+) error {
+	return releaseLockScript.Run(
+		r.ctx,
+		r.client,
+		[]string{name},
+		token,
 	).Err()
-	// Remove expired sessions
-	if rand.Intn(cleanupRandChance) == 0 {
-		err = errors.Join(
-			err,
-			r.cleanupExpiredSessions(activeSessionsKey, sessionTsKey, now),
-		)
-	}
-	return errors.Join(
-		err,
-		remActiveSessionsErr,
-		remSessionTsErr,
-	)
 }
 
 // get list of best scored users payloads for gived room and client id's
@@ -292,18 +466,8 @@ func (r *Redis) getBestUsersPayloads(
 ) ([]protocol.UserPayload, error) {
 	var err error
 	payloads := make([]protocol.UserPayload, 0)
-	activeSessionsKey := fmt.Sprintf(
-		"%s:%s:%s",
-		clientId,
-		RedisKeyActiveSessions,
-		roomId,
-	)
-	payloadsKey := fmt.Sprintf(
-		"%s:%s:%s",
-		clientId,
-		RedisKeyPayloads,
-		roomId,
-	)
+	activeSessionsKey := roomTaggedKey(clientId, roomId, RedisKeyActiveSessions)
+	payloadsKey := roomTaggedKey(clientId, roomId, RedisKeyPayloads)
 	// Get best scored users
 	users, err := r.client.ZRange(
 		r.ctx,
@@ -329,6 +493,26 @@ func (r *Redis) getBestUsersPayloads(
 	return payloads, err
 }
 
+// get list of users holding the button longest in given room
+func (r *Redis) getBestActiveUsers(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	count int64,
+) ([]protocol.UserID, error) {
+	activeSessionsKey := roomTaggedKey(clientId, roomId, RedisKeyActiveSessions)
+	users, err := r.client.ZRevRange(
+		r.ctx,
+		activeSessionsKey,
+		0,
+		count-1,
+	).Result()
+	userIDs := make([]protocol.UserID, len(users))
+	for i, user := range users {
+		userIDs[i] = protocol.UserID(user)
+	}
+	return userIDs, err
+}
+
 // add user payload to set of given client and room id's
 func (r *Redis) addUserPayload(
 	clientId protocol.ClientID,
@@ -339,12 +523,7 @@ func (r *Redis) addUserPayload(
 	var err error
 	userIdStr := string(userID)
 	payloadStr := string(payload)
-	payloadsKey := fmt.Sprintf(
-		"%s:%s:%s",
-		clientId,
-		RedisKeyPayloads,
-		roomId,
-	)
+	payloadsKey := roomTaggedKey(clientId, roomId, RedisKeyPayloads)
 	_, err = r.client.HSet(
 		r.ctx,
 		payloadsKey,
@@ -362,12 +541,7 @@ func (r *Redis) removeUserPayload(
 ) error {
 	var err error
 	userIdStr := string(userID)
-	payloadsKey := fmt.Sprintf(
-		"%s:%s:%s",
-		clientId,
-		RedisKeyPayloads,
-		roomId,
-	)
+	payloadsKey := roomTaggedKey(clientId, roomId, RedisKeyPayloads)
 	// Remove record
 	_, err = r.client.HDel(
 		r.ctx,
@@ -377,68 +551,87 @@ func (r *Redis) removeUserPayload(
 	return err
 }
 
+// remove active sessions and payloads of the room.
+func (r *Redis) removeRoomSessions(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) error {
+	return r.client.Del(
+		r.ctx,
+		roomTaggedKey(clientId, roomId, RedisKeyActiveSessions),
+		roomTaggedKey(clientId, roomId, RedisKeySessionTs),
+		roomTaggedKey(clientId, roomId, RedisKeySessionPush),
+		roomTaggedKey(clientId, roomId, RedisKeyPayloads),
+	).Err()
+}
+
 // list custom game rooms
 func (r *Redis) listCustomGameRooms() ([]protocol.RoomKey, error) {
 	var err error
 	var roomList []protocol.RoomKey
+	var customRoomsKeys []string
 	// Scan db and get all room hash sets
 	prefix := fmt.Sprintf("*:%s", RedisKeyCustomRooms)
 	trimStr := fmt.Sprintf(":%s", RedisKeyCustomRooms)
-	iter := r.client.Scan(r.ctx, 0, prefix, 0).Iterator()
-	for iter.Next(r.ctx) {
+	err = r.scanKeys(prefix, func(key string) {
+		customRoomsKeys = append(customRoomsKeys, key)
+	})
+	for _, customRoomsKey := range customRoomsKeys {
 		// Extract client id from key
-		keyStr := strings.TrimSuffix(iter.Val(), trimStr)
+		keyStr := strings.TrimSuffix(customRoomsKey, trimStr)
 		keySplit := strings.Split(keyStr, ":")
 		if len(keySplit) != 1 {
 			err = fmt.Errorf("invalid key found: %s", keyStr)
 			break
 		}
-		// Iterate over rooms in hash sets
-		roomIter := r.client.HScan(r.ctx, iter.Val(), 0, "", 0).Iterator()
+		// Iterate over rooms in hash sets, the iterator yields room ids followed by owners
+		roomIter := r.client.HScan(r.ctx, customRoomsKey, 0, "", 0).Iterator()
 		for roomIter.Next(r.ctx) {
 			// Add room key to slice
 			clientId := protocol.ClientID(keySplit[0])
 			roomId := protocol.RoomID(roomIter.Val())
 			key := protocol.RoomKey(tuple.New2(clientId, roomId))
 			roomList = append(roomList, key)
+			// Skip the owner
+			roomIter.Next(r.ctx)
+		}
+		if err = roomIter.Err(); err != nil {
+			break
 		}
 	}
 	return roomList, err
 }
 
-// add new user's custom game room.
+// add new user's custom game room, the count of rooms owned by the user is limited.
 func (r *Redis) createCustomRoom(
 	clientId protocol.ClientID,
 	roomId protocol.RoomID,
 	userID protocol.UserID,
+	maxRooms int64,
 ) error {
-	var err error
-	roomIdStr := string(roomId)
-	userIdStr := string(userID)
 	customRoomKey := fmt.Sprintf(
 		"%s:%s",
 		clientId,
 		RedisKeyCustomRooms,
 	)
-	// Check if room already exist
-	roomInRedis, err := r.client.HExists(
+	result, err := createCustomRoomScript.Run(
 		r.ctx,
-		customRoomKey,
-		roomIdStr,
-	).Result()
-	if roomInRedis {
-		err = errors.New("room exist")
+		r.client,
+		[]string{customRoomKey, ownedRoomsKey(clientId, userID)},
+		string(roomId),
+		string(userID),
+		maxRooms,
+	).Int64()
+	if err != nil {
+		return err
 	}
-	// Create new room
-	if err == nil {
-		_, err = r.client.HSet(
-			r.ctx,
-			customRoomKey,
-			roomIdStr,
-			userIdStr,
-		).Result()
+	switch result {
+	case -1:
+		return ErrRoomExists
+	case 0:
+		return ErrRoomQuotaExceeded
 	}
-	return err
+	return nil
 }
 
 // remove user's custom game room.
@@ -447,139 +640,52 @@ func (r *Redis) removeCustomRoom(
 	roomId protocol.RoomID,
 	userID protocol.UserID,
 ) error {
-	var err error
-	roomIdStr := string(roomId)
-	userIdStr := string(userID)
 	customRoomKey := fmt.Sprintf(
 		"%s:%s",
 		clientId,
 		RedisKeyCustomRooms,
 	)
-	// Check if room exist and belongs to user
-	roomInRedis, err := r.client.HGet(
+	result, err := removeCustomRoomScript.Run(
 		r.ctx,
-		customRoomKey,
-		roomIdStr,
-	).Result()
-	if roomInRedis == "" {
-		err = errors.New("room not exist")
-	}
-	if roomInRedis != userIdStr {
-		err = errors.New("room does not belong to the user")
-	}
+		r.client,
+		[]string{customRoomKey, ownedRoomsKey(clientId, userID)},
+		string(roomId),
+		string(userID),
+	).Int64()
 	if err != nil {
-		return nil
+		return err
 	}
-	// Remove record
-	_, err = r.client.HDel(
-		r.ctx,
-		customRoomKey,
-		roomIdStr,
-	).Result()
-	return err
-}
-
-// init user's chat consumer group
-func (r *Redis) initChatConsumerGroup(
-	clientId protocol.ClientID,
-	roomId protocol.RoomID,
-) error {
-	streamKey := fmt.Sprintf(
-		"%s:%s:%s",
-		clientId,
-		roomId,
-		RedisKeyChat,
-	)
-	return r.client.XGroupCreateMkStream(
-		r.ctx,
-		streamKey,
-		string(roomId),
-		"$",
-	).Err()
-}
-
-// add user consumer to consumer group of chat stream
-func (r *Redis) addChatConsumerToGroup(
-	clientId protocol.ClientID,
-	roomId protocol.RoomID,
-	userId protocol.UserID,
-) error {
-	streamKey := fmt.Sprintf(
-		"%s:%s:%s",
-		clientId,
-		roomId,
-		RedisKeyChat,
-	)
-	return r.client.XGroupCreateConsumer(
-		r.ctx,
-		streamKey,
-		string(roomId),
-		string(userId),
-	).Err()
-}
-
-// push user's chat message.
-func (r *Redis) pushChatMessage(
-	clientId protocol.ClientID,
-	roomId protocol.RoomID,
-	chatMessage protocol.ChatMessage,
-) error {
-	streamKey := fmt.Sprintf(
-		"%s:%s:%s",
-		clientId,
-		roomId,
-		RedisKeyChat,
-	)
-	return r.client.XAdd(r.ctx, &redis.XAddArgs{
-		MaxLen: maxChatMessageInStream,
-		Stream: streamKey,
-		Values: []interface{}{
-			"UserID", string(chatMessage.UserID),
-			"Message", string(chatMessage.Message),
-		},
-	}).Err()
+	switch result {
+	case 0:
+		return ErrRoomNotExist
+	case -1:
+		return ErrRoomNotOwned
+	}
+	return nil
 }
 
-// pop user's chat message
-func (r *Redis) popChatMessage(
-	clientId protocol.ClientID,
-	roomId protocol.RoomID,
-	userId protocol.UserID,
-) (protocol.ChatMessage, error) {
-	var msg protocol.ChatMessage
-	streamKey := fmt.Sprintf(
-		"%s:%s:%s",
-		clientId,
-		roomId,
-		RedisKeyChat,
-	)
-	result, err := r.client.XReadGroup(r.ctx, &redis.XReadGroupArgs{
-		Streams:  []string{streamKey, ">"},
-		Group:    string(roomId),
-		Consumer: string(userId),
-		Block:    time.Second,
-		Count:    10,
-		NoAck:    true,
-	}).Result()
-	if err == nil {
-		for _, s := range result {
-			for _, message := range s.Messages {
-				message_ := message.Values["Message"].(string)
-				userid_ := protocol.UserID(message.Values["UserID"].(string))
-				err = r.client.XAck(
-					r.ctx,
-					streamKey,
-					string(roomId),
-					message.ID,
-				).Err()
-
-				if userid_ != userId {
-					msg.Message = message_
-					msg.UserID = userid_
-					break
-				}
-			}
+// adds custom rooms of every client to sets of rooms owned by their owners.
+func (r *Redis) reconcileOwnedRooms() error {
+	var customRoomsKeys []string
+	trimStr := fmt.Sprintf(":%s", RedisKeyCustomRooms)
+	err := r.scanKeys(fmt.Sprintf("*%s", trimStr), func(key string) {
+		customRoomsKeys = append(customRoomsKeys, key)
+	})
+	for _, customRoomsKey := range customRoomsKeys {
+		clientId := protocol.ClientID(strings.TrimSuffix(customRoomsKey, trimStr))
+		rooms, getErr := r.client.HGetAll(r.ctx, customRoomsKey).Result()
+		if getErr != nil {
+			err = errors.Join(err, getErr)
+			continue
+		}
+		pipe := r.client.Pipeline()
+		for roomId, owner := range rooms {
+			pipe.SAdd(r.ctx, ownedRoomsKey(clientId, protocol.UserID(owner)), roomId)
+		}
+		if len(rooms) > 0 {
+			_, execErr := pipe.Exec(r.ctx)
+			err = errors.Join(err, execErr)
 		}
 	}
-	return msg, err
+	return err
 }
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"buttonmania.win/protocol"
+)
+
+const (
+	benchClientId protocol.ClientID = "bench"
+	benchRoomId   protocol.RoomID   = "bench"
+	benchUsers                      = 100
+)
+
+// newBenchRedis connects to the redis server given by REDIS_ADDRESS env variable.
+func newBenchRedis(b *testing.B) *Redis {
+	address := os.Getenv("REDIS_ADDRESS")
+	if address == "" {
+		b.Skip("REDIS_ADDRESS is not set")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = context.WithValue(ctx, KeyRedisAddress, address)
+	r, err := NewRedis(ctx)
+	if err != nil {
+		cancel()
+		b.Fatalf("Failed to connect to redis: %v", err)
+	}
+	b.Cleanup(func() {
+		for i := 0; i < benchUsers; i++ {
+			userID := protocol.UserID(strconv.Itoa(i))
+			_, _ = r.removeUserDurationFromActiveSessions(benchClientId, benchRoomId, userID)
+		}
+		cancel()
+		_ = r.close()
+	})
+	return r
+}
+
+// BenchmarkUpdateSessionSeparateCalls measures the update path made of separate redis calls.
+func BenchmarkUpdateSessionSeparateCalls(b *testing.B) {
+	r := newBenchRedis(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		userID := protocol.UserID(strconv.Itoa(i % benchUsers))
+		now := time.Now().Unix()
+		if err := r.setUserDurationToActiveSessions(benchClientId, benchRoomId, userID, int64(i), now); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := r.getUserPlaceInActiveSessions(benchClientId, benchRoomId, userID); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := r.getUsersCountInActiveSessions(benchClientId, benchRoomId); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkUpdateSessionScript measures the single round-trip scripted update path.
+func BenchmarkUpdateSessionScript(b *testing.B) {
+	r := newBenchRedis(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		userID := protocol.UserID(strconv.Itoa(i % benchUsers))
+		now := time.Now().Unix()
+		if _, _, err := r.updateActiveSession(benchClientId, benchRoomId, userID, int64(i), now, now, true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
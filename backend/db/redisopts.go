@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	redis "github.com/go-redis/redis/v8"
+)
+
+// RedisMode represents the topology of redis deployment.
+type RedisMode string
+
+const (
+	// Supported redis deployments
+	RedisModeSingle   RedisMode = "single"
+	RedisModeSentinel RedisMode = "sentinel"
+	RedisModeCluster  RedisMode = "cluster"
+)
+
+// newRedisOptions reads redis connection options from the context.
+// Connection url, when provided, takes precedence over separate options.
+func newRedisOptions(ctx context.Context) (RedisMode, *redis.UniversalOptions, error) {
+	redisurl, _ := ctx.Value(KeyRedisUrl).(string)
+	redismode, _ := ctx.Value(KeyRedisMode).(string)
+	redisaddress, _ := ctx.Value(KeyRedisAddress).(string)
+	redisusername, _ := ctx.Value(KeyRedisUsername).(string)
+	redispassword, _ := ctx.Value(KeyRedisPassword).(string)
+	redisdatabase, _ := ctx.Value(KeyRedisDatabase).(int)
+	redistls, _ := ctx.Value(KeyRedisTLS).(bool)
+	redismaster, _ := ctx.Value(KeyRedisMasterName).(string)
+	redissentinelpassword, _ := ctx.Value(KeyRedisSentinelPassword).(string)
+
+	if len(redisurl) > 0 {
+		return parseRedisUrl(redisurl, redissentinelpassword)
+	}
+
+	mode := RedisMode(redismode)
+	if mode == "" {
+		mode = RedisModeSingle
+	}
+	opts := &redis.UniversalOptions{
+		Addrs:            splitRedisAddresses(redisaddress),
+		Username:         redisusername,
+		Password:         redispassword,
+		DB:               redisdatabase,
+		MasterName:       redismaster,
+		SentinelPassword: redissentinelpassword,
+	}
+	if redistls {
+		opts.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+	return mode, opts, validateRedisOptions(mode, opts)
+}
+
+// parseRedisUrl parses connection url of form
+// redis[s][+sentinel|+cluster]://[user:password@]host:port[,host:port...][/db][?master=name]
+func parseRedisUrl(redisurl string, sentinelPassword string) (RedisMode, *redis.UniversalOptions, error) {
+	u, err := url.Parse(redisurl)
+	if err != nil {
+		return "", nil, err
+	}
+
+	opts := &redis.UniversalOptions{
+		Addrs:            splitRedisAddresses(u.Host),
+		MasterName:       u.Query().Get("master"),
+		SentinelPassword: sentinelPassword,
+	}
+	if u.User != nil {
+		opts.Username = u.User.Username()
+		opts.Password, _ = u.User.Password()
+	}
+	if dbStr := strings.Trim(u.Path, "/"); len(dbStr) > 0 {
+		opts.DB, err = strconv.Atoi(dbStr)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid redis database number: %s", dbStr)
+		}
+	}
+
+	var mode RedisMode
+	scheme, topology, _ := strings.Cut(u.Scheme, "+")
+	switch topology {
+	case "":
+		mode = RedisModeSingle
+	case string(RedisModeSentinel):
+		mode = RedisModeSentinel
+	case string(RedisModeCluster):
+		mode = RedisModeCluster
+	default:
+		return "", nil, fmt.Errorf("invalid redis url scheme: %s", u.Scheme)
+	}
+	switch scheme {
+	case "redis":
+	case "rediss":
+		opts.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+	default:
+		return "", nil, fmt.Errorf("invalid redis url scheme: %s", u.Scheme)
+	}
+	return mode, opts, validateRedisOptions(mode, opts)
+}
+
+// validateRedisOptions checks options are consistent with redis deployment mode.
+func validateRedisOptions(mode RedisMode, opts *redis.UniversalOptions) error {
+	if len(opts.Addrs) == 0 {
+		return fmt.Errorf("redis address not provided")
+	}
+	switch mode {
+	case RedisModeSingle:
+		if len(opts.Addrs) > 1 {
+			return fmt.Errorf("single redis mode expects one address, got %d", len(opts.Addrs))
+		}
+	case RedisModeSentinel:
+		if opts.MasterName == "" {
+			return fmt.Errorf("redis sentinel master name not provided")
+		}
+	case RedisModeCluster:
+		if opts.DB != 0 {
+			return fmt.Errorf("redis cluster supports only database 0")
+		}
+	default:
+		return fmt.Errorf("invalid redis mode: %s", mode)
+	}
+	return nil
+}
+
+// newRedisClient creates redis client for given deployment mode.
+func newRedisClient(mode RedisMode, opts *redis.UniversalOptions) redis.UniversalClient {
+	switch mode {
+	case RedisModeSentinel:
+		return redis.NewFailoverClient(opts.Failover())
+	case RedisModeCluster:
+		return redis.NewClusterClient(opts.Cluster())
+	default:
+		return redis.NewClient(opts.Simple())
+	}
+}
+
+// splitRedisAddresses splits comma separated list of addresses.
+func splitRedisAddresses(addresses string) []string {
+	var addrs []string
+	for _, addr := range strings.Split(addresses, ",") {
+		if addr = strings.TrimSpace(addr); len(addr) > 0 {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"log"
+	"sync"
+
+	"buttonmania.win/protocol"
+)
+
+// Define the count of messages buffered for every subscriber of the room channel
+const roomBusBuffer = 100
+
+// Define kinds of room channels received by the shared subscription
+var roomBusChannels = []RedisKey{
+	RedisKeyChat,
+	RedisKeyReactions,
+	RedisKeyRoomControl,
+	RedisKeyTournamentEvents,
+}
+
+// roomBus hands messages of room channels received by the only pattern subscription
+// of the instance to subscribers of the channel.
+type roomBus struct {
+	mu   sync.RWMutex
+	subs map[string]map[chan string]struct{}
+}
+
+// newRoomBus creates a new roomBus instance.
+func newRoomBus() *roomBus {
+	return &roomBus{
+		subs: make(map[string]map[chan string]struct{}),
+	}
+}
+
+// subscribe registers the subscriber of the channel, the stop function unregisters it
+// and closes its messages.
+func (b *roomBus) subscribe(channel string) (<-chan string, func() error) {
+	messages := make(chan string, roomBusBuffer)
+	b.mu.Lock()
+	if b.subs[channel] == nil {
+		b.subs[channel] = make(map[chan string]struct{})
+	}
+	b.subs[channel][messages] = struct{}{}
+	b.mu.Unlock()
+	var once sync.Once
+	return messages, func() error {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subs[channel], messages)
+			if len(b.subs[channel]) == 0 {
+				delete(b.subs, channel)
+			}
+			close(messages)
+		})
+		return nil
+	}
+}
+
+// dispatch hands the message to subscribers of the channel, messages of slow subscribers
+// are dropped so they do not hold up other rooms.
+func (b *roomBus) dispatch(channel string, payload string) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for messages := range b.subs[channel] {
+		select {
+		case messages <- payload:
+		default:
+			log.Println("Room subscriber is too slow, dropped message of", channel)
+		}
+	}
+}
+
+// receive room channels of every room with one pattern subscription and hand their
+// messages to subscribers of this instance, the subscription is restored on reconnects.
+func (r *Redis) receiveRoomChannels() {
+	patterns := make([]string, 0, len(roomBusChannels))
+	for _, kind := range roomBusChannels {
+		patterns = append(patterns, roomTaggedKey("*", "*", kind))
+	}
+	pubsub := r.client.PSubscribe(r.ctx, patterns...)
+	defer pubsub.Close()
+	for m := range pubsub.Channel() {
+		r.bus.dispatch(m.Channel, m.Payload)
+	}
+}
+
+// subscribe to the room channel of given kind through the shared subscription.
+func (r *Redis) subscribeRoomChannel(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	kind RedisKey,
+) (<-chan string, func() error) {
+	return r.bus.subscribe(roomTaggedKey(clientId, roomId, kind))
+}
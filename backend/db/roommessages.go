@@ -0,0 +1,79 @@
+package db
+
+import (
+	"buttonmania.win/protocol"
+	"github.com/jackc/pgx/v5"
+)
+
+// replaces the message pack of the custom room, locales missing in the pack are removed.
+func (p *Postgres) setRoomMessages(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	pack map[protocol.UserLocale][]string,
+) error {
+	tx, err := p.pool.Begin(p.ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(p.ctx)
+	if _, err := tx.Exec(
+		p.ctx,
+		`DELETE FROM room_messages WHERE client_id=$1 AND room_id=$2`,
+		clientId,
+		roomId,
+	); err != nil {
+		return err
+	}
+	for locale, messages := range pack {
+		if _, err := tx.Exec(
+			p.ctx,
+			`INSERT INTO room_messages(client_id, room_id, locale, messages)
+			VALUES($1, $2, $3, $4)`,
+			clientId,
+			roomId,
+			locale,
+			messages,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(p.ctx)
+}
+
+// retrieves the message pack of the custom room, empty if not uploaded.
+func (p *Postgres) getRoomMessages(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (map[protocol.UserLocale][]string, error) {
+	rows, err := p.pool.Query(
+		p.ctx,
+		`SELECT locale, messages FROM room_messages WHERE client_id=$1 AND room_id=$2`,
+		clientId,
+		roomId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	pack := make(map[protocol.UserLocale][]string)
+	var locale protocol.UserLocale
+	var messages []string
+	_, err = pgx.ForEachRow(rows, []any{&locale, &messages}, func() error {
+		pack[locale] = messages
+		return nil
+	})
+	return pack, err
+}
+
+// removes the message pack of the custom room.
+func (p *Postgres) removeRoomMessages(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) error {
+	_, err := p.pool.Exec(
+		p.ctx,
+		`DELETE FROM room_messages WHERE client_id=$1 AND room_id=$2`,
+		clientId,
+		roomId,
+	)
+	return err
+}
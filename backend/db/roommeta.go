@@ -0,0 +1,281 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"buttonmania.win/protocol"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Define fields of the room metadata hash
+const (
+	roomMetaTitle       = "title"
+	roomMetaDescription = "description"
+	roomMetaIcon        = "icon"
+	roomMetaLocale      = "locale"
+	roomMetaCreated     = "created"
+	roomMetaPrivate     = "private"
+	roomMetaMode        = "mode"
+	roomMetaTeams       = "teams"
+	roomMetaWarned      = "warned"
+)
+
+// Team names cannot contain the separator of the stored list
+const teamsSeparator = ","
+
+// set metadata of the new custom game room, public rooms are added to the directory of the client.
+func (r *Redis) createRoomMeta(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	meta protocol.RoomMeta,
+) error {
+	metaKey := roomTaggedKey(clientId, roomId, RedisKeyRoomMeta)
+	pipe := r.client.Pipeline()
+	pipe.HSet(
+		r.ctx,
+		metaKey,
+		roomMetaTitle, meta.Title,
+		roomMetaDescription, meta.Description,
+		roomMetaIcon, meta.Icon,
+		roomMetaLocale, string(meta.Locale),
+		roomMetaCreated, meta.Created,
+		roomMetaPrivate, meta.Private,
+		roomMetaMode, string(meta.Mode),
+	)
+	if !meta.Private {
+		pipe.ZAdd(
+			r.ctx,
+			clientTaggedKey(clientId, RedisKeyRoomDirectory),
+			&redis.Z{
+				Score:  float64(meta.Created),
+				Member: string(roomId),
+			},
+		)
+	}
+	_, err := pipe.Exec(r.ctx)
+	return err
+}
+
+// update provided fields of the custom game room metadata, the room leaves the directory
+// once made private and returns once made public.
+func (r *Redis) updateRoomMeta(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	update protocol.RoomMetaUpdate,
+) error {
+	var values []any
+	if update.Title != nil {
+		values = append(values, roomMetaTitle, *update.Title)
+	}
+	if update.Description != nil {
+		values = append(values, roomMetaDescription, *update.Description)
+	}
+	if update.Icon != nil {
+		values = append(values, roomMetaIcon, *update.Icon)
+	}
+	if update.Locale != nil {
+		values = append(values, roomMetaLocale, *update.Locale)
+	}
+	if update.Private != nil {
+		values = append(values, roomMetaPrivate, *update.Private)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	metaKey := roomTaggedKey(clientId, roomId, RedisKeyRoomMeta)
+	if err := r.client.HSet(
+		r.ctx,
+		metaKey,
+		values...,
+	).Err(); err != nil {
+		return err
+	}
+	if update.Private == nil {
+		return nil
+	}
+	directoryKey := clientTaggedKey(clientId, RedisKeyRoomDirectory)
+	if *update.Private {
+		return r.client.ZRem(
+			r.ctx,
+			directoryKey,
+			string(roomId),
+		).Err()
+	}
+	created, err := r.client.HGet(
+		r.ctx,
+		metaKey,
+		roomMetaCreated,
+	).Int64()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	return r.client.ZAdd(
+		r.ctx,
+		directoryKey,
+		&redis.Z{
+			Score:  float64(created),
+			Member: string(roomId),
+		},
+	).Err()
+}
+
+// retrieves metadata of the game room.
+func (r *Redis) getRoomMeta(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (protocol.RoomMeta, error) {
+	owner, err := r.getCustomRoomOwner(clientId, roomId)
+	if err != nil {
+		return protocol.RoomMeta{}, err
+	}
+	metaKey := roomTaggedKey(clientId, roomId, RedisKeyRoomMeta)
+	fields, err := r.client.HGetAll(
+		r.ctx,
+		metaKey,
+	).Result()
+	if err != nil {
+		return protocol.RoomMeta{}, err
+	}
+	return parseRoomMeta(owner, fields), nil
+}
+
+// parses fields of the room metadata hash.
+func parseRoomMeta(owner protocol.UserID, fields map[string]string) protocol.RoomMeta {
+	created, _ := strconv.ParseInt(fields[roomMetaCreated], 10, 64)
+	private, _ := strconv.ParseBool(fields[roomMetaPrivate])
+	warned, _ := strconv.ParseInt(fields[roomMetaWarned], 10, 64)
+	var teams []string
+	if fields[roomMetaTeams] != "" {
+		teams = strings.Split(fields[roomMetaTeams], teamsSeparator)
+	}
+	return protocol.RoomMeta{
+		Title:       fields[roomMetaTitle],
+		Description: fields[roomMetaDescription],
+		Icon:        fields[roomMetaIcon],
+		Owner:       owner,
+		Created:     created,
+		Locale:      protocol.UserLocale(fields[roomMetaLocale]),
+		Private:     private,
+		Mode:        protocol.RoomMode(fields[roomMetaMode]),
+		Teams:       teams,
+		Warned:      warned,
+	}
+}
+
+// retrieves metadata of public custom rooms in the directory of the client,
+// owners and metadata of every room are read in one round trip.
+func (r *Redis) getDirectoryRooms(
+	clientId protocol.ClientID,
+) (map[protocol.RoomID]protocol.RoomMeta, error) {
+	roomIds, err := r.client.ZRange(
+		r.ctx,
+		clientTaggedKey(clientId, RedisKeyRoomDirectory),
+		0,
+		-1,
+	).Result()
+	if err != nil || len(roomIds) == 0 {
+		return map[protocol.RoomID]protocol.RoomMeta{}, err
+	}
+	customRoomKey := fmt.Sprintf(
+		"%s:%s",
+		clientId,
+		RedisKeyCustomRooms,
+	)
+	pipe := r.client.Pipeline()
+	ownersCmd := pipe.HMGet(r.ctx, customRoomKey, roomIds...)
+	metaCmds := make([]*redis.StringStringMapCmd, len(roomIds))
+	for i, roomId := range roomIds {
+		metaCmds[i] = pipe.HGetAll(r.ctx, roomTaggedKey(clientId, protocol.RoomID(roomId), RedisKeyRoomMeta))
+	}
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return nil, err
+	}
+	owners := ownersCmd.Val()
+	rooms := make(map[protocol.RoomID]protocol.RoomMeta, len(roomIds))
+	for i, roomId := range roomIds {
+		// Rooms removed while being listed have no owner
+		owner, ok := owners[i].(string)
+		if !ok {
+			continue
+		}
+		meta := parseRoomMeta(protocol.UserID(owner), metaCmds[i].Val())
+		if !meta.Private {
+			rooms[protocol.RoomID(roomId)] = meta
+		}
+	}
+	return rooms, nil
+}
+
+// store the time the owner was warned about the room expiry.
+func (r *Redis) setRoomWarned(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	warned int64,
+) error {
+	metaKey := roomTaggedKey(clientId, roomId, RedisKeyRoomMeta)
+	return r.client.HSet(
+		r.ctx,
+		metaKey,
+		roomMetaWarned,
+		warned,
+	).Err()
+}
+
+// remove metadata of the custom game room.
+func (r *Redis) removeRoomMeta(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) error {
+	metaKey := roomTaggedKey(clientId, roomId, RedisKeyRoomMeta)
+	if err := r.client.Del(
+		r.ctx,
+		metaKey,
+	).Err(); err != nil {
+		return err
+	}
+	return r.client.ZRem(
+		r.ctx,
+		clientTaggedKey(clientId, RedisKeyRoomDirectory),
+		string(roomId),
+	).Err()
+}
+
+// adds public custom rooms of every client to directories of their clients.
+func (r *Redis) reconcileRoomDirectory() error {
+	var customRoomsKeys []string
+	trimStr := fmt.Sprintf(":%s", RedisKeyCustomRooms)
+	err := r.scanKeys(fmt.Sprintf("*%s", trimStr), func(key string) {
+		customRoomsKeys = append(customRoomsKeys, key)
+	})
+	for _, customRoomsKey := range customRoomsKeys {
+		clientId := protocol.ClientID(strings.TrimSuffix(customRoomsKey, trimStr))
+		roomIds, keysErr := r.client.HKeys(r.ctx, customRoomsKey).Result()
+		if keysErr != nil {
+			err = errors.Join(err, keysErr)
+			continue
+		}
+		for _, roomId := range roomIds {
+			meta, metaErr := r.getRoomMeta(clientId, protocol.RoomID(roomId))
+			if metaErr != nil {
+				err = errors.Join(err, metaErr)
+				continue
+			}
+			if meta.Private {
+				continue
+			}
+			err = errors.Join(err, r.client.ZAdd(
+				r.ctx,
+				clientTaggedKey(clientId, RedisKeyRoomDirectory),
+				&redis.Z{
+					Score:  float64(meta.Created),
+					Member: roomId,
+				},
+			).Err())
+		}
+	}
+	return err
+}
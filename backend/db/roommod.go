@@ -0,0 +1,168 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"buttonmania.win/protocol"
+	redis "github.com/go-redis/redis/v8"
+)
+
+// get the owner of the custom game room, empty for predefined rooms.
+func (r *Redis) getCustomRoomOwner(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (protocol.UserID, error) {
+	customRoomKey := fmt.Sprintf(
+		"%s:%s",
+		clientId,
+		RedisKeyCustomRooms,
+	)
+	owner, err := r.client.HGet(
+		r.ctx,
+		customRoomKey,
+		string(roomId),
+	).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return protocol.UserID(owner), err
+}
+
+// restrict the user in the room until given time, zero duration restricts forever.
+func (r *Redis) restrictRoomUser(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	kind RedisKey,
+	userID protocol.UserID,
+	duration time.Duration,
+) error {
+	until := math.Inf(1)
+	if duration > 0 {
+		until = float64(time.Now().Add(duration).Unix())
+	}
+	restrictionsKey := roomTaggedKey(clientId, roomId, kind)
+	return r.client.ZAdd(
+		r.ctx,
+		restrictionsKey,
+		&redis.Z{
+			Score:  until,
+			Member: string(userID),
+		},
+	).Err()
+}
+
+// lift the restriction of the user in the room.
+func (r *Redis) unrestrictRoomUser(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	kind RedisKey,
+	userID protocol.UserID,
+) error {
+	restrictionsKey := roomTaggedKey(clientId, roomId, kind)
+	return r.client.ZRem(
+		r.ctx,
+		restrictionsKey,
+		string(userID),
+	).Err()
+}
+
+// check if the user is restricted in the room, expired restrictions are removed.
+func (r *Redis) isRoomUserRestricted(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	kind RedisKey,
+	userID protocol.UserID,
+) (bool, error) {
+	restrictionsKey := roomTaggedKey(clientId, roomId, kind)
+	until, err := r.client.ZScore(
+		r.ctx,
+		restrictionsKey,
+		string(userID),
+	).Result()
+	if err == redis.Nil {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if until > float64(time.Now().Unix()) {
+		return true, nil
+	}
+	return false, r.unrestrictRoomUser(clientId, roomId, kind, userID)
+}
+
+// remove bans and mutes of the room.
+func (r *Redis) removeRoomRestrictions(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) error {
+	return r.client.Del(
+		r.ctx,
+		roomTaggedKey(clientId, roomId, RedisKeyRoomBans),
+		roomTaggedKey(clientId, roomId, RedisKeyRoomMutes),
+	).Err()
+}
+
+// publish room owner's action to every instance.
+func (r *Redis) publishRoomControl(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	control protocol.RoomControl,
+) error {
+	channel := roomTaggedKey(clientId, roomId, RedisKeyRoomControl)
+	return r.client.Publish(
+		r.ctx,
+		channel,
+		control,
+	).Err()
+}
+
+// subscribe to room owner's actions.
+func (r *Redis) subscribeRoomControl(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (<-chan protocol.RoomControl, func() error) {
+	payloads, stop := r.subscribeRoomChannel(clientId, roomId, RedisKeyRoomControl)
+	controls := make(chan protocol.RoomControl)
+	go func() {
+		defer close(controls)
+		for payload := range payloads {
+			var control protocol.RoomControl
+			if err := json.Unmarshal([]byte(payload), &control); err != nil {
+				log.Println("Failed to decode room control:", err)
+				continue
+			}
+			controls <- control
+		}
+	}()
+	return controls, stop
+}
+
+// get users in active sessions of the room with their durations, longest first.
+func (r *Redis) getActiveSessions(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) ([]protocol.RoomHolder, error) {
+	activeSessionsKey := roomTaggedKey(clientId, roomId, RedisKeyActiveSessions)
+	sessions, err := r.client.ZRevRangeWithScores(
+		r.ctx,
+		activeSessionsKey,
+		0,
+		-1,
+	).Result()
+	if err != nil {
+		return nil, err
+	}
+	holders := make([]protocol.RoomHolder, 0, len(sessions))
+	for _, s := range sessions {
+		userID := protocol.UserID(fmt.Sprint(s.Member))
+		holders = append(holders, protocol.RoomHolder{
+			User:     protocol.UserProfile{UserID: userID},
+			Duration: int64(s.Score),
+		})
+	}
+	return holders, nil
+}
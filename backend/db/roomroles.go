@@ -0,0 +1,130 @@
+package db
+
+import (
+	"fmt"
+
+	"buttonmania.win/protocol"
+)
+
+// get the role of the user in the custom game room, empty for users without a role.
+func (r *Redis) getRoomRole(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+) (protocol.RoomRole, error) {
+	owner, err := r.getCustomRoomOwner(clientId, roomId)
+	if err != nil || owner == "" {
+		return "", err
+	}
+	if owner == userID {
+		return protocol.RoleOwner, nil
+	}
+	moderatorsKey := roomTaggedKey(clientId, roomId, RedisKeyRoomModerators)
+	moderator, err := r.client.SIsMember(
+		r.ctx,
+		moderatorsKey,
+		string(userID),
+	).Result()
+	if err != nil || !moderator {
+		return "", err
+	}
+	return protocol.RoleModerator, nil
+}
+
+// add the moderator of the custom game room.
+func (r *Redis) addRoomModerator(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+) error {
+	moderatorsKey := roomTaggedKey(clientId, roomId, RedisKeyRoomModerators)
+	return r.client.SAdd(
+		r.ctx,
+		moderatorsKey,
+		string(userID),
+	).Err()
+}
+
+// remove the moderator of the custom game room.
+func (r *Redis) removeRoomModerator(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+) error {
+	moderatorsKey := roomTaggedKey(clientId, roomId, RedisKeyRoomModerators)
+	return r.client.SRem(
+		r.ctx,
+		moderatorsKey,
+		string(userID),
+	).Err()
+}
+
+// get moderators of the custom game room.
+func (r *Redis) getRoomModerators(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) ([]protocol.UserID, error) {
+	moderatorsKey := roomTaggedKey(clientId, roomId, RedisKeyRoomModerators)
+	members, err := r.client.SMembers(
+		r.ctx,
+		moderatorsKey,
+	).Result()
+	moderators := make([]protocol.UserID, len(members))
+	for i, member := range members {
+		moderators[i] = protocol.UserID(member)
+	}
+	return moderators, err
+}
+
+// remove moderators of the custom game room.
+func (r *Redis) removeRoomModerators(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) error {
+	moderatorsKey := roomTaggedKey(clientId, roomId, RedisKeyRoomModerators)
+	return r.client.Del(
+		r.ctx,
+		moderatorsKey,
+	).Err()
+}
+
+// transfer the custom game room to the new owner, the count of rooms owned by the new owner is limited.
+// The previous owner becomes a moderator of the room.
+func (r *Redis) transferCustomRoom(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	ownerID protocol.UserID,
+	newOwnerID protocol.UserID,
+	maxRooms int64,
+) error {
+	customRoomKey := fmt.Sprintf(
+		"%s:%s",
+		clientId,
+		RedisKeyCustomRooms,
+	)
+	result, err := transferCustomRoomScript.Run(
+		r.ctx,
+		r.client,
+		[]string{customRoomKey, ownedRoomsKey(clientId, ownerID), ownedRoomsKey(clientId, newOwnerID)},
+		string(roomId),
+		string(ownerID),
+		string(newOwnerID),
+		maxRooms,
+	).Int64()
+	if err != nil {
+		return err
+	}
+	switch result {
+	case -1:
+		return ErrRoomNotOwned
+	case 0:
+		return ErrRoomQuotaExceeded
+	}
+	// Moderators set is stored in the room slot, so it is updated separately
+	moderatorsKey := roomTaggedKey(clientId, roomId, RedisKeyRoomModerators)
+	pipe := r.client.TxPipeline()
+	pipe.SRem(r.ctx, moderatorsKey, string(newOwnerID))
+	pipe.SAdd(r.ctx, moderatorsKey, string(ownerID))
+	_, err = pipe.Exec(r.ctx)
+	return err
+}
@@ -0,0 +1,477 @@
+package db
+
+import (
+	redis "github.com/go-redis/redis/v8"
+)
+
+// Decrements presence counters of the user and removes emptied fields.
+//
+// KEYS[1] - online users hash, KEYS[2] - online rooms hash, KEYS[3] - presence version
+// ARGV[1] - user id, ARGV[2] - room id
+var presenceLeaveScript = redis.NewScript(`
+redis.call('INCR', KEYS[3])
+local users = redis.call('HINCRBY', KEYS[1], ARGV[1], -1)
+if users <= 0 then
+	redis.call('HDEL', KEYS[1], ARGV[1])
+end
+local rooms = redis.call('HINCRBY', KEYS[2], ARGV[2], -1)
+if rooms <= 0 then
+	redis.call('HDEL', KEYS[2], ARGV[2])
+end
+return users
+`)
+
+// Replaces presence counters of the client with counts of active sessions unless
+// presence of the client changed while they were counted. Returns whether counters
+// are replaced.
+//
+// KEYS[1] - online users hash, KEYS[2] - online rooms hash, KEYS[3] - presence version
+// ARGV[1] - presence version the counting started at, ARGV[2] - count of users,
+// ARGV[3...] - user id and count pairs followed by room id and count pairs
+var reconcilePresenceScript = redis.NewScript(`
+if tonumber(redis.call('GET', KEYS[3]) or '0') ~= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('DEL', KEYS[1], KEYS[2])
+local usersEnd = 2 + tonumber(ARGV[2]) * 2
+for i = 3, usersEnd, 2 do
+	redis.call('HSET', KEYS[1], ARGV[i], ARGV[i + 1])
+end
+for i = usersEnd + 1, #ARGV, 2 do
+	redis.call('HSET', KEYS[2], ARGV[i], ARGV[i + 1])
+end
+return 1
+`)
+
+// Lua helpers shared by active sessions scripts. Scripts touch keys of
+// a single room only, so all of them belong to the same cluster slot.
+//
+// KEYS[1] - active sessions, KEYS[2] - sessions timestamps, KEYS[3] - sessions push timestamps
+const sessionsScriptHelpers = `
+local function leave(member)
+	local removed = redis.call('ZREM', KEYS[1], member)
+	redis.call('ZREM', KEYS[2], member)
+	redis.call('HDEL', KEYS[3], member)
+	return removed
+end
+`
+
+// Updates user's duration, heartbeat and push timestamp. Returns user's place, total count
+// of active sessions and whether the user has just joined active sessions, or nil if the
+// updated session is not active anymore, so the reaped session is never revived.
+//
+// ARGV[1] - user id, ARGV[2] - duration, ARGV[3] - heartbeat timestamp,
+// ARGV[4] - push timestamp, ARGV[5] - whether the session starts
+var updateActiveSessionScript = redis.NewScript(`
+if ARGV[5] ~= '1' and not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return nil
+end
+local joined = redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+redis.call('ZADD', KEYS[2], ARGV[3], ARGV[1])
+redis.call('HSET', KEYS[3], ARGV[1], ARGV[4])
+local count = redis.call('ZCARD', KEYS[1])
+local rank = redis.call('ZRANK', KEYS[1], ARGV[1])
+return {count - rank, count, joined}
+`)
+
+// Removes user from active sessions. Returns whether the user was present.
+//
+// ARGV[1] - user id
+var removeActiveSessionScript = redis.NewScript(sessionsScriptHelpers + `
+return leave(ARGV[1])
+`)
+
+// Removes expired and inconsistent active sessions along with orphaned payloads. Returns
+// the count of removed payloads, flat list of reaped sessions made of user id, duration
+// and push timestamp triples and the list of stale users left holding in the room mode
+// without active sessions. The state of the mode is left to be released by the reaper.
+//
+// KEYS[4] - payloads hash, KEYS[5] - optional holders of the room mode
+// ARGV[1] - expired heartbeat score, ARGV[2] - max count of expired sessions
+// reaped at once, ARGV[3] - now, ARGV[4] - holders type, hash or set
+var reapActiveSessionsScript = redis.NewScript(sessionsScriptHelpers + `
+local reaped = {}
+local released = {}
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, member in ipairs(expired) do
+	local duration = redis.call('ZSCORE', KEYS[1], member)
+	local pushed = redis.call('HGET', KEYS[3], member)
+	leave(member)
+	redis.call('HDEL', KEYS[4], member)
+	if duration then
+		released[member] = true
+		table.insert(reaped, member)
+		table.insert(reaped, duration)
+		table.insert(reaped, pushed or tostring(tonumber(ARGV[3]) - tonumber(duration)))
+	end
+end
+local sessions = redis.call('ZRANGE', KEYS[1], 0, -1, 'WITHSCORES')
+for i = 1, #sessions, 2 do
+	if not redis.call('ZSCORE', KEYS[2], sessions[i]) then
+		local pushed = redis.call('HGET', KEYS[3], sessions[i])
+		leave(sessions[i])
+		redis.call('HDEL', KEYS[4], sessions[i])
+		released[sessions[i]] = true
+		table.insert(reaped, sessions[i])
+		table.insert(reaped, sessions[i + 1])
+		table.insert(reaped, pushed or tostring(tonumber(ARGV[3]) - tonumber(sessions[i + 1])))
+	end
+end
+local orphaned = 0
+for _, member in ipairs(redis.call('HKEYS', KEYS[4])) do
+	if not redis.call('ZSCORE', KEYS[1], member) then
+		redis.call('HDEL', KEYS[4], member)
+		orphaned = orphaned + 1
+	end
+end
+local stale = {}
+local holders = {}
+if KEYS[5] and ARGV[4] == 'set' then
+	holders = redis.call('SMEMBERS', KEYS[5])
+elseif KEYS[5] then
+	holders = redis.call('HKEYS', KEYS[5])
+end
+for _, member in ipairs(holders) do
+	if not released[member] and not redis.call('ZSCORE', KEYS[1], member) then
+		released[member] = true
+		table.insert(stale, member)
+	end
+end
+return {orphaned, reaped, stale}
+`)
+
+// Increments the counter of fixed rate limiting window.
+//
+// KEYS[1] - counter key
+// ARGV[1] - window length in milliseconds
+var rateLimitScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// Registers the report on the user and mutes the user once enough
+// distinct users have reported. Returns whether the user was muted.
+//
+// KEYS[1] - reporters set, KEYS[2] - mute key
+// ARGV[1] - reporter id, ARGV[2] - reports window in milliseconds,
+// ARGV[3] - count of reports to mute, ARGV[4] - mute duration in milliseconds
+var reportUserScript = redis.NewScript(`
+redis.call('SADD', KEYS[1], ARGV[1])
+if redis.call('PTTL', KEYS[1]) < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+if redis.call('SCARD', KEYS[1]) >= tonumber(ARGV[3]) then
+	redis.call('SET', KEYS[2], ARGV[1], 'PX', ARGV[4])
+	redis.call('DEL', KEYS[1])
+	return 1
+end
+return 0
+`)
+
+// Acquires or prolongs a lock owned by given token.
+//
+// KEYS[1] - lock key
+// ARGV[1] - owner token, ARGV[2] - lock ttl in milliseconds
+var acquireLockScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return 1
+end
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// Releases a lock owned by given token.
+//
+// KEYS[1] - lock key
+// ARGV[1] - owner token
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Redeems the room invite code and adds the user to room members.
+// Members join again without consuming the invite. Returns 1 when the user
+// is a member, 0 for unknown code, -1 for expired and -2 for exhausted invite.
+//
+// KEYS[1] - room invite hash, KEYS[2] - room members set
+// ARGV[1] - invite code, ARGV[2] - user id, ARGV[3] - now in seconds
+var redeemInviteScript = redis.NewScript(`
+if redis.call('SISMEMBER', KEYS[2], ARGV[2]) == 1 then
+	return 1
+end
+local invite = redis.call('HMGET', KEYS[1], 'code', 'expires', 'maxuses', 'uses')
+if not invite[1] or invite[1] ~= ARGV[1] then
+	return 0
+end
+local expires = tonumber(invite[2]) or 0
+if expires > 0 and expires <= tonumber(ARGV[3]) then
+	return -1
+end
+local maxUses = tonumber(invite[3]) or 0
+if maxUses > 0 and (tonumber(invite[4]) or 0) >= maxUses then
+	return -2
+end
+redis.call('HINCRBY', KEYS[1], 'uses', 1)
+redis.call('SADD', KEYS[2], ARGV[2])
+return 1
+`)
+
+// Creates the custom room unless it exists or the user owns too many rooms.
+// Returns 1 when created, -1 if the room exists and 0 if the quota is exceeded.
+//
+// KEYS[1] - custom rooms hash, KEYS[2] - rooms owned by the user set
+// ARGV[1] - room id, ARGV[2] - user id, ARGV[3] - max count of rooms per user
+var createCustomRoomScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 1 then
+	return -1
+end
+if redis.call('SCARD', KEYS[2]) >= tonumber(ARGV[3]) then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+redis.call('SADD', KEYS[2], ARGV[1])
+return 1
+`)
+
+// Removes the custom room of the owner. Returns 1 when removed, 0 if the room
+// does not exist and -1 if the room belongs to another user.
+//
+// KEYS[1] - custom rooms hash, KEYS[2] - rooms owned by the user set
+// ARGV[1] - room id, ARGV[2] - user id
+var removeCustomRoomScript = redis.NewScript(`
+local owner = redis.call('HGET', KEYS[1], ARGV[1])
+if not owner then
+	return 0
+end
+if owner ~= ARGV[2] then
+	return -1
+end
+redis.call('HDEL', KEYS[1], ARGV[1])
+redis.call('SREM', KEYS[2], ARGV[1])
+return 1
+`)
+
+// Transfers the custom room to the new owner unless the new owner owns too many rooms.
+// Returns 1 when transferred, -1 if the room does not belong to the current owner
+// and 0 if the quota is exceeded.
+//
+// KEYS[1] - custom rooms hash, KEYS[2] - rooms owned by the current owner set,
+// KEYS[3] - rooms owned by the new owner set
+// ARGV[1] - room id, ARGV[2] - current owner id, ARGV[3] - new owner id, ARGV[4] - max count of rooms per user
+var transferCustomRoomScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], ARGV[1]) ~= ARGV[2] then
+	return -1
+end
+if redis.call('SCARD', KEYS[3]) >= tonumber(ARGV[4]) then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[3])
+redis.call('SREM', KEYS[2], ARGV[1])
+redis.call('SADD', KEYS[3], ARGV[1])
+return 1
+`)
+
+// Aggregates holders of every team. Returns team, count of holders and
+// total hold time triples, the time includes durations of holds in progress.
+//
+// KEYS[1] - active sessions, KEYS[2] - team members hash, KEYS[3] - team hold time hash
+var teamStandingsScript = redis.NewScript(`
+local holders = {}
+local durations = {}
+local totals = redis.call('HGETALL', KEYS[3])
+for i = 1, #totals, 2 do
+	holders[totals[i]] = 0
+	durations[totals[i]] = tonumber(totals[i + 1])
+end
+local members = redis.call('HGETALL', KEYS[2])
+for i = 1, #members, 2 do
+	local duration = redis.call('ZSCORE', KEYS[1], members[i])
+	if duration then
+		local team = members[i + 1]
+		holders[team] = (holders[team] or 0) + 1
+		durations[team] = (durations[team] or 0) + tonumber(duration)
+	end
+end
+local standings = {}
+for team, count in pairs(holders) do
+	table.insert(standings, team)
+	table.insert(standings, count)
+	table.insert(standings, durations[team])
+end
+return standings
+`)
+
+// Adds the holder to the chain, the first holder starts a new chain and holders of crashed
+// instances are released by the reaper. Returns chain start time, count of holders and
+// count of participants.
+//
+// KEYS[1] - chain hash, KEYS[2] - chain holders set, KEYS[3] - chain participants set
+// ARGV[1] - user id, ARGV[2] - now in seconds
+var joinChainScript = redis.NewScript(`
+if redis.call('SCARD', KEYS[2]) == 0 then
+	redis.call('DEL', KEYS[1], KEYS[3])
+	redis.call('HSET', KEYS[1], 'started', ARGV[2], 'peak', 0)
+end
+redis.call('SADD', KEYS[2], ARGV[1])
+redis.call('SADD', KEYS[3], ARGV[1])
+local holders = redis.call('SCARD', KEYS[2])
+if holders > tonumber(redis.call('HGET', KEYS[1], 'peak')) then
+	redis.call('HSET', KEYS[1], 'peak', holders)
+end
+return {tonumber(redis.call('HGET', KEYS[1], 'started')), holders, redis.call('SCARD', KEYS[3])}
+`)
+
+// Removes the holder from the chain, the chain breaks when the last holder leaves.
+// Returns nil while the chain lasts or start time, count of participants and peak
+// count of holders of the broken chain.
+//
+// KEYS[1] - chain hash, KEYS[2] - chain holders set, KEYS[3] - chain participants set
+// ARGV[1] - user id
+var leaveChainScript = redis.NewScript(`
+if redis.call('SREM', KEYS[2], ARGV[1]) == 0 or redis.call('SCARD', KEYS[2]) > 0 then
+	return nil
+end
+local chain = redis.call('HMGET', KEYS[1], 'started', 'peak')
+local participants = redis.call('SCARD', KEYS[3])
+redis.call('DEL', KEYS[1], KEYS[3])
+if not chain[1] then
+	return nil
+end
+return {tonumber(chain[1]), participants, tonumber(chain[2])}
+`)
+
+// Schedules a new tournament of the room unless the previous one is not finished,
+// the state of the previous tournament is removed. Returns 1 when scheduled and 0 otherwise.
+//
+// KEYS[1] - tournament hash, KEYS[2] - registered players set, KEYS[3] - holding players hash,
+// KEYS[4] - eliminated players zset, KEYS[5] - hold durations hash
+// ARGV[1] - start time in seconds, ARGV[2] - grace period in seconds
+var scheduleTournamentScript = redis.NewScript(`
+local status = redis.call('HGET', KEYS[1], 'status')
+if status and status ~= 'finished' then
+	return 0
+end
+redis.call('DEL', KEYS[1], KEYS[2], KEYS[3], KEYS[4], KEYS[5])
+redis.call('HSET', KEYS[1], 'status', 'registration', 'starts', ARGV[1], 'grace', ARGV[2], 'seq', 0)
+return 1
+`)
+
+// Registers the player of the tournament while the registration is open.
+// Returns 1 when registered, 0 if the registration is closed and -1 if no tournament is scheduled.
+//
+// KEYS[1] - tournament hash, KEYS[2] - registered players set
+// ARGV[1] - user id, ARGV[2] - now in seconds
+var registerTournamentScript = redis.NewScript(`
+local t = redis.call('HMGET', KEYS[1], 'status', 'starts')
+if not t[1] or t[1] == 'finished' then
+	return -1
+end
+if t[1] ~= 'registration' or tonumber(ARGV[2]) >= tonumber(t[2]) then
+	return 0
+end
+redis.call('SADD', KEYS[2], ARGV[1])
+return 1
+`)
+
+// Starts holding of the registered player within the grace period.
+// Returns 1 when started, 0 for unregistered player, -1 if no tournament is scheduled,
+// -2 before the start, -3 after the grace period and -4 if the player has already played.
+//
+// KEYS[1] - tournament hash, KEYS[2] - registered players set, KEYS[3] - holding players hash,
+// KEYS[4] - eliminated players zset
+// ARGV[1] - user id, ARGV[2] - now in seconds
+var pushTournamentScript = redis.NewScript(`
+local t = redis.call('HMGET', KEYS[1], 'status', 'starts', 'grace')
+if not t[1] or t[1] == 'finished' then
+	return -1
+end
+local now = tonumber(ARGV[2])
+if now < tonumber(t[2]) then
+	return -2
+end
+if now > tonumber(t[2]) + tonumber(t[3]) then
+	return -3
+end
+if redis.call('SISMEMBER', KEYS[2], ARGV[1]) == 0 then
+	return 0
+end
+if redis.call('HEXISTS', KEYS[3], ARGV[1]) == 1 or redis.call('ZSCORE', KEYS[4], ARGV[1]) then
+	return -4
+end
+redis.call('HSET', KEYS[3], ARGV[1], ARGV[2])
+redis.call('HSET', KEYS[1], 'status', 'running')
+return 1
+`)
+
+// Eliminates the holding player of the running tournament.
+// Returns the count of remaining holders or -1 if the player is not holding.
+//
+// KEYS[1] - tournament hash, KEYS[2] - holding players hash, KEYS[3] - eliminated players zset,
+// KEYS[4] - hold durations hash
+// ARGV[1] - user id, ARGV[2] - now in seconds
+var eliminateTournamentScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'status') ~= 'running' then
+	return -1
+end
+local pushed = redis.call('HGET', KEYS[2], ARGV[1])
+if not pushed then
+	return -1
+end
+redis.call('HDEL', KEYS[2], ARGV[1])
+local seq = redis.call('HINCRBY', KEYS[1], 'seq', 1)
+redis.call('ZADD', KEYS[3], seq, ARGV[1])
+redis.call('HSET', KEYS[4], ARGV[1], tonumber(ARGV[2]) - tonumber(pushed))
+return redis.call('HLEN', KEYS[2])
+`)
+
+// Finishes the tournament once the grace period is over and at most one player is holding.
+// Returns the final standing as user id, place and duration triples, best first,
+// or nil if the tournament is not finished by this call. Players eliminated later get
+// lower places and registered players who never pushed share the last place.
+//
+// KEYS[1] - tournament hash, KEYS[2] - registered players set, KEYS[3] - holding players hash,
+// KEYS[4] - eliminated players zset, KEYS[5] - hold durations hash
+// ARGV[1] - now in seconds
+var finishTournamentScript = redis.NewScript(`
+local t = redis.call('HMGET', KEYS[1], 'status', 'starts', 'grace')
+if not t[1] or t[1] == 'finished' then
+	return nil
+end
+local now = tonumber(ARGV[1])
+if now <= tonumber(t[2]) + tonumber(t[3]) or redis.call('HLEN', KEYS[3]) > 1 then
+	return nil
+end
+redis.call('HSET', KEYS[1], 'status', 'finished', 'finished', ARGV[1])
+local standings = {}
+local place = 1
+local alive = redis.call('HGETALL', KEYS[3])
+for i = 1, #alive, 2 do
+	table.insert(standings, alive[i])
+	table.insert(standings, place)
+	table.insert(standings, now - tonumber(alive[i + 1]))
+	place = place + 1
+end
+for _, user in ipairs(redis.call('ZREVRANGE', KEYS[4], 0, -1)) do
+	table.insert(standings, user)
+	table.insert(standings, place)
+	table.insert(standings, tonumber(redis.call('HGET', KEYS[5], user)))
+	place = place + 1
+end
+for _, user in ipairs(redis.call('SMEMBERS', KEYS[2])) do
+	if redis.call('HEXISTS', KEYS[3], user) == 0 and not redis.call('ZSCORE', KEYS[4], user) then
+		table.insert(standings, user)
+		table.insert(standings, place)
+		table.insert(standings, 0)
+	end
+end
+redis.call('DEL', KEYS[3])
+return standings
+`)
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"buttonmania.win/protocol"
+	"github.com/go-redis/redis/v8"
+)
+
+// store the team the user holds the button for.
+func (r *Redis) joinTeam(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+	team string,
+) error {
+	teamMembersKey := roomTaggedKey(clientId, roomId, RedisKeyTeamMembers)
+	return r.client.HSet(
+		r.ctx,
+		teamMembersKey,
+		string(userID),
+		team,
+	).Err()
+}
+
+// get the team the user holds the button for, empty if the user is not holding.
+func (r *Redis) getUserTeam(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+) (string, error) {
+	teamMembersKey := roomTaggedKey(clientId, roomId, RedisKeyTeamMembers)
+	team, err := r.client.HGet(
+		r.ctx,
+		teamMembersKey,
+		string(userID),
+	).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return team, err
+}
+
+// remove the user from the team and add the finished hold to the team hold time.
+func (r *Redis) leaveTeam(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+	team string,
+	duration int64,
+) error {
+	teamMembersKey := roomTaggedKey(clientId, roomId, RedisKeyTeamMembers)
+	teamTimeKey := roomTaggedKey(clientId, roomId, RedisKeyTeamTime)
+	pipe := r.client.TxPipeline()
+	pipe.HDel(r.ctx, teamMembersKey, string(userID))
+	if duration > 0 {
+		pipe.HIncrBy(r.ctx, teamTimeKey, team, duration)
+	}
+	_, err := pipe.Exec(r.ctx)
+	return err
+}
+
+// get live standings of the room teams, the longest total hold time first.
+func (r *Redis) getTeamStandings(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) ([]protocol.TeamStanding, error) {
+	result, err := teamStandingsScript.Run(
+		r.ctx,
+		r.client,
+		[]string{
+			roomTaggedKey(clientId, roomId, RedisKeyActiveSessions),
+			roomTaggedKey(clientId, roomId, RedisKeyTeamMembers),
+			roomTaggedKey(clientId, roomId, RedisKeyTeamTime),
+		},
+	).Slice()
+	if err != nil {
+		return nil, err
+	}
+	standings := make([]protocol.TeamStanding, 0, len(result)/3)
+	for i := 0; i+2 < len(result); i += 3 {
+		team, _ := result[i].(string)
+		holders, _ := result[i+1].(int64)
+		duration, _ := result[i+2].(int64)
+		standings = append(standings, protocol.TeamStanding{
+			Team:     team,
+			Holders:  holders,
+			Duration: duration,
+		})
+	}
+	slices.SortFunc(standings, func(a, b protocol.TeamStanding) int {
+		return cmp.Or(cmp.Compare(b.Duration, a.Duration), cmp.Compare(a.Team, b.Team))
+	})
+	return standings, nil
+}
+
+// remove team members and team hold time of the room.
+func (r *Redis) removeTeams(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) error {
+	return r.client.Del(
+		r.ctx,
+		roomTaggedKey(clientId, roomId, RedisKeyTeamMembers),
+		roomTaggedKey(clientId, roomId, RedisKeyTeamTime),
+	).Err()
+}
+
+// add the finished hold of the user to the team leaderboard.
+func (p *Postgres) addTeamRecord(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	team string,
+	userID protocol.UserID,
+	record protocol.GameplayRecord,
+) error {
+	_, err := p.pool.Exec(
+		p.ctx,
+		`INSERT INTO team_records(client_id, room_id, team, user_id, ts, duration)
+		VALUES($1, $2, $3, $4, $5, $6)
+		ON CONFLICT DO NOTHING`,
+		clientId,
+		roomId,
+		team,
+		userID,
+		time.Unix(record.Timestamp, 0),
+		record.Duration,
+	)
+	return err
+}
+
+// retrieves teams of the room ordered by total hold time of finished holds.
+func (p *Postgres) getTeamLeaderboard(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) ([]protocol.TeamLeaderboardEntry, error) {
+	rows, err := p.queryReadOnly(
+		`SELECT team, SUM(duration), COUNT(DISTINCT user_id), MAX(duration)
+		FROM team_records
+		WHERE client_id=$1 AND room_id=$2
+		GROUP BY team
+		ORDER BY SUM(duration) DESC, team`,
+		clientId,
+		roomId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	entries := []protocol.TeamLeaderboardEntry{}
+	for rows.Next() {
+		var entry protocol.TeamLeaderboardEntry
+		if err := rows.Scan(&entry.Team, &entry.Duration, &entry.Players, &entry.Best); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
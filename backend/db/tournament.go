@@ -0,0 +1,374 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"buttonmania.win/protocol"
+	"github.com/go-redis/redis/v8"
+	"github.com/jackc/pgx/v5"
+)
+
+// Define tournament errors
+var (
+	ErrTournamentScheduled          = errors.New("tournament is not finished yet")
+	ErrTournamentNotScheduled       = errors.New("tournament is not scheduled")
+	ErrTournamentRegistrationClosed = errors.New("tournament registration is closed")
+	ErrTournamentNotRegistered      = errors.New("player is not registered in the tournament")
+	ErrTournamentNotStarted         = errors.New("tournament is not started yet")
+	ErrTournamentGraceOver          = errors.New("tournament grace period is over")
+	ErrTournamentPlayed             = errors.New("player has already played in the tournament")
+)
+
+// build keys of the room tournament state in the order expected by tournament scripts.
+func tournamentKeys(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	kinds ...RedisKey,
+) []string {
+	keys := make([]string, len(kinds))
+	for i, kind := range kinds {
+		keys[i] = roomTaggedKey(clientId, roomId, kind)
+	}
+	return keys
+}
+
+// schedule a new tournament of the room, the previous tournament must be finished.
+func (r *Redis) scheduleTournament(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	starts int64,
+	grace int64,
+) error {
+	scheduled, err := scheduleTournamentScript.Run(
+		r.ctx,
+		r.client,
+		tournamentKeys(
+			clientId,
+			roomId,
+			RedisKeyTournament,
+			RedisKeyTournamentPlayers,
+			RedisKeyTournamentAlive,
+			RedisKeyTournamentOut,
+			RedisKeyTournamentDurations,
+		),
+		starts,
+		grace,
+	).Int64()
+	if err != nil {
+		return err
+	} else if scheduled == 0 {
+		return ErrTournamentScheduled
+	}
+	return nil
+}
+
+// get the state of the room tournament, nil if no tournament was scheduled.
+func (r *Redis) getTournament(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (*protocol.Tournament, error) {
+	keys := tournamentKeys(
+		clientId,
+		roomId,
+		RedisKeyTournament,
+		RedisKeyTournamentPlayers,
+		RedisKeyTournamentAlive,
+	)
+	pipe := r.client.Pipeline()
+	fieldsCmd := pipe.HGetAll(r.ctx, keys[0])
+	registeredCmd := pipe.SCard(r.ctx, keys[1])
+	remainingCmd := pipe.HLen(r.ctx, keys[2])
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return nil, err
+	}
+	fields := fieldsCmd.Val()
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	starts, _ := strconv.ParseInt(fields["starts"], 10, 64)
+	grace, _ := strconv.ParseInt(fields["grace"], 10, 64)
+	return &protocol.Tournament{
+		ID:         starts,
+		Status:     protocol.TournamentStatus(fields["status"]),
+		Starts:     starts,
+		Grace:      grace,
+		Registered: registeredCmd.Val(),
+		Remaining:  remainingCmd.Val(),
+	}, nil
+}
+
+// register the player of the room tournament.
+func (r *Redis) registerTournamentPlayer(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+	now int64,
+) error {
+	result, err := registerTournamentScript.Run(
+		r.ctx,
+		r.client,
+		tournamentKeys(
+			clientId,
+			roomId,
+			RedisKeyTournament,
+			RedisKeyTournamentPlayers,
+		),
+		string(userID),
+		now,
+	).Int64()
+	if err != nil {
+		return err
+	}
+	switch result {
+	case -1:
+		return ErrTournamentNotScheduled
+	case 0:
+		return ErrTournamentRegistrationClosed
+	}
+	return nil
+}
+
+// start holding of the registered player within the grace period of the tournament.
+func (r *Redis) pushTournament(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+	now int64,
+) error {
+	result, err := pushTournamentScript.Run(
+		r.ctx,
+		r.client,
+		tournamentKeys(
+			clientId,
+			roomId,
+			RedisKeyTournament,
+			RedisKeyTournamentPlayers,
+			RedisKeyTournamentAlive,
+			RedisKeyTournamentOut,
+		),
+		string(userID),
+		now,
+	).Int64()
+	if err != nil {
+		return err
+	}
+	switch result {
+	case 0:
+		return ErrTournamentNotRegistered
+	case -1:
+		return ErrTournamentNotScheduled
+	case -2:
+		return ErrTournamentNotStarted
+	case -3:
+		return ErrTournamentGraceOver
+	case -4:
+		return ErrTournamentPlayed
+	}
+	return nil
+}
+
+// eliminate the player released the button, returns the count of remaining holders
+// and false if the player was not holding.
+func (r *Redis) eliminateTournamentPlayer(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+	now int64,
+) (int64, bool, error) {
+	remaining, err := eliminateTournamentScript.Run(
+		r.ctx,
+		r.client,
+		tournamentKeys(
+			clientId,
+			roomId,
+			RedisKeyTournament,
+			RedisKeyTournamentAlive,
+			RedisKeyTournamentOut,
+			RedisKeyTournamentDurations,
+		),
+		string(userID),
+		now,
+	).Int64()
+	if err != nil || remaining < 0 {
+		return 0, false, err
+	}
+	return remaining, true, nil
+}
+
+// finish the tournament if it is over, returns the final standing without profiles
+// and false if the tournament is not finished by this call.
+func (r *Redis) finishTournament(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	now int64,
+) ([]protocol.TournamentStanding, bool, error) {
+	result, err := finishTournamentScript.Run(
+		r.ctx,
+		r.client,
+		tournamentKeys(
+			clientId,
+			roomId,
+			RedisKeyTournament,
+			RedisKeyTournamentPlayers,
+			RedisKeyTournamentAlive,
+			RedisKeyTournamentOut,
+			RedisKeyTournamentDurations,
+		),
+		now,
+	).Slice()
+	if err == redis.Nil {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	standings := make([]protocol.TournamentStanding, 0, len(result)/3)
+	for i := 0; i+2 < len(result); i += 3 {
+		userID, _ := result[i].(string)
+		place, _ := result[i+1].(int64)
+		duration, _ := result[i+2].(int64)
+		standings = append(standings, protocol.TournamentStanding{
+			User:     protocol.UserProfile{UserID: protocol.UserID(userID)},
+			Place:    place,
+			Duration: duration,
+		})
+	}
+	return standings, true, nil
+}
+
+// remove the tournament state of the room.
+func (r *Redis) removeTournament(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) error {
+	return r.client.Del(
+		r.ctx,
+		tournamentKeys(
+			clientId,
+			roomId,
+			RedisKeyTournament,
+			RedisKeyTournamentPlayers,
+			RedisKeyTournamentAlive,
+			RedisKeyTournamentOut,
+			RedisKeyTournamentDurations,
+		)...,
+	).Err()
+}
+
+// publish the tournament event to the room channel.
+func (r *Redis) publishTournamentUpdate(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	update protocol.TournamentUpdate,
+) error {
+	channel := roomTaggedKey(clientId, roomId, RedisKeyTournamentEvents)
+	return r.client.Publish(
+		r.ctx,
+		channel,
+		update,
+	).Err()
+}
+
+// subscribe to tournament events of the room.
+func (r *Redis) subscribeTournamentUpdates(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+) (<-chan protocol.TournamentUpdate, func() error) {
+	payloads, stop := r.subscribeRoomChannel(clientId, roomId, RedisKeyTournamentEvents)
+	updates := make(chan protocol.TournamentUpdate)
+	go func() {
+		defer close(updates)
+		for payload := range payloads {
+			var update protocol.TournamentUpdate
+			if err := json.Unmarshal([]byte(payload), &update); err != nil {
+				log.Println("Failed to decode tournament update:", err)
+				continue
+			}
+			updates <- update
+		}
+	}()
+	return updates, stop
+}
+
+// store the final standing of the finished tournament.
+func (p *Postgres) addTournamentResult(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	tournamentId int64,
+	standings []protocol.TournamentStanding,
+) error {
+	batch := &pgx.Batch{}
+	for _, standing := range standings {
+		batch.Queue(
+			`INSERT INTO tournament_results(client_id, room_id, tournament_id, user_id, place, duration)
+			VALUES($1, $2, $3, $4, $5, $6)
+			ON CONFLICT DO NOTHING`,
+			clientId,
+			roomId,
+			tournamentId,
+			standing.User.UserID,
+			standing.Place,
+			standing.Duration,
+		)
+	}
+	return p.pool.SendBatch(p.ctx, batch).Close()
+}
+
+// retrieves final standings of the latest finished tournaments of the room, the latest first.
+func (p *Postgres) getTournamentResults(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	count int64,
+) ([]protocol.TournamentResult, error) {
+	rows, err := p.queryReadOnly(
+		`SELECT t.tournament_id, t.finished_at, t.place, t.duration, t.user_id,
+			u.name, u.username, u.photo_url, u.premium, u.language, u.privacy
+		FROM tournament_results t
+		LEFT JOIN users u ON u.user_id = t.user_id
+		WHERE t.client_id=$1 AND t.room_id=$2 AND t.tournament_id IN (
+			SELECT DISTINCT tournament_id
+			FROM tournament_results
+			WHERE client_id=$1 AND room_id=$2
+			ORDER BY tournament_id DESC
+			LIMIT $3
+		)
+		ORDER BY t.tournament_id DESC, t.place`,
+		clientId,
+		roomId,
+		count,
+	)
+	if err != nil {
+		return nil, err
+	}
+	results := []protocol.TournamentResult{}
+	for rows.Next() {
+		var tournamentId int64
+		var finished time.Time
+		var standing protocol.TournamentStanding
+		err := scanUserProfile(
+			rows,
+			&standing.User,
+			&tournamentId,
+			&finished,
+			&standing.Place,
+			&standing.Duration,
+			&standing.User.UserID,
+		)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if len(results) == 0 || results[len(results)-1].ID != tournamentId {
+			results = append(results, protocol.TournamentResult{
+				ID:       tournamentId,
+				Finished: finished.Unix(),
+			})
+		}
+		last := &results[len(results)-1]
+		last.Standings = append(last.Standings, standing)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"encoding/json"
+	"time"
+
+	"buttonmania.win/protocol"
+	"github.com/jackc/pgx/v5"
+)
+
+// logs the webhook delivery attempt, zero status means the request failed without response.
+func (p *Postgres) addWebhookDelivery(
+	event protocol.WebhookEvent,
+	url string,
+	attempt int,
+	status int,
+	deliveryErr string,
+) error {
+	_, err := p.pool.Exec(
+		p.ctx,
+		`INSERT INTO webhook_deliveries(delivery_id, client_id, room_id, event, url, attempt, status, error)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8)`,
+		event.ID,
+		event.ClientID,
+		event.RoomID,
+		event.Type,
+		url,
+		attempt,
+		status,
+		deliveryErr,
+	)
+	return err
+}
+
+// retrieves the latest webhook delivery attempts of the room, newest first.
+func (p *Postgres) getWebhookDeliveries(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	count int64,
+) ([]protocol.WebhookDelivery, error) {
+	rows, err := p.queryReadOnly(
+		`SELECT delivery_id, event, attempt, status, error, ts
+		FROM webhook_deliveries
+		WHERE client_id=$1 AND room_id=$2
+		ORDER BY id DESC
+		LIMIT $3`,
+		clientId,
+		roomId,
+		count,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (protocol.WebhookDelivery, error) {
+		var delivery protocol.WebhookDelivery
+		var ts time.Time
+		err := row.Scan(
+			&delivery.DeliveryID,
+			&delivery.Event,
+			&delivery.Attempt,
+			&delivery.Status,
+			&delivery.Error,
+			&ts,
+		)
+		delivery.Timestamp = ts.Unix()
+		return delivery, err
+	})
+}
+
+// removes webhook delivery log entries older than the retention period.
+func (p *Postgres) removeExpiredWebhookDeliveries(retention time.Duration) (int64, error) {
+	tag, err := p.pool.Exec(
+		p.ctx,
+		`DELETE FROM webhook_deliveries WHERE ts < $1`,
+		time.Now().Add(-retention),
+	)
+	return tag.RowsAffected(), err
+}
+
+// schedules the next attempt of the failed webhook delivery.
+func (p *Postgres) addWebhookRetry(
+	event protocol.WebhookEvent,
+	url string,
+	attempt int,
+	due time.Time,
+) error {
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = p.pool.Exec(
+		p.ctx,
+		`INSERT INTO webhook_retries(url, attempt, event, due)
+		VALUES($1, $2, $3, $4)`,
+		url,
+		attempt,
+		string(eventJSON),
+		due,
+	)
+	return err
+}
+
+// removes and returns webhook retries due by now, retries locked by another
+// instance are skipped, so every retry is claimed once.
+func (p *Postgres) claimWebhookRetries(count int64) ([]WebhookRetry, error) {
+	rows, err := p.pool.Query(
+		p.ctx,
+		`DELETE FROM webhook_retries
+		WHERE id IN (
+			SELECT id FROM webhook_retries
+			WHERE due <= $1
+			ORDER BY due
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING url, attempt, event`,
+		time.Now(),
+		count,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (WebhookRetry, error) {
+		var retry WebhookRetry
+		var eventJSON string
+		if err := row.Scan(&retry.URL, &retry.Attempt, &eventJSON); err != nil {
+			return retry, err
+		}
+		err := json.Unmarshal([]byte(eventJSON), &retry.Event)
+		return retry, err
+	})
+}
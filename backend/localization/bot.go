@@ -9,8 +9,10 @@ import (
 
 //go:embed en/bot/start.txt
 //go:embed en/bot/donate.txt
+//go:embed en/bot/room_expiry.txt
 //go:embed ru/bot/start.txt
 //go:embed ru/bot/donate.txt
+//go:embed ru/bot/room_expiry.txt
 var fsBot embed.FS
 
 // Bot is responsible for loading and providing localized strings for bot commands.
@@ -33,7 +35,7 @@ func NewBotLocalization() (*BotLocalization, error) {
 	localization := make(map[protocol.UserLocale]map[string]string)
 	for _, locale := range []protocol.UserLocale{protocol.EN, protocol.RU} {
 		strings := make(map[string]string)
-		for _, command := range []string{"start", "donate"} {
+		for _, command := range []string{"start", "donate", "room_expiry"} {
 			str, err := loadLocalizedStrings(fsBot, locale, command)
 			if err != nil {
 				return nil, err
@@ -4,6 +4,7 @@ import (
 	"embed"
 	"fmt"
 	"math/rand"
+	"slices"
 	"strings"
 
 	"buttonmania.win/protocol"
@@ -72,10 +73,35 @@ func NewMessagesLocalization(
 	}, nil
 }
 
-// RandomLocalizedMessage returns a random localized message.
+// NewCustomMessagesLocalization creates a new MessagesLocalization instance
+// from the message pack uploaded by the room owner.
+func NewCustomMessagesLocalization(
+	roomId protocol.RoomID,
+	pack map[protocol.UserLocale][]string,
+) *MessagesLocalization {
+	messages := make(map[protocol.UserLocale][]string)
+	for locale, msgs := range pack {
+		if len(msgs) > 0 {
+			messages[locale] = slices.Clone(msgs)
+		}
+	}
+	return &MessagesLocalization{
+		roomId,
+		messages,
+	}
+}
+
+// RandomLocalizedMessage returns a random localized message, falls back to english
+// messages if the locale is missing in the pack, nil if there are no messages.
 func (s *MessagesLocalization) RandomLocalizedMessage(
 	locale protocol.UserLocale,
 ) *string {
-	messages := s.messages[locale]
+	messages, exists := s.messages[locale]
+	if !exists {
+		messages = s.messages[protocol.EN]
+	}
+	if len(messages) == 0 {
+		return nil
+	}
 	return &messages[rand.Intn(len(messages))]
 }
@@ -0,0 +1,57 @@
+package localization
+
+import (
+	"embed"
+	"fmt"
+
+	"buttonmania.win/protocol"
+)
+
+//go:embed en/moderation/*.txt
+//go:embed ru/moderation/*.txt
+var fsModeration embed.FS
+
+// ModerationLocalization is responsible for loading and providing localized moderation messages.
+type ModerationLocalization struct {
+	localization map[protocol.UserLocale]map[protocol.ErrorCode]string
+}
+
+// NewModerationLocalization creates a new ModerationLocalization instance.
+func NewModerationLocalization() (*ModerationLocalization, error) {
+	localization := make(map[protocol.UserLocale]map[protocol.ErrorCode]string)
+	for _, locale := range []protocol.UserLocale{protocol.EN, protocol.RU} {
+		strings := make(map[protocol.ErrorCode]string)
+		for _, code := range []protocol.ErrorCode{
+			protocol.ChatTooLong,
+			protocol.ChatRateLimited,
+			protocol.ChatForbiddenWord,
+			protocol.ChatLink,
+			protocol.ChatMuted,
+			protocol.RoomKicked,
+			protocol.RoomBanned,
+			protocol.TournamentNotScheduled,
+			protocol.TournamentNotRegistered,
+			protocol.TournamentNotStarted,
+			protocol.TournamentGraceOver,
+			protocol.TournamentPlayed,
+		} {
+			filename := fmt.Sprintf("%s/moderation/%s.txt", locale, code)
+			content, err := fsModeration.ReadFile(filename)
+			if err != nil {
+				return nil, err
+			}
+			strings[code] = string(content)
+		}
+		localization[locale] = strings
+	}
+
+	return &ModerationLocalization{
+		localization,
+	}, nil
+}
+
+// LocalizedErrorString returns a localized string for moderation error code.
+func (m *ModerationLocalization) LocalizedErrorString(locale protocol.UserLocale, code protocol.ErrorCode) string {
+	strings := m.localization[locale]
+	return strings[code]
+}
@@ -7,10 +7,13 @@ import (
 	"os/signal"
 	"syscall"
 
+	"buttonmania.win/archiver"
 	"buttonmania.win/bot"
 	"buttonmania.win/conf"
 	"buttonmania.win/db"
+	"buttonmania.win/reaper"
 	"buttonmania.win/web"
+	"buttonmania.win/webhook"
 	"github.com/alecthomas/kingpin"
 	"github.com/gin-gonic/gin"
 	"github.com/gookit/config/v2"
@@ -26,11 +29,27 @@ var (
 	serverTLSKey   = kingpin.Flag(string(web.KeyServerTLSKey), "Server tls key file.").Envar("SERVER_TLS_KEY").String()
 	allowedOrigins = kingpin.Flag(string(web.KeyAllowedOrigins), "Allowed CORS origins.").Envar("CORS_ORIGINS").Default("*").String()
 	postgresUrl    = kingpin.Flag(string(db.KeyPostgresUrl), "Postgres server url.").Envar("POSTGRES_URL").Required().String()
-	redisAddress   = kingpin.Flag(string(db.KeyRedisAddress), "Redis server address.").Envar("REDIS_ADDRESS").Required().String()
+	pgReplicaUrl   = kingpin.Flag(string(db.KeyPostgresReplicaUrl), "Postgres read replica url.").Envar("POSTGRES_REPLICA_URL").Default("").String()
+	pgMaxLag       = kingpin.Flag(string(db.KeyPostgresMaxReplicaLag), "Postgres replica max lag in seconds before reads fall back to primary.").Envar("POSTGRES_MAX_REPLICA_LAG").Default("5").Int()
+	pgMaxConns     = kingpin.Flag(string(db.KeyPostgresMaxConns), "Postgres pool max connections.").Envar("POSTGRES_MAX_CONNS").Default("0").Int()
+	pgMinConns     = kingpin.Flag(string(db.KeyPostgresMinConns), "Postgres pool min connections.").Envar("POSTGRES_MIN_CONNS").Default("0").Int()
+	pgConnLifetime = kingpin.Flag(string(db.KeyPostgresMaxConnLifetime), "Postgres connection max lifetime in seconds.").Envar("POSTGRES_MAX_CONN_LIFETIME").Default("0").Int()
+	pgConnIdleTime = kingpin.Flag(string(db.KeyPostgresMaxConnIdleTime), "Postgres connection max idle time in seconds.").Envar("POSTGRES_MAX_CONN_IDLE_TIME").Default("0").Int()
+	pgHealthPeriod = kingpin.Flag(string(db.KeyPostgresHealthCheckPeriod), "Postgres pool health check period in seconds.").Envar("POSTGRES_HEALTH_CHECK_PERIOD").Default("0").Int()
+	pgConnTimeout  = kingpin.Flag(string(db.KeyPostgresConnectTimeout), "Postgres connect timeout in seconds.").Envar("POSTGRES_CONNECT_TIMEOUT").Default("0").Int()
+	chatRetention  = kingpin.Flag(string(db.KeyChatRetention), "Chat history retention in hours, 0 keeps history forever.").Envar("CHAT_RETENTION").Default("168").Int()
+	redisUrl       = kingpin.Flag(string(db.KeyRedisUrl), "Redis connection url, overrides other redis options.").Envar("REDIS_URL").Default("").String()
+	redisMode      = kingpin.Flag(string(db.KeyRedisMode), "Redis deployment mode (single, sentinel or cluster).").Envar("REDIS_MODE").Default(string(db.RedisModeSingle)).String()
+	redisAddress   = kingpin.Flag(string(db.KeyRedisAddress), "Redis server address, comma separated list of addresses for sentinel and cluster modes.").Envar("REDIS_ADDRESS").Default("").String()
 	redisUsername  = kingpin.Flag(string(db.KeyRedisUsername), "Redis server username.").Envar("REDIS_USERNAME").Default("").String()
 	redisPassword  = kingpin.Flag(string(db.KeyRedisPassword), "Redis server password.").Envar("REDIS_PASSWORD").Default("").String()
 	redisDatabase  = kingpin.Flag(string(db.KeyRedisDatabase), "Redis server database number.").Envar("REDIS_DB").Default("0").Int()
 	redisTLS       = kingpin.Flag(string(db.KeyRedisTLS), "Redis server tls connection.").Envar("REDIS_TLS").Default("0").Bool()
+	redisMaster    = kingpin.Flag(string(db.KeyRedisMasterName), "Redis sentinel master name.").Envar("REDIS_MASTER_NAME").Default("").String()
+	sentinelPasswd = kingpin.Flag(string(db.KeyRedisSentinelPassword), "Redis sentinel password.").Envar("REDIS_SENTINEL_PASSWORD").Default("").String()
+	reaperInterval = kingpin.Flag(string(reaper.KeyReaperInterval), "Expired sessions reaping interval in seconds.").Envar("REAPER_INTERVAL").Default("10").Int()
+	reaperPolicy   = kingpin.Flag(string(reaper.KeyReaperPolicy), "Reaped holds policy (record or discard).").Envar("REAPER_POLICY").Default(string(reaper.PolicyRecord)).String()
+	archInterval   = kingpin.Flag(string(archiver.KeyArchiverInterval), "Inactive custom rooms archiving interval in seconds.").Envar("ARCHIVER_INTERVAL").Default("3600").Int()
 	tgAppURL       = kingpin.Flag(string(bot.KeyTelegramAppUrl), "Telegram app url.").Envar("TG_APP_URL").Required().String()
 	tgToken        = kingpin.Flag(string(bot.KeyTelegramToken), "Telegram bot token.").Envar("TG_BOT_TOKEN").Required().String()
 	tgWebhook      = kingpin.Flag(string(bot.KeyTelegramWebhook), "Telegram webhook url.").Envar("TG_WEBHOOK_URL").Default("").String()
@@ -78,14 +97,33 @@ func main() {
 		log.Fatalf("Failed to initialize bot: %v", err)
 	}
 
-	web, err := web.NewWeb(ctx, conf, engine, db, debug)
+	hooks, err := webhook.NewDispatcher(ctx, conf, db)
+	if err != nil {
+		log.Fatalf("Failed to initialize webhooks: %v", err)
+	}
+
+	archiver, err := archiver.NewArchiver(ctx, conf, db, bot, hooks)
+	if err != nil {
+		log.Fatalf("Failed to initialize archiver: %v", err)
+	}
+
+	web, err := web.NewWeb(ctx, conf, engine, db, hooks, debug)
 	if err != nil {
 		log.Fatalf("Failed to initialize web: %v", err)
 	}
 
-	// Start the web and bot components
+	// Reaped holds are released in game modes of the web rooms
+	reaper, err := reaper.NewReaper(ctx, engine, db, web)
+	if err != nil {
+		log.Fatalf("Failed to initialize reaper: %v", err)
+	}
+
+	// Start the web, bot, reaper, archiver and webhook components
 	go web.Run()
 	go bot.Run()
+	go reaper.Run()
+	go archiver.Run()
+	go hooks.Run()
 
 	// Handle CTRL-C
 	sigIntHandler()
@@ -94,11 +132,27 @@ func main() {
 func setupContext() context.Context {
 	ctx := context.TODO()
 	ctx = context.WithValue(ctx, db.KeyPostgresUrl, *postgresUrl)
+	ctx = context.WithValue(ctx, db.KeyPostgresReplicaUrl, *pgReplicaUrl)
+	ctx = context.WithValue(ctx, db.KeyPostgresMaxReplicaLag, *pgMaxLag)
+	ctx = context.WithValue(ctx, db.KeyPostgresMaxConns, *pgMaxConns)
+	ctx = context.WithValue(ctx, db.KeyPostgresMinConns, *pgMinConns)
+	ctx = context.WithValue(ctx, db.KeyPostgresMaxConnLifetime, *pgConnLifetime)
+	ctx = context.WithValue(ctx, db.KeyPostgresMaxConnIdleTime, *pgConnIdleTime)
+	ctx = context.WithValue(ctx, db.KeyPostgresHealthCheckPeriod, *pgHealthPeriod)
+	ctx = context.WithValue(ctx, db.KeyPostgresConnectTimeout, *pgConnTimeout)
+	ctx = context.WithValue(ctx, db.KeyChatRetention, *chatRetention)
+	ctx = context.WithValue(ctx, db.KeyRedisUrl, *redisUrl)
+	ctx = context.WithValue(ctx, db.KeyRedisMode, *redisMode)
 	ctx = context.WithValue(ctx, db.KeyRedisAddress, *redisAddress)
 	ctx = context.WithValue(ctx, db.KeyRedisUsername, *redisUsername)
 	ctx = context.WithValue(ctx, db.KeyRedisPassword, *redisPassword)
 	ctx = context.WithValue(ctx, db.KeyRedisDatabase, *redisDatabase)
 	ctx = context.WithValue(ctx, db.KeyRedisTLS, *redisTLS)
+	ctx = context.WithValue(ctx, db.KeyRedisMasterName, *redisMaster)
+	ctx = context.WithValue(ctx, db.KeyRedisSentinelPassword, *sentinelPasswd)
+	ctx = context.WithValue(ctx, reaper.KeyReaperInterval, *reaperInterval)
+	ctx = context.WithValue(ctx, reaper.KeyReaperPolicy, *reaperPolicy)
+	ctx = context.WithValue(ctx, archiver.KeyArchiverInterval, *archInterval)
 	ctx = context.WithValue(ctx, web.KeySessionSecret, *sessionSecret)
 	ctx = context.WithValue(ctx, web.KeySessionName, *sessionName)
 	ctx = context.WithValue(ctx, web.KeyStaticPath, *staticPath)
@@ -13,6 +13,14 @@ type UserID string
 type UserPayload string
 type GameMessage string
 type GameState int
+type ErrorCode string
+type UserPrivacy string
+type RoomControlAction string
+type RoomRole string
+type RoomMode string
+type TournamentStatus string
+type EventStatus string
+type WebhookEventType string
 type ClientID string
 type RoomID string
 type RoomKey tuple.T2[ClientID, RoomID]
@@ -26,9 +34,119 @@ const (
 	EN UserLocale = "en"
 	RU UserLocale = "ru"
 	// Game state
-	Update GameState = 0
-	Record GameState = 1
-	Error  GameState = 99
+	Update      GameState = 0
+	Record      GameState = 1
+	Chat        GameState = 2
+	Reactions   GameState = 3
+	Elimination GameState = 4
+	Countdown   GameState = 5
+	Error       GameState = 99
+	// Chat moderation error codes
+	ChatTooLong       ErrorCode = "chat_too_long"
+	ChatRateLimited   ErrorCode = "chat_rate_limited"
+	ChatForbiddenWord ErrorCode = "chat_forbidden_word"
+	ChatLink          ErrorCode = "chat_link"
+	ChatMuted         ErrorCode = "chat_muted"
+	// Room owner moderation error codes
+	RoomKicked ErrorCode = "room_kicked"
+	RoomBanned ErrorCode = "room_banned"
+	// Tournament error codes
+	TournamentNotScheduled  ErrorCode = "tournament_not_scheduled"
+	TournamentNotRegistered ErrorCode = "tournament_not_registered"
+	TournamentNotStarted    ErrorCode = "tournament_not_started"
+	TournamentGraceOver     ErrorCode = "tournament_grace_over"
+	TournamentPlayed        ErrorCode = "tournament_played"
+	// REST API error codes
+	ApiInvalidRequest       ErrorCode = "invalid_request"
+	ApiInvalidInitData      ErrorCode = "invalid_init_data"
+	ApiUserIdMissing        ErrorCode = "user_id_missing"
+	ApiRoomIdMissing        ErrorCode = "room_id_missing"
+	ApiRoomIdTooLong        ErrorCode = "room_id_too_long"
+	ApiRoomIdTooShort       ErrorCode = "room_id_too_short"
+	ApiRoomIdInvalid        ErrorCode = "room_id_invalid"
+	ApiRoomIdReserved       ErrorCode = "room_id_reserved"
+	ApiRoomIdForbidden      ErrorCode = "room_id_forbidden"
+	ApiClientNotAllowed     ErrorCode = "client_not_allowed"
+	ApiClientNotFound       ErrorCode = "client_not_found"
+	ApiRoomExists           ErrorCode = "room_exists"
+	ApiRoomQuotaExceeded    ErrorCode = "room_quota_exceeded"
+	ApiRoomNotArchived      ErrorCode = "room_not_archived"
+	ApiRoomNotFound         ErrorCode = "room_not_found"
+	ApiRoomNotDeletable     ErrorCode = "room_not_deletable"
+	ApiRoomNotOwner         ErrorCode = "room_not_owner"
+	ApiRoomOwnerSelf        ErrorCode = "room_owner_self"
+	ApiRoomNotModerator     ErrorCode = "room_not_moderator"
+	ApiRoomTargetProtected  ErrorCode = "room_target_protected"
+	ApiTransferNotModerator ErrorCode = "transfer_not_moderator"
+	ApiTargetUserIdMissing  ErrorCode = "target_user_id_missing"
+	ApiInvalidDuration      ErrorCode = "invalid_duration"
+	ApiInvalidBefore        ErrorCode = "invalid_before"
+	ApiInvalidLimit         ErrorCode = "invalid_limit"
+	ApiReportYourself       ErrorCode = "report_yourself"
+	ApiInvalidPrivacy       ErrorCode = "invalid_privacy"
+	ApiRoomTitleTooLong     ErrorCode = "room_title_too_long"
+	ApiRoomDescTooLong      ErrorCode = "room_description_too_long"
+	ApiRoomIconTooLong      ErrorCode = "room_icon_too_long"
+	ApiInvalidLocale        ErrorCode = "invalid_locale"
+	ApiRoomPrivate          ErrorCode = "room_private"
+	ApiInvalidMaxUses       ErrorCode = "invalid_max_uses"
+	ApiInviteNotFound       ErrorCode = "invite_not_found"
+	ApiInviteInvalid        ErrorCode = "invite_invalid"
+	ApiInviteExpired        ErrorCode = "invite_expired"
+	ApiInviteExhausted      ErrorCode = "invite_exhausted"
+	ApiInvalidSort          ErrorCode = "invalid_sort"
+	ApiInvalidOffset        ErrorCode = "invalid_offset"
+	ApiMessagesEmpty        ErrorCode = "messages_empty"
+	ApiMessagesTooMany      ErrorCode = "messages_too_many"
+	ApiMessagesTooLarge     ErrorCode = "messages_too_large"
+	ApiMessageTooLong       ErrorCode = "message_too_long"
+	ApiMessageRejected      ErrorCode = "message_rejected"
+	ApiInvalidMode          ErrorCode = "invalid_mode"
+	ApiRoomNotTournament    ErrorCode = "room_not_tournament"
+	ApiInvalidSchedule      ErrorCode = "invalid_schedule"
+	ApiTournamentScheduled  ErrorCode = "tournament_scheduled"
+	ApiTournamentNotFound   ErrorCode = "tournament_not_found"
+	ApiRegistrationClosed   ErrorCode = "registration_closed"
+	ApiRoomUserBanned       ErrorCode = "room_user_banned"
+	ApiInvalidTeams         ErrorCode = "invalid_teams"
+	ApiTeamNotFound         ErrorCode = "team_not_found"
+	ApiRoomNotTeams         ErrorCode = "room_not_teams"
+	ApiRoomNotChain         ErrorCode = "room_not_chain"
+	ApiRoomNotEvent         ErrorCode = "room_not_event"
+	ApiInternal             ErrorCode = "internal_error"
+	// Room control actions
+	ControlKick     RoomControlAction = "kick"
+	ControlBan      RoomControlAction = "ban"
+	ControlMessages RoomControlAction = "messages"
+	ControlClose    RoomControlAction = "close"
+	// Roles of users in custom rooms, the owner has every moderator's permission
+	RoleOwner     RoomRole = "owner"
+	RoleModerator RoomRole = "moderator"
+	// Game modes of rooms
+	ModeClassic    RoomMode = "classic"
+	ModeTournament RoomMode = "tournament"
+	ModeTeams      RoomMode = "teams"
+	ModeChain      RoomMode = "chain"
+	// Tournament statuses
+	TournamentRegistration TournamentStatus = "registration"
+	TournamentRunning      TournamentStatus = "running"
+	TournamentFinished     TournamentStatus = "finished"
+	// Event statuses
+	EventUpcoming EventStatus = "upcoming"
+	EventOpen     EventStatus = "open"
+	EventClosed   EventStatus = "closed"
+	// Webhook event types
+	WebhookRecord         WebhookEventType = "record"
+	WebhookWorldRecord    WebhookEventType = "world_record"
+	WebhookRoomCreated    WebhookEventType = "room_created"
+	WebhookRoomDeleted    WebhookEventType = "room_deleted"
+	WebhookSessionStarted WebhookEventType = "session_started"
+	WebhookSessionEnded   WebhookEventType = "session_ended"
+	// User profile privacy: public shows name, username and photo,
+	// name shows only the first name, hidden shows nothing but user id
+	PrivacyPublic UserPrivacy = "public"
+	PrivacyName   UserPrivacy = "name"
+	PrivacyHidden UserPrivacy = "hidden"
 )
 
 // GameplayGameState represents the base struct of game, which contains only current game state
@@ -40,6 +158,7 @@ type GameplayGameState struct {
 type GameplayContext struct {
 	ButtonPhase ButtonPhase  `json:"buttonPhase"`
 	ChatMessage *ChatMessage `json:"chat,omitempty"`
+	Reaction    string       `json:"reaction,omitempty"`
 	Timestamp   *int64       `json:"timestamp,omitempty"`
 	Duration    *int64       `json:"duration,omitempty"`
 }
@@ -58,6 +177,7 @@ func NewGameplayContext() GameplayContext {
 // GameplayError represents an error that can occur during gameplay.
 type GameplayError struct {
 	Message GameMessage `json:"message"`
+	Code    ErrorCode   `json:"code,omitempty"`
 }
 
 // NewGameplayError creates a new GameplayError.
@@ -67,6 +187,14 @@ func NewGameplayError(msg GameMessage) GameplayError {
 	}
 }
 
+// NewGameplayErrorWithCode creates a new GameplayError with machine-readable code.
+func NewGameplayErrorWithCode(msg GameMessage, code ErrorCode) GameplayError {
+	return GameplayError{
+		Message: msg,
+		Code:    code,
+	}
+}
+
 // GameplayRecord represents a record of a completed game session.
 type GameplayRecord struct {
 	Timestamp int64 `json:"timestamp"`
@@ -90,28 +218,78 @@ func (r GameplayRecord) MarshalBinary() ([]byte, error) {
 
 // ClientStats
 type ClientStats struct {
-	UsersOnline *int64 `json:"usersOnline,omitempty"`
-	RoomsCount  *int64 `json:"roomsCount,omitempty"`
+	UsersOnline *int64    `json:"usersOnline,omitempty"`
+	RoomsCount  *int64    `json:"roomsCount,omitempty"`
+	Reactions   *[]string `json:"reactions,omitempty"`
 }
 
 // NewClientStats creates a new ClientStats.
 func NewClientStats(
 	usersOnline *int64,
 	roomsCount *int64,
+	reactions *[]string,
 ) ClientStats {
 	return ClientStats{
 		UsersOnline: usersOnline,
 		RoomsCount:  roomsCount,
+		Reactions:   reactions,
 	}
 }
 
+// UserProfile represents user's public profile retrieved from telegram init data.
+type UserProfile struct {
+	UserID   UserID      `json:"userID"`
+	Name     string      `json:"name,omitempty"`
+	Username string      `json:"username,omitempty"`
+	PhotoURL string      `json:"photoUrl,omitempty"`
+	Premium  bool        `json:"premium,omitempty"`
+	Language string      `json:"-"`
+	Privacy  UserPrivacy `json:"-"`
+}
+
+// NewUserPrivacy retrieves the supported privacy setting, defaults to the first name only.
+func NewUserPrivacy(privacy string) (UserPrivacy, bool) {
+	switch UserPrivacy(privacy) {
+	case PrivacyPublic, PrivacyName, PrivacyHidden:
+		return UserPrivacy(privacy), true
+	}
+	return PrivacyName, false
+}
+
+// Public returns the part of the profile the user agreed to show to others.
+func (p UserProfile) Public() UserProfile {
+	public := UserProfile{
+		UserID: p.UserID,
+	}
+	switch p.Privacy {
+	case PrivacyPublic:
+		public.Name = p.Name
+		public.Username = p.Username
+		public.PhotoURL = p.PhotoURL
+		public.Premium = p.Premium
+	case PrivacyHidden:
+	default:
+		public.Name = p.Name
+	}
+	return public
+}
+
+// LeaderboardEntry represents user's best record in the leaderboard.
+type LeaderboardEntry struct {
+	User     UserProfile `json:"user"`
+	Duration int64       `json:"duration"`
+}
+
 // GameRoomStats represents statistics for a game room.
 type GameRoomStats struct {
-	CountActive         *int64         `json:"countActive,omitempty"`
-	CountLeaderboard    *int64         `json:"countLeaderboard,omitempty"`
-	BestOverallDuration *int64         `json:"bestOverallDuration,omitempty"`
-	BestTodaysDuration  *int64         `json:"bestTodaysDuration,omitempty"`
-	BestUsersPayloads   *[]UserPayload `json:"bestUsersPayloads,omitempty"`
+	CountActive         *int64              `json:"countActive,omitempty"`
+	CountLeaderboard    *int64              `json:"countLeaderboard,omitempty"`
+	BestOverallDuration *int64              `json:"bestOverallDuration,omitempty"`
+	BestTodaysDuration  *int64              `json:"bestTodaysDuration,omitempty"`
+	BestUsersPayloads   *[]UserPayload      `json:"bestUsersPayloads,omitempty"`
+	BestHolders         *[]UserProfile      `json:"bestHolders,omitempty"`
+	Leaderboard         *[]LeaderboardEntry `json:"leaderboard,omitempty"`
+	Meta                *RoomMeta           `json:"meta,omitempty"`
 }
 
 // NewGameRoomStats creates a new GameRoomStats.
@@ -121,6 +299,9 @@ func NewGameRoomStats(
 	bestOverallDuration *int64,
 	bestTodaysDuration *int64,
 	bestUsersPayloads *[]UserPayload,
+	bestHolders *[]UserProfile,
+	leaderboard *[]LeaderboardEntry,
+	meta *RoomMeta,
 ) GameRoomStats {
 	return GameRoomStats{
 		CountActive:         totalCountActive,
@@ -128,13 +309,19 @@ func NewGameRoomStats(
 		BestOverallDuration: bestOverallDuration,
 		BestTodaysDuration:  bestTodaysDuration,
 		BestUsersPayloads:   bestUsersPayloads,
+		BestHolders:         bestHolders,
+		Leaderboard:         leaderboard,
+		Meta:                meta,
 	}
 }
 
 // ChatMessage represents the struct, which contains string message with optional user id
 type ChatMessage struct {
-	UserID  UserID `json:"userID,omitempty"`
-	Message string `json:"message"`
+	ID        int64        `json:"id,omitempty"`
+	UserID    UserID       `json:"userID,omitempty"`
+	User      *UserProfile `json:"user,omitempty"`
+	Message   string       `json:"message"`
+	Timestamp int64        `json:"timestamp,omitempty"`
 }
 
 // MarshalBinary marshals a ChatMessage to binary data.
@@ -142,18 +329,182 @@ func (m ChatMessage) MarshalBinary() ([]byte, error) {
 	return json.Marshal(m)
 }
 
+// ChatHistory represents a page of room chat messages in chronological order.
+type ChatHistory struct {
+	Messages []ChatMessage `json:"messages"`
+	Before   *int64        `json:"before,omitempty"`
+}
+
+// NewChatHistory creates a new ChatHistory, cursor to the previous page is set if the page is full.
+func NewChatHistory(
+	messages []ChatMessage,
+	limit int64,
+) ChatHistory {
+	var before *int64
+	if len(messages) > 0 && int64(len(messages)) == limit {
+		before = &messages[0].ID
+	}
+	return ChatHistory{
+		Messages: messages,
+		Before:   before,
+	}
+}
+
+// RoomControl represents the room owner's action applied to holders on every instance.
+type RoomControl struct {
+	Action RoomControlAction `json:"action"`
+	UserID UserID            `json:"userID"`
+}
+
+// MarshalBinary marshals a RoomControl to binary data.
+func (c RoomControl) MarshalBinary() ([]byte, error) {
+	return json.Marshal(c)
+}
+
+// Tournament represents the state of the last holder standing tournament of the room.
+type Tournament struct {
+	ID         int64            `json:"id"`
+	Status     TournamentStatus `json:"status"`
+	Starts     int64            `json:"starts"`
+	Grace      int64            `json:"grace"`
+	Registered int64            `json:"registered"`
+	Remaining  int64            `json:"remaining"`
+}
+
+// TournamentStanding represents the final place of the player in the tournament.
+type TournamentStanding struct {
+	User     UserProfile `json:"user"`
+	Place    int64       `json:"place"`
+	Duration int64       `json:"duration"`
+}
+
+// TournamentResult represents the final standing of the finished tournament.
+type TournamentResult struct {
+	ID        int64                `json:"id"`
+	Finished  int64                `json:"finished"`
+	Standings []TournamentStanding `json:"standings"`
+}
+
+// TournamentUpdate represents the tournament event broadcast to holders of the room,
+// the final standing is sent when the tournament is finished.
+type TournamentUpdate struct {
+	ID         int64                `json:"id"`
+	Status     TournamentStatus     `json:"status"`
+	Remaining  int64                `json:"remaining"`
+	Eliminated *UserProfile         `json:"eliminated,omitempty"`
+	Standings  []TournamentStanding `json:"standings,omitempty"`
+}
+
+// MarshalBinary marshals a TournamentUpdate to binary data.
+func (u TournamentUpdate) MarshalBinary() ([]byte, error) {
+	return json.Marshal(u)
+}
+
+// TeamStanding represents the live state of the team, the hold time includes holds in progress.
+type TeamStanding struct {
+	Team     string `json:"team"`
+	Holders  int64  `json:"holders"`
+	Duration int64  `json:"duration"`
+}
+
+// TeamLeaderboardEntry represents the finished holds of the team.
+type TeamLeaderboardEntry struct {
+	Team     string `json:"team"`
+	Duration int64  `json:"duration"`
+	Players  int64  `json:"players"`
+	Best     int64  `json:"best"`
+}
+
+// TeamStats represents live standings and the leaderboard of the room teams.
+type TeamStats struct {
+	Standings   []TeamStanding         `json:"standings"`
+	Leaderboard []TeamLeaderboardEntry `json:"leaderboard"`
+}
+
+// ChainState represents the live chain of the room, the chain lasts while anyone is holding.
+type ChainState struct {
+	Started      int64 `json:"started"`
+	Length       int64 `json:"length"`
+	Holders      int64 `json:"holders"`
+	Participants int64 `json:"participants"`
+	Peak         int64 `json:"peak"`
+}
+
+// ChainRecord represents the collective record of the broken chain.
+type ChainRecord struct {
+	Started      int64 `json:"started"`
+	Duration     int64 `json:"duration"`
+	Participants int64 `json:"participants"`
+	Peak         int64 `json:"peak"`
+}
+
+// ChainStats represents the live chain and the longest chains of the room.
+type ChainStats struct {
+	Current *ChainState   `json:"current,omitempty"`
+	Records []ChainRecord `json:"records"`
+}
+
+// Event represents the open window of the time-boxed event room, remaining is seconds
+// until the event opens if upcoming or closes if open.
+type Event struct {
+	Status    EventStatus `json:"status"`
+	Opens     int64       `json:"opens"`
+	Closes    int64       `json:"closes"`
+	Remaining int64       `json:"remaining"`
+}
+
+// EventResult represents the best holds of the latest event of the room.
+type EventResult struct {
+	Event
+	Standings []LeaderboardEntry `json:"standings"`
+}
+
+// WebhookEvent represents the game event delivered to webhooks of the client,
+// the record is sent with record events and ended sessions.
+type WebhookEvent struct {
+	ID        string           `json:"id"`
+	Type      WebhookEventType `json:"type"`
+	ClientID  ClientID         `json:"clientId"`
+	RoomID    RoomID           `json:"roomId"`
+	UserID    UserID           `json:"userId,omitempty"`
+	Timestamp int64            `json:"timestamp"`
+	Record    *GameplayRecord  `json:"record,omitempty"`
+}
+
+// WebhookDelivery represents the logged attempt to deliver the event to the webhook,
+// zero status means the request failed without response.
+type WebhookDelivery struct {
+	DeliveryID string           `json:"deliveryId"`
+	Event      WebhookEventType `json:"event"`
+	Attempt    int              `json:"attempt"`
+	Status     int              `json:"status"`
+	Error      string           `json:"error,omitempty"`
+	Timestamp  int64            `json:"timestamp"`
+}
+
+// RoomHolder represents the user currently holding the button in the room.
+type RoomHolder struct {
+	User     UserProfile `json:"user"`
+	Duration int64       `json:"duration"`
+}
+
 // GameplayMessage represents an update sent to the client during gameplay.
 type GameplayMessage struct {
 	GameplayGameState
 	GameRoomStats
-	Context          *GameplayContext `json:"context,omitempty"`
-	ChatMessage      *ChatMessage     `json:"chat,omitempty"`
-	Record           *GameplayRecord  `json:"record,omitempty"`
-	Error            *GameplayError   `json:"error,omitempty"`
-	GameMessage      *GameMessage     `json:"message,omitempty"`
-	PlaceActive      *int64           `json:"placeActive,omitempty"`
-	PlaceLeaderboard *int64           `json:"placeLeaderboard,omitempty"`
-	WorldRecord      *bool            `json:"worldRecord,omitempty"`
+	Context          *GameplayContext  `json:"context,omitempty"`
+	ChatMessage      *ChatMessage      `json:"chat,omitempty"`
+	Reactions        map[string]int64  `json:"reactions,omitempty"`
+	Tournament       *TournamentUpdate `json:"tournament,omitempty"`
+	Teams            []TeamStanding    `json:"teams,omitempty"`
+	Chain            *ChainState       `json:"chain,omitempty"`
+	Event            *Event            `json:"event,omitempty"`
+	Record           *GameplayRecord   `json:"record,omitempty"`
+	Error            *GameplayError    `json:"error,omitempty"`
+	GameMessage      *GameMessage      `json:"message,omitempty"`
+	PlaceActive      *int64            `json:"placeActive,omitempty"`
+	PlaceLeaderboard *int64            `json:"placeLeaderboard,omitempty"`
+	WorldRecord      *bool             `json:"worldRecord,omitempty"`
 }
 
 // NewGameplayMessage creates a new GameplayMessage.
@@ -189,6 +540,11 @@ func NewGameplayMessage(
 	}
 }
 
+// IsSupportedLocale checks if the locale string is one of supported locales.
+func IsSupportedLocale(locale string) bool {
+	return locale == string(EN) || locale == string(RU)
+}
+
 // NewUserLocale retrieves the supported user locale string based on the user's input.
 func NewUserLocale(locale string) UserLocale {
 	if locale == string(RU) {
@@ -196,3 +552,194 @@ func NewUserLocale(locale string) UserLocale {
 	}
 	return EN
 }
+
+// ApiError represents the error returned by the REST API,
+// room ids available instead of the taken one are suggested on conflict.
+type ApiError struct {
+	Code        ErrorCode `json:"code"`
+	Message     string    `json:"message"`
+	Suggestions []RoomID  `json:"suggestions,omitempty"`
+}
+
+// ErrorResponse represents the JSON error envelope of the REST API.
+type ErrorResponse struct {
+	Error ApiError `json:"error"`
+}
+
+// NewErrorResponse creates a new ErrorResponse.
+func NewErrorResponse(code ErrorCode, message string) ErrorResponse {
+	return ErrorResponse{
+		Error: ApiError{
+			Code:    code,
+			Message: message,
+		},
+	}
+}
+
+// UserAuth identifies the user of the REST API request, telegram init data takes precedence over user id.
+// Owner, moderator and member actions accept telegram init data only.
+type UserAuth struct {
+	UserID   UserID `json:"userId,omitempty"`
+	InitData string `json:"initData,omitempty"`
+}
+
+// RoomRef identifies the game room.
+type RoomRef struct {
+	ClientID ClientID `json:"clientId"`
+	RoomID   RoomID   `json:"roomId"`
+}
+
+// RoomMeta represents the metadata of the custom game room.
+type RoomMeta struct {
+	Title       string     `json:"title,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Icon        string     `json:"icon,omitempty"`
+	Owner       UserID     `json:"owner,omitempty"`
+	Created     int64      `json:"created,omitempty"`
+	Locale      UserLocale `json:"locale,omitempty"`
+	Private     bool       `json:"private,omitempty"`
+	Mode        RoomMode   `json:"mode,omitempty"`
+	Teams       []string   `json:"teams,omitempty"`
+	Warned      int64      `json:"-"`
+}
+
+// RoomMetaUpdate represents changes of the custom game room metadata, omitted fields are kept.
+type RoomMetaUpdate struct {
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Icon        *string `json:"icon,omitempty"`
+	Locale      *string `json:"locale,omitempty"`
+	Private     *bool   `json:"private,omitempty"`
+}
+
+// RoomInfo represents the game room with its metadata.
+type RoomInfo struct {
+	RoomRef
+	RoomMeta
+	Event *Event `json:"event,omitempty"`
+}
+
+// RoomActivity represents the activity of the game room.
+type RoomActivity struct {
+	Active       int64 `json:"active"`
+	Players      int64 `json:"players"`
+	LastActivity int64 `json:"lastActivity,omitempty"`
+}
+
+// RoomListing represents the public game room found by the discovery.
+type RoomListing struct {
+	RoomInfo
+	RoomActivity
+}
+
+// RoomList represents a page of public game rooms, offset of the next page is set if more rooms are found.
+type RoomList struct {
+	Rooms      []RoomListing `json:"rooms"`
+	Total      int64         `json:"total"`
+	NextOffset *int64        `json:"nextOffset,omitempty"`
+}
+
+// NewRoomList creates a new RoomList from the page of found rooms.
+func NewRoomList(
+	rooms []RoomListing,
+	total int64,
+	offset int64,
+) RoomList {
+	var nextOffset *int64
+	if next := offset + int64(len(rooms)); next < total {
+		nextOffset = &next
+	}
+	return RoomList{
+		Rooms:      rooms,
+		Total:      total,
+		NextOffset: nextOffset,
+	}
+}
+
+// ArchivedRoom represents the custom game room archived after a long inactivity.
+type ArchivedRoom struct {
+	RoomInfo
+	Archived    int64               `json:"archived"`
+	Leaderboard *[]LeaderboardEntry `json:"leaderboard,omitempty"`
+}
+
+// RoomInvite represents the invite code of the private game room.
+type RoomInvite struct {
+	Code    string `json:"code"`
+	Link    string `json:"link,omitempty"`
+	Expires int64  `json:"expires,omitempty"`
+	MaxUses int64  `json:"maxUses,omitempty"`
+	Uses    int64  `json:"uses"`
+}
+
+// MessagePack represents localized motivational messages of the custom game room.
+type MessagePack struct {
+	Messages map[UserLocale][]string `json:"messages"`
+}
+
+// MessagePackRequest represents the room owner's request to replace the room message pack.
+type MessagePackRequest struct {
+	UserAuth
+	MessagePack
+}
+
+// CreateRoomRequest represents the request to create a custom game room.
+type CreateRoomRequest struct {
+	UserAuth
+	RoomRef
+	RoomMetaUpdate
+	Mode  RoomMode `json:"mode,omitempty"`
+	Teams []string `json:"teams,omitempty"`
+}
+
+// UpdateRoomRequest represents the room owner's request to edit the room metadata.
+type UpdateRoomRequest struct {
+	UserAuth
+	RoomMetaUpdate
+}
+
+// CreateInviteRequest represents the room owner's request to issue a new invite code,
+// the previous code stops working. Zero ttl and max uses mean unlimited.
+type CreateInviteRequest struct {
+	UserAuth
+	TTL     int64 `json:"ttl,omitempty"`
+	MaxUses int64 `json:"maxUses,omitempty"`
+}
+
+// JoinRoomRequest represents the request to join the private game room by invite code.
+type JoinRoomRequest struct {
+	UserAuth
+	Code string `json:"code"`
+}
+
+// TargetUserRequest represents the room owner's request applied to another user.
+type TargetUserRequest struct {
+	UserAuth
+	TargetUserID UserID `json:"targetUserId"`
+	Duration     int64  `json:"duration,omitempty"`
+}
+
+// ScheduleTournamentRequest represents the room owner's request to schedule the tournament,
+// registration is open until the start and players must push within the grace period in seconds.
+type ScheduleTournamentRequest struct {
+	UserAuth
+	Starts int64 `json:"starts"`
+	Grace  int64 `json:"grace"`
+}
+
+// ChatReportRequest represents the request to report user's chat messages.
+type ChatReportRequest struct {
+	UserAuth
+	ReportedUserID UserID `json:"reportedUserId"`
+}
+
+// ChatReportResponse represents the result of user's chat report.
+type ChatReportResponse struct {
+	Muted bool `json:"muted"`
+}
+
+// PrivacyRequest represents the request to change user's profile privacy.
+type PrivacyRequest struct {
+	UserAuth
+	Privacy UserPrivacy `json:"privacy"`
+}
@@ -0,0 +1,261 @@
+package reaper
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"buttonmania.win/db"
+	"buttonmania.win/protocol"
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKey is used for context keys.
+type ContextKey string
+
+// Policy defines what happens to holds reaped from active sessions.
+type Policy string
+
+const (
+	// Context keys for configuration.
+	KeyReaperInterval ContextKey = ContextKey("reaperinterval")
+	KeyReaperPolicy   ContextKey = ContextKey("reaperpolicy")
+	// Reaped holds policies.
+	PolicyRecord  Policy = "record"
+	PolicyDiscard Policy = "discard"
+	// Name of the lock held by the leading reaper
+	leaderLockName = "reaper:leader"
+	// Interval between presence counters reconciliations of the leader
+	presenceInterval = time.Minute
+)
+
+// Metrics represents reaping statistics of the running instance.
+type Metrics struct {
+	Leader            bool   `json:"leader"`
+	Sweeps            int64  `json:"sweeps"`
+	ReapedSessions    int64  `json:"reapedSessions"`
+	FinalizedRecords  int64  `json:"finalizedRecords"`
+	DiscardedSessions int64  `json:"discardedSessions"`
+	OrphanedPayloads  int64  `json:"orphanedPayloads"`
+	Errors            int64  `json:"errors"`
+	LastSweep         *int64 `json:"lastSweep,omitempty"`
+	LastSweepMillis   *int64 `json:"lastSweepMillis,omitempty"`
+}
+
+// Releaser ends reaped holds in the game modes of their rooms.
+type Releaser interface {
+	// RoomMode returns the configured mode of the room, empty if the room does not exist.
+	RoomMode(clientId protocol.ClientID, roomId protocol.RoomID) (protocol.RoomMode, error)
+	// ReleaseReaped ends the reaped hold, the record is nil if the hold is discarded.
+	// Returns whether the hold is scored.
+	ReleaseReaped(
+		clientId protocol.ClientID,
+		roomId protocol.RoomID,
+		userID protocol.UserID,
+		record *protocol.GameplayRecord,
+		end int64,
+	) (bool, error)
+}
+
+// Reaper periodically removes expired active sessions and reconciles presence counters.
+type Reaper struct {
+	ctx      context.Context
+	db       *db.DB
+	releaser Releaser
+	token    string
+	interval time.Duration
+	policy   Policy
+	mu       sync.Mutex
+	metrics  Metrics
+	// Last presence counters reconciliation
+	reconciled time.Time
+}
+
+// NewReaper creates a new instance of Reaper.
+func NewReaper(
+	ctx context.Context,
+	engine *gin.Engine,
+	db *db.DB,
+	releaser Releaser,
+) (*Reaper, error) {
+	intervalSeconds := ctx.Value(KeyReaperInterval).(int)
+	policy := Policy(ctx.Value(KeyReaperPolicy).(string))
+	if intervalSeconds <= 0 {
+		return nil, fmt.Errorf("invalid reaper interval: %d", intervalSeconds)
+	}
+	if policy != PolicyRecord && policy != PolicyDiscard {
+		return nil, fmt.Errorf("invalid reaper policy: %s", policy)
+	}
+
+	// Identify this instance in leader election
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, err
+	}
+
+	r := &Reaper{
+		ctx:      ctx,
+		db:       db,
+		releaser: releaser,
+		token:    hex.EncodeToString(tokenBytes),
+		interval: time.Duration(intervalSeconds) * time.Second,
+		policy:   policy,
+	}
+	engine.GET("/api/reaper/metrics", r.metricsHandler)
+	return r, nil
+}
+
+// Metrics returns a snapshot of reaping statistics.
+func (r *Reaper) Metrics() Metrics {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.metrics
+}
+
+// @Summary	Get reaper metrics of the instance
+// @Produce	json
+// @Success	200	{object}	reaper.Metrics
+// @Router		/api/reaper/metrics [get]
+func (r *Reaper) metricsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, r.Metrics())
+}
+
+// lead acquires or prolongs leadership, only the leader sweeps rooms.
+func (r *Reaper) lead() bool {
+	// Leadership expires if the leader misses a few sweeps
+	leader, err := r.db.AcquireLock(leaderLockName, r.token, 3*r.interval)
+	if err != nil {
+		log.Println("Reaper failed to acquire leadership:", err)
+		leader = false
+	}
+	r.mu.Lock()
+	if leader != r.metrics.Leader {
+		log.Println("Reaper leadership changed, leader:", leader)
+	}
+	r.metrics.Leader = leader
+	r.mu.Unlock()
+	return leader
+}
+
+// finalize releases reaped holds in modes of their rooms, records are kept according to the policy.
+func (r *Reaper) finalize(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	reaped []db.ReapedSession,
+) (int64, int64, error) {
+	var err error
+	var finalized, discarded int64
+	// Holds are released in the order they ended, so the chain breaks when the last hold ended
+	slices.SortFunc(reaped, func(a, b db.ReapedSession) int {
+		return cmp.Compare(a.Timestamp+a.Duration, b.Timestamp+b.Duration)
+	})
+	for _, s := range reaped {
+		var record *protocol.GameplayRecord
+		if r.policy == PolicyRecord && s.Duration > 0 {
+			// Records are stamped with the end of the hold like records of released holds
+			gameplayRecord := protocol.NewGameplayRecord(protocol.GameplayContext{
+				Timestamp: &s.Timestamp,
+				Duration:  &s.Duration,
+			})
+			record = &gameplayRecord
+		}
+		recorded, releaseErr := r.releaser.ReleaseReaped(clientId, roomId, s.UserID, record, s.Timestamp+s.Duration)
+		if releaseErr != nil {
+			err = errors.Join(err, releaseErr)
+			continue
+		}
+		if recorded {
+			finalized++
+		} else {
+			discarded++
+		}
+	}
+	return finalized, discarded, err
+}
+
+// sweep reaps expired sessions of every room with active sessions.
+func (r *Reaper) sweep() error {
+	var err error
+	var reapedCount, finalizedCount, discardedCount, orphanedCount, errorsCount int64
+	start := time.Now()
+
+	rooms, err := r.db.ListActiveSessionRooms()
+	if err != nil {
+		errorsCount++
+	}
+	for _, roomKey := range rooms {
+		clientId, roomId := roomKey.V1, roomKey.V2
+		// Only holders of the configured mode can be left in the room
+		mode, modeErr := r.releaser.RoomMode(clientId, roomId)
+		if modeErr != nil {
+			err = errors.Join(err, modeErr)
+			errorsCount++
+			continue
+		}
+		reaped, orphaned, reapErr := r.db.ReapActiveSessions(clientId, roomId, mode, time.Now().Unix())
+		finalized, discarded, finalizeErr := r.finalize(clientId, roomId, reaped)
+		if reapErr != nil || finalizeErr != nil {
+			err = errors.Join(err, reapErr, finalizeErr)
+			errorsCount++
+		}
+		reapedCount += int64(len(reaped))
+		finalizedCount += finalized
+		discardedCount += discarded
+		orphanedCount += orphaned
+	}
+
+	lastSweep := start.Unix()
+	lastSweepMillis := time.Since(start).Milliseconds()
+	r.mu.Lock()
+	r.metrics.Sweeps++
+	r.metrics.ReapedSessions += reapedCount
+	r.metrics.FinalizedRecords += finalizedCount
+	r.metrics.DiscardedSessions += discardedCount
+	r.metrics.OrphanedPayloads += orphanedCount
+	r.metrics.Errors += errorsCount
+	r.metrics.LastSweep = &lastSweep
+	r.metrics.LastSweepMillis = &lastSweepMillis
+	r.mu.Unlock()
+	return err
+}
+
+// reconcilePresence rebuilds online counters once per interval, only the leader rebuilds them.
+func (r *Reaper) reconcilePresence() {
+	if time.Since(r.reconciled) < presenceInterval {
+		return
+	}
+	r.reconciled = time.Now()
+	if err := r.db.ReconcilePresence(); err != nil {
+		log.Println("Reaper failed to reconcile presence counters:", err)
+	}
+}
+
+// Run sweeps expired sessions on interval while this instance is the leader.
+func (r *Reaper) Run() error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	defer func() {
+		_ = r.db.ReleaseLock(leaderLockName, r.token)
+	}()
+	for {
+		if r.lead() {
+			if err := r.sweep(); err != nil {
+				log.Println("Reaper failed to sweep sessions:", err)
+			}
+			r.reconcilePresence()
+		}
+		select {
+		case <-r.ctx.Done():
+			return r.ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
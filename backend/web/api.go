@@ -0,0 +1,1099 @@
+package web
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"buttonmania.win/bot"
+	"buttonmania.win/db"
+	"buttonmania.win/protocol"
+	"github.com/gin-gonic/gin"
+
+	tuple "github.com/barweiss/go-tuple"
+)
+
+// Define the maximal lengths of room id and metadata
+const (
+	maxRoomIdLength          = 36
+	maxRoomTitleLength       = 64
+	maxRoomDescriptionLength = 256
+	maxRoomIconLength        = 8
+)
+
+// Define limits of custom room message packs
+const (
+	maxRoomMessages       = 100
+	maxRoomMessageLength  = 200
+	maxRoomMessagesLength = 32 * 1024
+)
+
+// Define room discovery parameters
+const (
+	defaultRoomListLimit = 20
+	maxRoomListLimit     = 100
+	roomListSortActive   = "active"
+	roomListSortPlayers  = "players"
+	roomListSortRecent   = "recent"
+)
+
+// Define game modes of custom rooms
+var roomModes = []protocol.RoomMode{
+	protocol.ModeClassic,
+	protocol.ModeTournament,
+	protocol.ModeTeams,
+	protocol.ModeChain,
+}
+
+// Define the default count of custom rooms the user may own
+const defaultRoomsPerUser = 5
+
+// ApiError represents the failure of the API operation.
+type ApiError struct {
+	Status      int
+	Code        protocol.ErrorCode
+	Message     string
+	Suggestions []protocol.RoomID
+}
+
+// Error returns the message of the API error.
+func (e *ApiError) Error() string {
+	return e.Message
+}
+
+// newApiError creates a new ApiError instance.
+func newApiError(status int, code protocol.ErrorCode, message string) *ApiError {
+	return &ApiError{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	}
+}
+
+// internalApiError wraps unexpected error of the API operation.
+func internalApiError(err error) *ApiError {
+	return newApiError(http.StatusInternalServerError, protocol.ApiInternal, err.Error())
+}
+
+// writeJSONError writes the API error in JSON error envelope.
+func writeJSONError(c *gin.Context, apiErr *ApiError) {
+	resp := protocol.NewErrorResponse(apiErr.Code, apiErr.Message)
+	resp.Error.Suggestions = apiErr.Suggestions
+	c.AbortWithStatusJSON(apiErr.Status, resp)
+}
+
+// writePlainError writes the API error as plain text, used by deprecated routes.
+func writePlainError(c *gin.Context, apiErr *ApiError) {
+	http.Error(c.Writer, apiErr.Message, apiErr.Status)
+}
+
+// deprecatedRoute marks responses of the route replaced by the versioned API.
+func deprecatedRoute(successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		c.Next()
+	}
+}
+
+// authUser identifies the user by telegram init data or user id.
+func (w *Web) authUser(auth protocol.UserAuth) (protocol.UserID, *ApiError) {
+	if len(auth.InitData) > 0 {
+		initData, err := w.parseTgInitData(auth.InitData)
+		if err != nil {
+			return "", newApiError(http.StatusBadRequest, protocol.ApiInvalidInitData, err.Error())
+		}
+		return protocol.UserID(strconv.FormatInt(initData.User.ID, 10)), nil
+	}
+	if len(auth.UserID) == 0 {
+		return "", newApiError(http.StatusBadRequest, protocol.ApiUserIdMissing, "User id not provided")
+	}
+	return auth.UserID, nil
+}
+
+// authVerifiedUser identifies the user by telegram init data only, the user id anyone can send
+// is not accepted by owner, moderator and member actions.
+func (w *Web) authVerifiedUser(auth protocol.UserAuth) (protocol.UserID, *ApiError) {
+	if len(auth.InitData) == 0 {
+		return "", newApiError(http.StatusBadRequest, protocol.ApiInvalidInitData, "Telegram init data not provided")
+	}
+	return w.authUser(auth)
+}
+
+// checkRoomId validates the room id.
+func checkRoomId(roomId protocol.RoomID) *ApiError {
+	if roomId == "" {
+		return newApiError(http.StatusBadRequest, protocol.ApiRoomIdMissing, "Room id not provided")
+	} else if len(roomId) > maxRoomIdLength {
+		return newApiError(http.StatusBadRequest, protocol.ApiRoomIdTooLong, "Room id is too long")
+	}
+	return nil
+}
+
+// checkRoomMeta validates the room metadata changes, text fields are trimmed.
+func checkRoomMeta(update *protocol.RoomMetaUpdate) *ApiError {
+	for _, field := range []*string{update.Title, update.Description, update.Icon} {
+		if field != nil {
+			*field = strings.TrimSpace(*field)
+		}
+	}
+	if update.Title != nil && utf8.RuneCountInString(*update.Title) > maxRoomTitleLength {
+		return newApiError(http.StatusBadRequest, protocol.ApiRoomTitleTooLong, "Room title is too long")
+	}
+	if update.Description != nil && utf8.RuneCountInString(*update.Description) > maxRoomDescriptionLength {
+		return newApiError(http.StatusBadRequest, protocol.ApiRoomDescTooLong, "Room description is too long")
+	}
+	if update.Icon != nil && utf8.RuneCountInString(*update.Icon) > maxRoomIconLength {
+		return newApiError(http.StatusBadRequest, protocol.ApiRoomIconTooLong, "Room icon is too long")
+	}
+	if update.Locale != nil && !protocol.IsSupportedLocale(*update.Locale) {
+		return newApiError(http.StatusBadRequest, protocol.ApiInvalidLocale, "Locale is not supported")
+	}
+	return nil
+}
+
+// checkTeams validates team names of the new team room.
+func checkTeams(teams []string) *ApiError {
+	if len(teams) < minRoomTeams || len(teams) > maxRoomTeams {
+		return newApiError(http.StatusBadRequest, protocol.ApiInvalidTeams, "Invalid count of teams")
+	}
+	for i, team := range teams {
+		if team == "" || utf8.RuneCountInString(team) > maxTeamNameLength {
+			return newApiError(http.StatusBadRequest, protocol.ApiInvalidTeams, "Invalid team name length")
+		}
+		for _, r := range team {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+				return newApiError(http.StatusBadRequest, protocol.ApiInvalidTeams, "Team name contains invalid characters")
+			}
+		}
+		if slices.Contains(teams[:i], team) {
+			return newApiError(http.StatusBadRequest, protocol.ApiInvalidTeams, "Team names are not unique")
+		}
+	}
+	return nil
+}
+
+// findRoom retrieves the game room by its reference.
+func (w *Web) findRoom(ref protocol.RoomRef) (*GameRoom, *ApiError) {
+	if apiErr := checkRoomId(ref.RoomID); apiErr != nil {
+		return nil, apiErr
+	}
+	roomKey := protocol.RoomKey(tuple.New2(ref.ClientID, ref.RoomID))
+	room, err := w.lookupRoom(roomKey)
+	if err != nil {
+		return nil, internalApiError(err)
+	} else if room == nil {
+		return nil, newApiError(http.StatusNotFound, protocol.ApiRoomNotFound, "Room not found")
+	}
+	return room, nil
+}
+
+// lookupRoom retrieves the open game room, nil if the room does not exist.
+// Custom rooms created by other instances are loaded and closed ones are forgotten.
+func (w *Web) lookupRoom(roomKey protocol.RoomKey) (*GameRoom, error) {
+	w.roomsMu.RLock()
+	room, exists := w.rooms[roomKey]
+	w.roomsMu.RUnlock()
+	if exists && !room.Closed() {
+		return room, nil
+	}
+	owner, err := w.db.GetCustomGameRoomOwner(roomKey.V1, roomKey.V2)
+	if err != nil {
+		return nil, err
+	}
+	w.roomsMu.Lock()
+	defer w.roomsMu.Unlock()
+	// Another request could load the room in the meantime
+	if room, exists := w.rooms[roomKey]; exists && !room.Closed() {
+		return room, nil
+	}
+	delete(w.rooms, roomKey)
+	if _, exists := w.mods[roomKey.V1]; owner == "" || !exists {
+		return nil, nil
+	}
+	room, err = NewCustomGameRoom(roomKey.V1, roomKey.V2, w.db, w.mods[roomKey.V1], w.reacts[roomKey.V1], w.hooks)
+	if room == nil {
+		return nil, err
+	} else if err != nil {
+		log.Println("Failed to load room messages:", err)
+	}
+	w.rooms[roomKey] = room
+	return room, nil
+}
+
+// RoomMode returns the configured mode of the room, empty if the room does not exist.
+func (w *Web) RoomMode(clientId protocol.ClientID, roomId protocol.RoomID) (protocol.RoomMode, error) {
+	room, err := w.lookupRoom(protocol.RoomKey(tuple.New2(clientId, roomId)))
+	if err != nil || room == nil {
+		return "", err
+	}
+	return room.mode.Name(), nil
+}
+
+// ReleaseReaped ends the reaped hold in the mode of its room and announces the ended session,
+// holds of removed rooms are discarded. Returns whether the hold is scored.
+func (w *Web) ReleaseReaped(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	userID protocol.UserID,
+	record *protocol.GameplayRecord,
+	end int64,
+) (bool, error) {
+	room, err := w.lookupRoom(protocol.RoomKey(tuple.New2(clientId, roomId)))
+	if err != nil || room == nil {
+		return false, err
+	}
+	scored, err := room.mode.OnReaped(userID, record, end)
+	// The session ends even if the mode failed to release it
+	room.emit(protocol.WebhookSessionEnded, userID, record)
+	return scored, err
+}
+
+// addRoom adds the game room created by this instance.
+func (w *Web) addRoom(roomKey protocol.RoomKey, room *GameRoom) {
+	w.roomsMu.Lock()
+	defer w.roomsMu.Unlock()
+	w.rooms[roomKey] = room
+}
+
+// removeRoom closes the game room and forgets it.
+func (w *Web) removeRoom(roomKey protocol.RoomKey, room *GameRoom) error {
+	w.roomsMu.Lock()
+	defer w.roomsMu.Unlock()
+	if w.rooms[roomKey] == room {
+		delete(w.rooms, roomKey)
+	}
+	return room.Close()
+}
+
+// tickRooms ticks every room of the instance with one ticker until the context is done.
+func (w *Web) tickRooms() {
+	ticker := time.NewTicker(modeTickInterval)
+	defer ticker.Stop()
+	for {
+		var now time.Time
+		select {
+		case <-w.ctx.Done():
+			return
+		case now = <-ticker.C:
+		}
+		w.roomsMu.RLock()
+		rooms := slices.Collect(maps.Values(w.rooms))
+		w.roomsMu.RUnlock()
+		for _, room := range rooms {
+			room.tick(now)
+		}
+	}
+}
+
+// maxRoomsPerUser returns the count of custom rooms the user may own in the client.
+func (w *Web) maxRoomsPerUser(clientId protocol.ClientID) int64 {
+	for _, clientConf := range w.conf.Clients {
+		if clientConf.ClientId == clientId {
+			return int64(valueOrDefault(clientConf.CustomRooms.MaxPerUser, defaultRoomsPerUser))
+		}
+	}
+	return defaultRoomsPerUser
+}
+
+// viewRoom retrieves the game room the user is allowed to view with its metadata.
+func (w *Web) viewRoom(ref protocol.RoomRef, auth protocol.UserAuth) (*GameRoom, *protocol.RoomMeta, *ApiError) {
+	room, apiErr := w.findRoom(ref)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+	meta, err := room.Meta()
+	if err != nil {
+		return nil, nil, internalApiError(fmt.Errorf("failed to get room metadata: %w", err))
+	}
+	// Members of the private room are identified by telegram init data only
+	var userID protocol.UserID
+	if meta != nil && meta.Private && (len(auth.UserID) > 0 || len(auth.InitData) > 0) {
+		if userID, apiErr = w.authVerifiedUser(auth); apiErr != nil {
+			return nil, nil, apiErr
+		}
+	}
+	if apiErr := roomAccessApiError(room.HasAccess(userID, meta)); apiErr != nil {
+		return nil, nil, apiErr
+	}
+	return room, meta, nil
+}
+
+// roomAccessApiError converts the result of room access check.
+func roomAccessApiError(access bool, err error) *ApiError {
+	if err != nil {
+		return internalApiError(err)
+	} else if !access {
+		return newApiError(http.StatusForbidden, protocol.ApiRoomPrivate, "Room is private")
+	}
+	return nil
+}
+
+// createRoom creates a custom game room owned by the user, the title defaults to the room id.
+// The room id is normalized to the slug, returns the reference of the created room.
+func (w *Web) createRoom(
+	ref protocol.RoomRef,
+	userID protocol.UserID,
+	update protocol.RoomMetaUpdate,
+	mode protocol.RoomMode,
+	teams []string,
+) (protocol.RoomRef, *ApiError) {
+	roomId, apiErr := checkNewRoomId(ref.RoomID)
+	if apiErr != nil {
+		return ref, apiErr
+	}
+	ref.RoomID = roomId
+	if apiErr := checkRoomMeta(&update); apiErr != nil {
+		return ref, apiErr
+	}
+	if mode == "" {
+		mode = protocol.ModeClassic
+	} else if !slices.Contains(roomModes, mode) {
+		return ref, newApiError(http.StatusBadRequest, protocol.ApiInvalidMode, "Room mode is not supported")
+	}
+	if mode != protocol.ModeTeams {
+		teams = nil
+	} else if apiErr := checkTeams(teams); apiErr != nil {
+		return ref, apiErr
+	}
+	// Check if client allowed
+	if !slices.Contains(w.clients, ref.ClientID) {
+		return ref, newApiError(http.StatusBadRequest, protocol.ApiClientNotAllowed, "Client not allowed")
+	}
+	// Check if the id is reserved or profane
+	if w.mods[ref.ClientID].containsForbiddenSlug(ref.RoomID) {
+		return ref, newApiError(http.StatusBadRequest, protocol.ApiRoomIdForbidden, "Room id is not allowed")
+	} else if w.reserved[ref.ClientID][ref.RoomID] {
+		return ref, w.roomIdConflictApiError(ref, protocol.ApiRoomIdReserved, "Room id is reserved")
+	}
+	// Ids of archived rooms stay bound to their archived leaderboards
+	if archived, err := w.db.IsRoomArchived(ref.ClientID, ref.RoomID); err != nil {
+		return ref, internalApiError(err)
+	} else if archived {
+		return ref, w.roomIdConflictApiError(ref, protocol.ApiRoomIdReserved, "Room id is reserved")
+	}
+	// Check if the room is already created
+	roomKey := protocol.RoomKey(tuple.New2(ref.ClientID, ref.RoomID))
+	if room, err := w.lookupRoom(roomKey); err != nil {
+		return ref, internalApiError(err)
+	} else if room != nil {
+		return ref, w.roomIdConflictApiError(ref, protocol.ApiRoomExists, "Room exists")
+	}
+	// Create new record in db
+	meta := protocol.RoomMeta{
+		Title:   string(ref.RoomID),
+		Owner:   userID,
+		Created: time.Now().Unix(),
+		Mode:    mode,
+		Teams:   teams,
+	}
+	if update.Title != nil && *update.Title != "" {
+		meta.Title = *update.Title
+	}
+	if update.Description != nil {
+		meta.Description = *update.Description
+	}
+	if update.Icon != nil {
+		meta.Icon = *update.Icon
+	}
+	if update.Locale != nil {
+		meta.Locale = protocol.UserLocale(*update.Locale)
+	}
+	if update.Private != nil {
+		meta.Private = *update.Private
+	}
+	err := w.db.AddCustomGameRoom(ref.ClientID, ref.RoomID, userID, meta, w.maxRoomsPerUser(ref.ClientID))
+	if errors.Is(err, db.ErrRoomExists) {
+		return ref, w.roomIdConflictApiError(ref, protocol.ApiRoomExists, "Room exists")
+	} else if errors.Is(err, db.ErrRoomQuotaExceeded) {
+		return ref, newApiError(http.StatusForbidden, protocol.ApiRoomQuotaExceeded, "Too many rooms owned by the user")
+	} else if err != nil {
+		return ref, internalApiError(err)
+	}
+	// Create room and add to map
+	room, err := NewGameRoom(
+		ref.ClientID,
+		ref.RoomID,
+		w.db,
+		nil,
+		w.mods[ref.ClientID],
+		w.reacts[ref.ClientID],
+		w.hooks,
+		mode,
+		teams,
+	)
+	if err != nil {
+		return ref, internalApiError(err)
+	}
+	w.addRoom(roomKey, room)
+	room.emit(protocol.WebhookRoomCreated, userID, nil)
+	return ref, nil
+}
+
+// deleteRoom deletes the custom game room owned by the user.
+func (w *Web) deleteRoom(ref protocol.RoomRef, userID protocol.UserID) *ApiError {
+	if apiErr := checkRoomId(ref.RoomID); apiErr != nil {
+		return apiErr
+	}
+	// Check room key (predefined rooms cannot be deleted)
+	for _, clientsConf := range w.conf.Clients {
+		if ref.ClientID == clientsConf.ClientId && slices.Contains(clientsConf.Rooms, ref.RoomID) {
+			return newApiError(http.StatusBadRequest, protocol.ApiRoomNotDeletable, "Room cannot be deleted")
+		}
+	}
+	room, apiErr := w.findRoom(ref)
+	if apiErr != nil {
+		return apiErr
+	}
+	if apiErr := ownerApiError(room.checkRole(userID, "", protocol.RoleOwner)); apiErr != nil {
+		return apiErr
+	}
+	// Remove record from db
+	if err := w.db.RemoveCustomGameRoom(ref.ClientID, ref.RoomID, userID); err != nil {
+		return internalApiError(err)
+	}
+	// Close room here and on other instances
+	roomKey := protocol.RoomKey(tuple.New2(ref.ClientID, ref.RoomID))
+	if err := w.removeRoom(roomKey, room); err != nil {
+		log.Println("Failed to close room:", err)
+	}
+	if err := w.db.PublishRoomControl(ref.ClientID, ref.RoomID, protocol.RoomControl{
+		Action: protocol.ControlClose,
+	}); err != nil {
+		log.Println("Failed to publish room close:", err)
+	}
+	room.emit(protocol.WebhookRoomDeleted, userID, nil)
+	return nil
+}
+
+// newRoomInfo creates the room info, predefined rooms have no metadata.
+func newRoomInfo(ref protocol.RoomRef, meta *protocol.RoomMeta) protocol.RoomInfo {
+	info := protocol.RoomInfo{RoomRef: ref}
+	if meta != nil {
+		info.RoomMeta = *meta
+	}
+	return info
+}
+
+// roomInfo retrieves the game room with its metadata.
+func (w *Web) roomInfo(ref protocol.RoomRef, auth protocol.UserAuth) (protocol.RoomInfo, *ApiError) {
+	room, meta, apiErr := w.viewRoom(ref, auth)
+	if apiErr != nil {
+		return protocol.RoomInfo{}, apiErr
+	}
+	info := newRoomInfo(ref, meta)
+	info.Event = room.Event()
+	return info, nil
+}
+
+// updateRoom edits metadata of the custom game room owned by the user.
+func (w *Web) updateRoom(
+	ref protocol.RoomRef,
+	userID protocol.UserID,
+	update protocol.RoomMetaUpdate,
+) (protocol.RoomInfo, *ApiError) {
+	room, apiErr := w.findRoom(ref)
+	if apiErr != nil {
+		return protocol.RoomInfo{}, apiErr
+	}
+	if apiErr := checkRoomMeta(&update); apiErr != nil {
+		return protocol.RoomInfo{}, apiErr
+	}
+	if update.Title != nil && *update.Title == "" {
+		title := string(ref.RoomID)
+		update.Title = &title
+	}
+	if apiErr := ownerApiError(room.UpdateMeta(userID, update)); apiErr != nil {
+		return protocol.RoomInfo{}, apiErr
+	}
+	meta, err := room.Meta()
+	if err != nil {
+		return protocol.RoomInfo{}, internalApiError(fmt.Errorf("failed to get room metadata: %w", err))
+	}
+	return newRoomInfo(ref, meta), nil
+}
+
+// roomStats retrieves statistics of the game room.
+func (w *Web) roomStats(ref protocol.RoomRef, auth protocol.UserAuth) (protocol.GameRoomStats, *ApiError) {
+	room, _, apiErr := w.viewRoom(ref, auth)
+	if apiErr != nil {
+		return protocol.GameRoomStats{}, apiErr
+	}
+	stats, err := room.Stats(userPayloadCountInStats, usersCountInStats)
+	if err != nil {
+		return stats, internalApiError(fmt.Errorf("failed to get room stats: %w", err))
+	}
+	return stats, nil
+}
+
+// parseChatPage parses chat history pagination parameters.
+func parseChatPage(c *gin.Context) (int64, int64, *ApiError) {
+	beforeStr := c.DefaultQuery("before", "0")
+	limitStr := c.DefaultQuery("limit", strconv.Itoa(defaultChatHistoryLimit))
+	before, err := strconv.ParseInt(beforeStr, 10, 64)
+	if err != nil || before < 0 {
+		return 0, 0, newApiError(http.StatusBadRequest, protocol.ApiInvalidBefore, "Invalid before message id")
+	}
+	limit, err := strconv.ParseInt(limitStr, 10, 64)
+	if err != nil || limit <= 0 || limit > maxChatHistoryLimit {
+		return 0, 0, newApiError(http.StatusBadRequest, protocol.ApiInvalidLimit, "Invalid limit")
+	}
+	return before, limit, nil
+}
+
+// roomChat retrieves the page of the room chat history.
+func (w *Web) roomChat(
+	ref protocol.RoomRef,
+	auth protocol.UserAuth,
+	before int64,
+	limit int64,
+) (protocol.ChatHistory, *ApiError) {
+	if _, _, apiErr := w.viewRoom(ref, auth); apiErr != nil {
+		return protocol.ChatHistory{}, apiErr
+	}
+	messages, err := w.db.GetChatHistory(ref.ClientID, ref.RoomID, before, limit)
+	if err != nil {
+		return protocol.ChatHistory{}, internalApiError(fmt.Errorf("failed to get room chat history: %w", err))
+	}
+	for i := range messages {
+		profile := messages[i].User.Public()
+		messages[i].User = &profile
+	}
+	return protocol.NewChatHistory(messages, limit), nil
+}
+
+// clientStats retrieves statistics of the client.
+func (w *Web) clientStats(clientId protocol.ClientID) (protocol.ClientStats, *ApiError) {
+	if !slices.Contains(w.clients, clientId) {
+		return protocol.ClientStats{}, newApiError(http.StatusNotFound, protocol.ApiClientNotFound, "Client not found")
+	}
+	customRooms, err := w.db.ListCustomGameRooms()
+	if err != nil {
+		return protocol.ClientStats{}, internalApiError(err)
+	}
+	onlineUsersCount, err := w.db.GetOnlineUsersCount(clientId)
+	if err != nil {
+		return protocol.ClientStats{}, internalApiError(err)
+	}
+	usersOnline := int64(onlineUsersCount)
+	roomsCount := int64(len(customRooms))
+	reactions := w.reacts[clientId].List()
+	return protocol.NewClientStats(&usersOnline, &roomsCount, &reactions), nil
+}
+
+// checkMessagePack validates the room message pack, messages are trimmed and empty ones are dropped.
+func checkMessagePack(
+	pack map[protocol.UserLocale][]string,
+	mod *ChatModerator,
+) (map[protocol.UserLocale][]string, *ApiError) {
+	checked := make(map[protocol.UserLocale][]string)
+	size := 0
+	for locale, messages := range pack {
+		if !protocol.IsSupportedLocale(string(locale)) {
+			return nil, newApiError(http.StatusBadRequest, protocol.ApiInvalidLocale, "Locale is not supported")
+		}
+		var localeMessages []string
+		for _, message := range messages {
+			message = strings.TrimSpace(message)
+			if message == "" {
+				continue
+			}
+			if utf8.RuneCountInString(message) > maxRoomMessageLength {
+				return nil, newApiError(http.StatusBadRequest, protocol.ApiMessageTooLong, "Message is too long")
+			}
+			if mod.checkContent(locale, message) != "" {
+				return nil, newApiError(http.StatusBadRequest, protocol.ApiMessageRejected, "Message contains links or forbidden words")
+			}
+			size += len(message)
+			localeMessages = append(localeMessages, message)
+		}
+		if len(localeMessages) > maxRoomMessages {
+			return nil, newApiError(http.StatusBadRequest, protocol.ApiMessagesTooMany, "Too many messages")
+		}
+		if len(localeMessages) > 0 {
+			checked[locale] = localeMessages
+		}
+	}
+	if size > maxRoomMessagesLength {
+		return nil, newApiError(http.StatusBadRequest, protocol.ApiMessagesTooLarge, "Message pack is too large")
+	}
+	if len(checked) == 0 {
+		return nil, newApiError(http.StatusBadRequest, protocol.ApiMessagesEmpty, "Message pack is empty")
+	}
+	return checked, nil
+}
+
+// roomMessages retrieves the message pack of the custom game room.
+func (w *Web) roomMessages(ref protocol.RoomRef, auth protocol.UserAuth) (protocol.MessagePack, *ApiError) {
+	room, _, apiErr := w.viewRoom(ref, auth)
+	if apiErr != nil {
+		return protocol.MessagePack{}, apiErr
+	}
+	pack, err := w.db.GetRoomMessages(room.ClientID, room.RoomID)
+	if err != nil {
+		return protocol.MessagePack{}, internalApiError(fmt.Errorf("failed to get room messages: %w", err))
+	}
+	return protocol.MessagePack{Messages: pack}, nil
+}
+
+// setRoomMessages replaces the message pack of the custom game room owned by the user.
+func (w *Web) setRoomMessages(
+	ref protocol.RoomRef,
+	userID protocol.UserID,
+	pack map[protocol.UserLocale][]string,
+) (protocol.MessagePack, *ApiError) {
+	room, apiErr := w.findRoom(ref)
+	if apiErr != nil {
+		return protocol.MessagePack{}, apiErr
+	}
+	pack, apiErr = checkMessagePack(pack, room.Mod)
+	if apiErr != nil {
+		return protocol.MessagePack{}, apiErr
+	}
+	if apiErr := ownerApiError(room.SetMessages(userID, pack)); apiErr != nil {
+		return protocol.MessagePack{}, apiErr
+	}
+	return protocol.MessagePack{Messages: pack}, nil
+}
+
+// removeRoomMessages removes the message pack of the custom game room owned by the user.
+func (w *Web) removeRoomMessages(ref protocol.RoomRef, userID protocol.UserID) *ApiError {
+	room, apiErr := w.findRoom(ref)
+	if apiErr != nil {
+		return apiErr
+	}
+	return ownerApiError(room.RemoveMessages(userID))
+}
+
+// parseRoomListPage parses room discovery parameters.
+func parseRoomListPage(c *gin.Context) (string, int64, int64, *ApiError) {
+	sort := c.DefaultQuery("sort", roomListSortActive)
+	if sort != roomListSortActive && sort != roomListSortPlayers && sort != roomListSortRecent {
+		return "", 0, 0, newApiError(http.StatusBadRequest, protocol.ApiInvalidSort, "Invalid sort")
+	}
+	offset, err := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+	if err != nil || offset < 0 {
+		return "", 0, 0, newApiError(http.StatusBadRequest, protocol.ApiInvalidOffset, "Invalid offset")
+	}
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", strconv.Itoa(defaultRoomListLimit)), 10, 64)
+	if err != nil || limit <= 0 || limit > maxRoomListLimit {
+		return "", 0, 0, newApiError(http.StatusBadRequest, protocol.ApiInvalidLimit, "Invalid limit")
+	}
+	return sort, offset, limit, nil
+}
+
+// compareRoomListings compares rooms by the sort key descending, ties are ordered by title.
+func compareRoomListings(sort string) func(a, b protocol.RoomListing) int {
+	return func(a, b protocol.RoomListing) int {
+		var c int
+		switch sort {
+		case roomListSortActive:
+			c = cmp.Compare(b.Active, a.Active)
+		case roomListSortPlayers:
+			c = cmp.Compare(b.Players, a.Players)
+		case roomListSortRecent:
+			c = cmp.Compare(b.LastActivity, a.LastActivity)
+		}
+		if c != 0 {
+			return c
+		}
+		return cmp.Or(
+			cmp.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title)),
+			cmp.Compare(a.RoomID, b.RoomID),
+		)
+	}
+}
+
+// listRooms searches public custom rooms of the client by title.
+func (w *Web) listRooms(
+	clientId protocol.ClientID,
+	query string,
+	sort string,
+	offset int64,
+	limit int64,
+) (protocol.RoomList, *ApiError) {
+	if !slices.Contains(w.clients, clientId) {
+		return protocol.RoomList{}, newApiError(http.StatusNotFound, protocol.ApiClientNotFound, "Client not found")
+	}
+	directory, err := w.db.GetDirectoryRooms(clientId)
+	if err != nil {
+		return protocol.RoomList{}, internalApiError(fmt.Errorf("failed to get room directory: %w", err))
+	}
+	activityByRoom, err := w.db.GetRoomsActivity(clientId)
+	if err != nil {
+		return protocol.RoomList{}, internalApiError(err)
+	}
+	query = strings.ToLower(strings.TrimSpace(query))
+	rooms := []protocol.RoomListing{}
+	for roomId, meta := range directory {
+		if !strings.Contains(strings.ToLower(meta.Title), query) {
+			continue
+		}
+		// Rooms without records fall back to creation time
+		activity := activityByRoom[roomId]
+		activity.LastActivity = max(activity.LastActivity, meta.Created)
+		rooms = append(rooms, protocol.RoomListing{
+			RoomInfo:     newRoomInfo(protocol.RoomRef{ClientID: clientId, RoomID: roomId}, &meta),
+			RoomActivity: activity,
+		})
+	}
+	slices.SortFunc(rooms, compareRoomListings(sort))
+	total := int64(len(rooms))
+	start := min(offset, total)
+	end := min(offset+limit, total)
+	return protocol.NewRoomList(rooms[start:end], total, offset), nil
+}
+
+// archivedRooms retrieves public archived rooms of the client, empty owner retrieves rooms of every user.
+func (w *Web) archivedRooms(clientId protocol.ClientID, owner protocol.UserID) ([]protocol.ArchivedRoom, *ApiError) {
+	if !slices.Contains(w.clients, clientId) {
+		return nil, newApiError(http.StatusNotFound, protocol.ApiClientNotFound, "Client not found")
+	}
+	rooms, err := w.db.GetArchivedRooms(clientId, owner)
+	if err != nil {
+		return nil, internalApiError(err)
+	}
+	return rooms, nil
+}
+
+// archivedRoom retrieves the public archived room with its leaderboard.
+func (w *Web) archivedRoom(ref protocol.RoomRef) (protocol.ArchivedRoom, *ApiError) {
+	if apiErr := checkRoomId(ref.RoomID); apiErr != nil {
+		return protocol.ArchivedRoom{}, apiErr
+	}
+	room, err := w.db.GetArchivedRoom(ref.ClientID, ref.RoomID)
+	if err != nil {
+		return protocol.ArchivedRoom{}, internalApiError(err)
+	} else if room == nil {
+		return protocol.ArchivedRoom{}, newApiError(http.StatusNotFound, protocol.ApiRoomNotArchived, "Room not archived")
+	}
+	leaderboard, err := w.db.GetArchivedLeaderboard(ref.ClientID, ref.RoomID, usersCountInStats)
+	if err != nil {
+		return protocol.ArchivedRoom{}, internalApiError(err)
+	}
+	for i := range leaderboard {
+		leaderboard[i].User = leaderboard[i].User.Public()
+	}
+	room.Leaderboard = &leaderboard
+	return *room, nil
+}
+
+// reportChat registers the user's report on another user's chat messages.
+func (w *Web) reportChat(
+	clientId protocol.ClientID,
+	userID protocol.UserID,
+	reportedUserID protocol.UserID,
+) (bool, *ApiError) {
+	if len(reportedUserID) == 0 {
+		return false, newApiError(http.StatusBadRequest, protocol.ApiTargetUserIdMissing, "Reported user id not provided")
+	}
+	mod, exists := w.mods[clientId]
+	if !exists || !slices.Contains(w.clients, clientId) {
+		return false, newApiError(http.StatusBadRequest, protocol.ApiClientNotAllowed, "Client not allowed")
+	}
+	// Register report, the user is muted once enough reports collected
+	muted, err := mod.Report(userID, reportedUserID)
+	if errors.Is(err, ErrChatReportYourself) {
+		return false, newApiError(http.StatusBadRequest, protocol.ApiReportYourself, "Users cannot report themselves")
+	} else if err != nil {
+		return false, internalApiError(err)
+	}
+	return muted, nil
+}
+
+// setPrivacy updates the user's profile privacy.
+func (w *Web) setPrivacy(userID protocol.UserID, privacyStr string) *ApiError {
+	privacy, ok := protocol.NewUserPrivacy(privacyStr)
+	if !ok {
+		return newApiError(http.StatusBadRequest, protocol.ApiInvalidPrivacy, "Invalid privacy setting")
+	}
+	if err := w.db.SetUserPrivacy(userID, privacy); err != nil {
+		return internalApiError(err)
+	}
+	return nil
+}
+
+// ownerApiError converts the error of room owner's or moderator's action.
+func ownerApiError(err error) *ApiError {
+	if errors.Is(err, ErrRoomNotOwner) {
+		return newApiError(http.StatusForbidden, protocol.ApiRoomNotOwner, "Room does not belong to the user")
+	} else if errors.Is(err, ErrRoomNotModerator) {
+		return newApiError(http.StatusForbidden, protocol.ApiRoomNotModerator, "User does not moderate the room")
+	} else if errors.Is(err, ErrRoomTargetProtected) {
+		return newApiError(http.StatusForbidden, protocol.ApiRoomTargetProtected, "Room owner and moderators cannot be targeted by moderators")
+	} else if errors.Is(err, ErrRoomOwnerSelf) {
+		return newApiError(http.StatusBadRequest, protocol.ApiRoomOwnerSelf, "User cannot target themselves")
+	} else if errors.Is(err, ErrRoomTransferNotModerator) {
+		return newApiError(http.StatusBadRequest, protocol.ApiTransferNotModerator, "Room can be transferred to its moderator only")
+	} else if errors.Is(err, db.ErrRoomQuotaExceeded) {
+		return newApiError(http.StatusForbidden, protocol.ApiRoomQuotaExceeded, "Too many rooms owned by the user")
+	} else if errors.Is(err, db.ErrRoomNotOwned) {
+		return newApiError(http.StatusForbidden, protocol.ApiRoomNotOwner, "Room does not belong to the user")
+	} else if err != nil {
+		return internalApiError(err)
+	}
+	return nil
+}
+
+// tournamentApiError converts the error of tournament operation.
+func tournamentApiError(err error) *ApiError {
+	if errors.Is(err, ErrRoomNotTournament) {
+		return newApiError(http.StatusBadRequest, protocol.ApiRoomNotTournament, "Room is not in the tournament mode")
+	} else if errors.Is(err, db.ErrTournamentScheduled) {
+		return newApiError(http.StatusBadRequest, protocol.ApiTournamentScheduled, "Tournament is not finished yet")
+	} else if errors.Is(err, db.ErrTournamentNotScheduled) {
+		return newApiError(http.StatusNotFound, protocol.ApiTournamentNotFound, "Tournament not found")
+	} else if errors.Is(err, db.ErrTournamentRegistrationClosed) {
+		return newApiError(http.StatusForbidden, protocol.ApiRegistrationClosed, "Tournament registration is closed")
+	} else if errors.Is(err, ErrRoomUserBanned) {
+		return newApiError(http.StatusForbidden, protocol.ApiRoomUserBanned, "User is banned from the room")
+	}
+	return ownerApiError(err)
+}
+
+// checkTarget validates the target of room owner's action.
+func checkTarget(targetID protocol.UserID, durationSeconds int64) (time.Duration, *ApiError) {
+	if len(targetID) == 0 {
+		return 0, newApiError(http.StatusBadRequest, protocol.ApiTargetUserIdMissing, "Target user id not provided")
+	}
+	if durationSeconds < 0 {
+		return 0, newApiError(http.StatusBadRequest, protocol.ApiInvalidDuration, "Invalid duration")
+	}
+	return time.Duration(durationSeconds) * time.Second, nil
+}
+
+// inviteApiError converts the error of invite code redemption.
+func inviteApiError(err error) *ApiError {
+	if errors.Is(err, db.ErrInviteInvalid) {
+		return newApiError(http.StatusForbidden, protocol.ApiInviteInvalid, "Invite code is invalid")
+	} else if errors.Is(err, db.ErrInviteExpired) {
+		return newApiError(http.StatusForbidden, protocol.ApiInviteExpired, "Invite code has expired")
+	} else if errors.Is(err, db.ErrInviteExhausted) {
+		return newApiError(http.StatusForbidden, protocol.ApiInviteExhausted, "Invite code has been used up")
+	} else if err != nil {
+		return internalApiError(err)
+	}
+	return nil
+}
+
+// teamStats retrieves live standings and the leaderboard of the room teams.
+func (w *Web) teamStats(ref protocol.RoomRef, auth protocol.UserAuth) (protocol.TeamStats, *ApiError) {
+	room, _, apiErr := w.viewRoom(ref, auth)
+	if apiErr != nil {
+		return protocol.TeamStats{}, apiErr
+	}
+	stats, err := room.TeamStats()
+	if errors.Is(err, ErrRoomNotTeams) {
+		return protocol.TeamStats{}, newApiError(http.StatusBadRequest, protocol.ApiRoomNotTeams, "Room is not in the teams mode")
+	} else if err != nil {
+		return protocol.TeamStats{}, internalApiError(err)
+	}
+	return stats, nil
+}
+
+// chainStats retrieves the live chain and the longest chains of the room.
+func (w *Web) chainStats(ref protocol.RoomRef, auth protocol.UserAuth) (protocol.ChainStats, *ApiError) {
+	room, _, apiErr := w.viewRoom(ref, auth)
+	if apiErr != nil {
+		return protocol.ChainStats{}, apiErr
+	}
+	stats, err := room.ChainStats()
+	if errors.Is(err, ErrRoomNotChain) {
+		return protocol.ChainStats{}, newApiError(http.StatusBadRequest, protocol.ApiRoomNotChain, "Room is not in the chain mode")
+	} else if err != nil {
+		return protocol.ChainStats{}, internalApiError(err)
+	}
+	return stats, nil
+}
+
+// eventResult retrieves the best holds of the latest event of the room.
+func (w *Web) eventResult(ref protocol.RoomRef, auth protocol.UserAuth) (protocol.EventResult, *ApiError) {
+	room, _, apiErr := w.viewRoom(ref, auth)
+	if apiErr != nil {
+		return protocol.EventResult{}, apiErr
+	}
+	result, err := room.EventResult(eventResultsLimit)
+	if errors.Is(err, ErrRoomNotEvent) {
+		return protocol.EventResult{}, newApiError(http.StatusBadRequest, protocol.ApiRoomNotEvent, "Room is not an event room")
+	} else if err != nil {
+		return protocol.EventResult{}, internalApiError(err)
+	}
+	return result, nil
+}
+
+// scheduleTournament schedules the tournament of the room owned by the user.
+func (w *Web) scheduleTournament(
+	ref protocol.RoomRef,
+	userID protocol.UserID,
+	starts int64,
+	grace int64,
+) (*protocol.Tournament, *ApiError) {
+	now := time.Now()
+	if starts <= now.Unix() || starts > now.Add(maxTournamentSchedule).Unix() {
+		return nil, newApiError(http.StatusBadRequest, protocol.ApiInvalidSchedule, "Invalid tournament start")
+	}
+	if grace < minTournamentGrace || grace > maxTournamentGrace {
+		return nil, newApiError(http.StatusBadRequest, protocol.ApiInvalidSchedule, "Invalid tournament grace period")
+	}
+	room, apiErr := w.findRoom(ref)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	tournament, err := room.ScheduleTournament(userID, starts, grace)
+	if apiErr := tournamentApiError(err); apiErr != nil {
+		return nil, apiErr
+	}
+	return tournament, nil
+}
+
+// roomTournament retrieves the last tournament of the room the user is allowed to view.
+func (w *Web) roomTournament(ref protocol.RoomRef, auth protocol.UserAuth) (*protocol.Tournament, *ApiError) {
+	room, _, apiErr := w.viewRoom(ref, auth)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	tournament, err := room.Tournament()
+	if apiErr := tournamentApiError(err); apiErr != nil {
+		return nil, apiErr
+	}
+	return tournament, nil
+}
+
+// registerTournament registers the user allowed to hold the button in the room tournament,
+// the verified user is identified by telegram init data.
+func (w *Web) registerTournament(ref protocol.RoomRef, userID protocol.UserID, verified bool) *ApiError {
+	room, apiErr := w.findRoom(ref)
+	if apiErr != nil {
+		return apiErr
+	}
+	if apiErr := w.checkRoomAccess(room, userID, verified, ""); apiErr != nil {
+		return apiErr
+	}
+	return tournamentApiError(room.RegisterTournamentPlayer(userID))
+}
+
+// tournamentResults retrieves final standings of the latest tournaments of the room.
+func (w *Web) tournamentResults(ref protocol.RoomRef, auth protocol.UserAuth) ([]protocol.TournamentResult, *ApiError) {
+	room, _, apiErr := w.viewRoom(ref, auth)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	results, err := room.TournamentResults(tournamentResultsLimit)
+	if apiErr := tournamentApiError(err); apiErr != nil {
+		return nil, apiErr
+	}
+	return results, nil
+}
+
+// inviteLink builds the telegram app link opening the app with the invite code.
+func (w *Web) inviteLink(code string) string {
+	appUrl, _ := w.ctx.Value(bot.KeyTelegramAppUrl).(string)
+	link, err := url.Parse(appUrl)
+	if err != nil || appUrl == "" {
+		return ""
+	}
+	query := link.Query()
+	query.Set("startapp", code)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+// createInvite issues a new invite code of the room owned by the user.
+func (w *Web) createInvite(
+	ref protocol.RoomRef,
+	userID protocol.UserID,
+	ttlSeconds int64,
+	maxUses int64,
+) (protocol.RoomInvite, *ApiError) {
+	room, apiErr := w.findRoom(ref)
+	if apiErr != nil {
+		return protocol.RoomInvite{}, apiErr
+	}
+	if ttlSeconds < 0 {
+		return protocol.RoomInvite{}, newApiError(http.StatusBadRequest, protocol.ApiInvalidDuration, "Invalid duration")
+	}
+	if maxUses < 0 {
+		return protocol.RoomInvite{}, newApiError(http.StatusBadRequest, protocol.ApiInvalidMaxUses, "Invalid max uses")
+	}
+	invite, err := room.CreateInvite(userID, time.Duration(ttlSeconds)*time.Second, maxUses)
+	if apiErr := ownerApiError(err); apiErr != nil {
+		return protocol.RoomInvite{}, apiErr
+	}
+	invite.Link = w.inviteLink(invite.Code)
+	return invite, nil
+}
+
+// roomInvite retrieves the current invite of the room owned by the user.
+func (w *Web) roomInvite(ref protocol.RoomRef, userID protocol.UserID) (protocol.RoomInvite, *ApiError) {
+	room, apiErr := w.findRoom(ref)
+	if apiErr != nil {
+		return protocol.RoomInvite{}, apiErr
+	}
+	invite, err := room.Invite(userID)
+	if apiErr := ownerApiError(err); apiErr != nil {
+		return protocol.RoomInvite{}, apiErr
+	}
+	if invite == nil {
+		return protocol.RoomInvite{}, newApiError(http.StatusNotFound, protocol.ApiInviteNotFound, "Invite not found")
+	}
+	invite.Link = w.inviteLink(invite.Code)
+	return *invite, nil
+}
+
+// revokeInvite revokes the current invite of the room owned by the user.
+func (w *Web) revokeInvite(ref protocol.RoomRef, userID protocol.UserID) *ApiError {
+	room, apiErr := w.findRoom(ref)
+	if apiErr != nil {
+		return apiErr
+	}
+	return ownerApiError(room.RevokeInvite(userID))
+}
+
+// joinRoom makes the user a member of the room by invite code.
+func (w *Web) joinRoom(ref protocol.RoomRef, userID protocol.UserID, code string) *ApiError {
+	room, apiErr := w.findRoom(ref)
+	if apiErr != nil {
+		return apiErr
+	}
+	return inviteApiError(room.Join(userID, code))
+}
+
+// resolveInvite finds the room the invite code was issued for.
+func (w *Web) resolveInvite(clientId protocol.ClientID, code string) (protocol.RoomRef, *ApiError) {
+	roomId, err := w.db.ResolveInvite(clientId, code)
+	if err != nil {
+		return protocol.RoomRef{}, internalApiError(err)
+	}
+	if roomId == "" {
+		return protocol.RoomRef{}, newApiError(http.StatusNotFound, protocol.ApiInviteNotFound, "Invite not found")
+	}
+	return protocol.RoomRef{ClientID: clientId, RoomID: roomId}, nil
+}
+
+// checkRoomAccess checks the user can hold the button in the room,
+// the invite code is redeemed if the user is not a member of the private room yet.
+// Holders of the private room must be verified by telegram init data.
+func (w *Web) checkRoomAccess(room *GameRoom, userID protocol.UserID, verified bool, code string) *ApiError {
+	meta, err := room.Meta()
+	if err != nil {
+		return internalApiError(fmt.Errorf("failed to get room metadata: %w", err))
+	}
+	if meta != nil && meta.Private && !verified {
+		return newApiError(http.StatusBadRequest, protocol.ApiInvalidInitData, "Telegram init data not provided")
+	}
+	access, err := room.HasAccess(userID, meta)
+	if err == nil && !access && code != "" {
+		return inviteApiError(room.Join(userID, code))
+	}
+	return roomAccessApiError(access, err)
+}
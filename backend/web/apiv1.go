@@ -0,0 +1,975 @@
+package web
+
+import (
+	"net/http"
+
+	"buttonmania.win/protocol"
+	"github.com/gin-gonic/gin"
+)
+
+// bindJSON decodes the JSON request body, the error envelope is written on failure.
+func bindJSON(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		writeJSONError(c, newApiError(http.StatusBadRequest, protocol.ApiInvalidRequest, err.Error()))
+		return false
+	}
+	return true
+}
+
+// pathRoomRef extracts the room reference from path parameters.
+func pathRoomRef(c *gin.Context) protocol.RoomRef {
+	return protocol.RoomRef{
+		ClientID: protocol.ClientID(c.Param("clientId")),
+		RoomID:   protocol.RoomID(c.Param("roomId")),
+	}
+}
+
+// ownerPathRequest extracts the room from path parameters and authenticates the room owner
+// by telegram init data.
+func (w *Web) ownerPathRequest(c *gin.Context, auth protocol.UserAuth) (*GameRoom, protocol.UserID, *ApiError) {
+	userID, apiErr := w.authVerifiedUser(auth)
+	if apiErr != nil {
+		return nil, "", apiErr
+	}
+	room, apiErr := w.findRoom(pathRoomRef(c))
+	return room, userID, apiErr
+}
+
+// writeNoContent writes the empty response of the successful action or the error envelope.
+func writeNoContent(c *gin.Context, apiErr *ApiError) {
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary	Create game room
+// @Accept		json
+// @Produce	json
+// @Param		body	body		protocol.CreateRoomRequest	true	"Room, owner and optional metadata, the room id is normalized to the slug"
+// @Success	201		{object}	protocol.RoomInfo
+// @Failure	400		{object}	protocol.ErrorResponse
+// @Failure	403		{object}	protocol.ErrorResponse
+// @Failure	409		{object}	protocol.ErrorResponse
+// @Failure	500		{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms [post]
+func (w *Web) createRoomV1Handler(c *gin.Context) {
+	var req protocol.CreateRoomRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	var info protocol.RoomInfo
+	ref := req.RoomRef
+	userID, apiErr := w.authVerifiedUser(req.UserAuth)
+	if apiErr == nil {
+		ref, apiErr = w.createRoom(req.RoomRef, userID, req.RoomMetaUpdate, req.Mode, req.Teams)
+	}
+	if apiErr == nil {
+		info, apiErr = w.roomInfo(ref, req.UserAuth)
+	}
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusCreated, info)
+}
+
+// @Summary	Search public custom rooms of the client by title
+// @Produce	json
+// @Param		clientId	query		string	true	"Client ID"
+// @Param		q			query		string	false	"Search in room titles"
+// @Param		sort		query		string	false	"Sort by active holders, total players or recent activity"	Enums(active, players, recent)
+// @Param		offset		query		int		false	"Count of rooms to skip"
+// @Param		limit		query		int		false	"Count of rooms in the page"
+// @Success	200			{object}	protocol.RoomList
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms [get]
+// @Router		/api/rooms [get]
+func (w *Web) listRoomsV1Handler(c *gin.Context) {
+	var list protocol.RoomList
+	sort, offset, limit, apiErr := parseRoomListPage(c)
+	if apiErr == nil {
+		list, apiErr = w.listRooms(protocol.ClientID(c.Query("clientId")), c.Query("q"), sort, offset, limit)
+	}
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// @Summary	Get room info with metadata and the state of the room event
+// @Produce	json
+// @Param		clientId	path		string	true	"Client ID"
+// @Param		roomId		path		string	true	"Room ID"
+// @Param		userId		query		string	false	"User ID of public rooms, private rooms require init data"
+// @Param		initData	query		string	false	"Telegram init data"
+// @Success	200			{object}	protocol.RoomInfo
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId} [get]
+func (w *Web) infoRoomV1Handler(c *gin.Context) {
+	info, apiErr := w.roomInfo(pathRoomRef(c), queryUserAuth(c))
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// @Summary	Edit room metadata, omitted fields are kept
+// @Accept		json
+// @Produce	json
+// @Param		clientId	path		string						true	"Client ID"
+// @Param		roomId		path		string						true	"Room ID"
+// @Param		body		body		protocol.UpdateRoomRequest	true	"Room owner and metadata changes"
+// @Success	200			{object}	protocol.RoomInfo
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId} [patch]
+func (w *Web) updateRoomV1Handler(c *gin.Context) {
+	var req protocol.UpdateRoomRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	var info protocol.RoomInfo
+	userID, apiErr := w.authVerifiedUser(req.UserAuth)
+	if apiErr == nil {
+		info, apiErr = w.updateRoom(pathRoomRef(c), userID, req.RoomMetaUpdate)
+	}
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// @Summary	Delete game room
+// @Accept		json
+// @Produce	json
+// @Param		clientId	path		string				true	"Client ID"
+// @Param		roomId		path		string				true	"Room ID"
+// @Param		body		body		protocol.UserAuth	true	"Room owner"
+// @Success	204
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId} [delete]
+func (w *Web) deleteRoomV1Handler(c *gin.Context) {
+	var req protocol.UserAuth
+	if !bindJSON(c, &req) {
+		return
+	}
+	userID, apiErr := w.authVerifiedUser(req)
+	if apiErr == nil {
+		apiErr = w.deleteRoom(pathRoomRef(c), userID)
+	}
+	writeNoContent(c, apiErr)
+}
+
+// @Summary	Get room stats
+// @Produce	json
+// @Param		clientId	path		string	true	"Client ID"
+// @Param		roomId		path		string	true	"Room ID"
+// @Param		userId		query		string	false	"User ID of public rooms, private rooms require init data"
+// @Param		initData	query		string	false	"Telegram init data"
+// @Success	200			{object}	protocol.GameRoomStats
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/stats [get]
+func (w *Web) statsRoomV1Handler(c *gin.Context) {
+	stats, apiErr := w.roomStats(pathRoomRef(c), queryUserAuth(c))
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// @Summary	Get room chat history
+// @Produce	json
+// @Param		clientId	path		string	true	"Client ID"
+// @Param		roomId		path		string	true	"Room ID"
+// @Param		userId		query		string	false	"User ID of public rooms, private rooms require init data"
+// @Param		initData	query		string	false	"Telegram init data"
+// @Param		before		query		int		false	"Return messages sent before the message with given id"
+// @Param		limit		query		int		false	"Count of messages in the page"
+// @Success	200			{object}	protocol.ChatHistory
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/chat [get]
+func (w *Web) chatRoomV1Handler(c *gin.Context) {
+	var history protocol.ChatHistory
+	before, limit, apiErr := parseChatPage(c)
+	if apiErr == nil {
+		history, apiErr = w.roomChat(pathRoomRef(c), queryUserAuth(c), before, limit)
+	}
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// @Summary	List users holding the button in the room
+// @Produce	json
+// @Param		clientId	path		string	true	"Client ID"
+// @Param		roomId		path		string	true	"Room ID"
+// @Param		initData	query		string	true	"Telegram init data"
+// @Success	200			{array}		protocol.RoomHolder
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/holders [get]
+func (w *Web) holdersRoomV1Handler(c *gin.Context) {
+	room, userID, apiErr := w.ownerPathRequest(c, queryUserAuth(c))
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	holders, err := room.Holders(userID)
+	if apiErr = ownerApiError(err); apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, holders)
+}
+
+// @Summary	Kick the holder from the room, the session ends without a record
+// @Accept		json
+// @Produce	json
+// @Param		clientId	path		string						true	"Client ID"
+// @Param		roomId		path		string						true	"Room ID"
+// @Param		body		body		protocol.TargetUserRequest	true	"Room owner or moderator and kicked user"
+// @Success	204
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/kicks [post]
+func (w *Web) kickRoomV1Handler(c *gin.Context) {
+	var req protocol.TargetUserRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	room, userID, apiErr := w.ownerPathRequest(c, req.UserAuth)
+	if apiErr == nil {
+		_, apiErr = checkTarget(req.TargetUserID, 0)
+	}
+	if apiErr == nil {
+		apiErr = ownerApiError(room.Kick(userID, req.TargetUserID))
+	}
+	writeNoContent(c, apiErr)
+}
+
+// @Summary	Ban the user from playing and chatting in the room
+// @Accept		json
+// @Produce	json
+// @Param		clientId	path		string						true	"Client ID"
+// @Param		roomId		path		string						true	"Room ID"
+// @Param		body		body		protocol.TargetUserRequest	true	"Room owner or moderator, banned user and ban duration in seconds, 0 bans forever"
+// @Success	204
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/bans [post]
+func (w *Web) banRoomV1Handler(c *gin.Context) {
+	var req protocol.TargetUserRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	room, userID, apiErr := w.ownerPathRequest(c, req.UserAuth)
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	duration, apiErr := checkTarget(req.TargetUserID, req.Duration)
+	if apiErr == nil {
+		apiErr = ownerApiError(room.Ban(userID, req.TargetUserID, duration))
+	}
+	writeNoContent(c, apiErr)
+}
+
+// @Summary	Lift the user's ban in the room
+// @Accept		json
+// @Produce	json
+// @Param		clientId	path		string				true	"Client ID"
+// @Param		roomId		path		string				true	"Room ID"
+// @Param		userId		path		string				true	"Unbanned user ID"
+// @Param		body		body		protocol.UserAuth	true	"Room owner or moderator"
+// @Success	204
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/bans/{userId} [delete]
+func (w *Web) unbanRoomV1Handler(c *gin.Context) {
+	var req protocol.UserAuth
+	if !bindJSON(c, &req) {
+		return
+	}
+	targetID := protocol.UserID(c.Param("userId"))
+	room, userID, apiErr := w.ownerPathRequest(c, req)
+	if apiErr == nil {
+		_, apiErr = checkTarget(targetID, 0)
+	}
+	if apiErr == nil {
+		apiErr = ownerApiError(room.Unban(userID, targetID))
+	}
+	writeNoContent(c, apiErr)
+}
+
+// @Summary	Mute the user in the room chat
+// @Accept		json
+// @Produce	json
+// @Param		clientId	path		string						true	"Client ID"
+// @Param		roomId		path		string						true	"Room ID"
+// @Param		body		body		protocol.TargetUserRequest	true	"Room owner or moderator, muted user and mute duration in seconds, 0 mutes forever"
+// @Success	204
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/mutes [post]
+func (w *Web) muteRoomV1Handler(c *gin.Context) {
+	var req protocol.TargetUserRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	room, userID, apiErr := w.ownerPathRequest(c, req.UserAuth)
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	duration, apiErr := checkTarget(req.TargetUserID, req.Duration)
+	if apiErr == nil {
+		apiErr = ownerApiError(room.Mute(userID, req.TargetUserID, duration))
+	}
+	writeNoContent(c, apiErr)
+}
+
+// @Summary	Lift the user's mute in the room chat
+// @Accept		json
+// @Produce	json
+// @Param		clientId	path		string				true	"Client ID"
+// @Param		roomId		path		string				true	"Room ID"
+// @Param		userId		path		string				true	"Unmuted user ID"
+// @Param		body		body		protocol.UserAuth	true	"Room owner or moderator"
+// @Success	204
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/mutes/{userId} [delete]
+func (w *Web) unmuteRoomV1Handler(c *gin.Context) {
+	var req protocol.UserAuth
+	if !bindJSON(c, &req) {
+		return
+	}
+	targetID := protocol.UserID(c.Param("userId"))
+	room, userID, apiErr := w.ownerPathRequest(c, req)
+	if apiErr == nil {
+		_, apiErr = checkTarget(targetID, 0)
+	}
+	if apiErr == nil {
+		apiErr = ownerApiError(room.Unmute(userID, targetID))
+	}
+	writeNoContent(c, apiErr)
+}
+
+// @Summary	List moderators of the room
+// @Produce	json
+// @Param		clientId	path		string	true	"Client ID"
+// @Param		roomId		path		string	true	"Room ID"
+// @Param		initData	query		string	true	"Telegram init data"
+// @Success	200			{array}		protocol.UserProfile
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/moderators [get]
+func (w *Web) moderatorsRoomV1Handler(c *gin.Context) {
+	room, userID, apiErr := w.ownerPathRequest(c, queryUserAuth(c))
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	moderators, err := room.Moderators(userID)
+	if apiErr = ownerApiError(err); apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, moderators)
+}
+
+// @Summary	List the latest webhook deliveries of the room events
+// @Produce	json
+// @Param		clientId	path		string	true	"Client ID"
+// @Param		roomId		path		string	true	"Room ID"
+// @Param		initData	query		string	true	"Telegram init data"
+// @Success	200			{array}		protocol.WebhookDelivery
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/webhooks/deliveries [get]
+func (w *Web) webhookDeliveriesRoomV1Handler(c *gin.Context) {
+	room, userID, apiErr := w.ownerPathRequest(c, queryUserAuth(c))
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	deliveries, err := room.WebhookDeliveries(userID)
+	if apiErr = ownerApiError(err); apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// @Summary	Grant the user the moderator role in the room
+// @Accept		json
+// @Produce	json
+// @Param		clientId	path		string						true	"Client ID"
+// @Param		roomId		path		string						true	"Room ID"
+// @Param		body		body		protocol.TargetUserRequest	true	"Init data of the room owner and new moderator"
+// @Success	204
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/moderators [post]
+func (w *Web) addModeratorRoomV1Handler(c *gin.Context) {
+	var req protocol.TargetUserRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	room, userID, apiErr := w.ownerPathRequest(c, req.UserAuth)
+	if apiErr == nil {
+		_, apiErr = checkTarget(req.TargetUserID, 0)
+	}
+	if apiErr == nil {
+		apiErr = ownerApiError(room.AddModerator(userID, req.TargetUserID))
+	}
+	writeNoContent(c, apiErr)
+}
+
+// @Summary	Revoke the moderator role of the user in the room
+// @Accept		json
+// @Produce	json
+// @Param		clientId	path		string				true	"Client ID"
+// @Param		roomId		path		string				true	"Room ID"
+// @Param		userId		path		string				true	"Moderator user ID"
+// @Param		body		body		protocol.UserAuth	true	"Init data of the room owner"
+// @Success	204
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/moderators/{userId} [delete]
+func (w *Web) removeModeratorRoomV1Handler(c *gin.Context) {
+	var req protocol.UserAuth
+	if !bindJSON(c, &req) {
+		return
+	}
+	targetID := protocol.UserID(c.Param("userId"))
+	room, userID, apiErr := w.ownerPathRequest(c, req)
+	if apiErr == nil {
+		_, apiErr = checkTarget(targetID, 0)
+	}
+	if apiErr == nil {
+		apiErr = ownerApiError(room.RemoveModerator(userID, targetID))
+	}
+	writeNoContent(c, apiErr)
+}
+
+// @Summary	Transfer the room to its moderator, the previous owner becomes a moderator
+// @Accept		json
+// @Produce	json
+// @Param		clientId	path		string						true	"Client ID"
+// @Param		roomId		path		string						true	"Room ID"
+// @Param		body		body		protocol.TargetUserRequest	true	"Init data of the room owner and new owner"
+// @Success	204
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/owner [put]
+func (w *Web) transferRoomV1Handler(c *gin.Context) {
+	var req protocol.TargetUserRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	room, userID, apiErr := w.ownerPathRequest(c, req.UserAuth)
+	if apiErr == nil {
+		_, apiErr = checkTarget(req.TargetUserID, 0)
+	}
+	if apiErr == nil {
+		maxRooms := w.maxRoomsPerUser(room.ClientID)
+		apiErr = ownerApiError(room.Transfer(userID, req.TargetUserID, maxRooms))
+	}
+	writeNoContent(c, apiErr)
+}
+
+// @Summary	Get live standings and the leaderboard of the room teams
+// @Produce	json
+// @Param		clientId	path		string	true	"Client ID"
+// @Param		roomId		path		string	true	"Room ID"
+// @Param		userId		query		string	false	"User ID of public rooms, private rooms require init data"
+// @Param		initData	query		string	false	"Telegram init data"
+// @Success	200			{object}	protocol.TeamStats
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/teams [get]
+func (w *Web) teamsRoomV1Handler(c *gin.Context) {
+	stats, apiErr := w.teamStats(pathRoomRef(c), queryUserAuth(c))
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// @Summary	Get the live chain and the longest chains of the room
+// @Produce	json
+// @Param		clientId	path		string	true	"Client ID"
+// @Param		roomId		path		string	true	"Room ID"
+// @Param		userId		query		string	false	"User ID of public rooms, private rooms require init data"
+// @Param		initData	query		string	false	"Telegram init data"
+// @Success	200			{object}	protocol.ChainStats
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/chains [get]
+func (w *Web) chainsRoomV1Handler(c *gin.Context) {
+	stats, apiErr := w.chainStats(pathRoomRef(c), queryUserAuth(c))
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// @Summary	Get the best holds of the latest event of the room, the upcoming event has no standings
+// @Produce	json
+// @Param		clientId	path		string	true	"Client ID"
+// @Param		roomId		path		string	true	"Room ID"
+// @Param		userId		query		string	false	"User ID of public rooms, private rooms require init data"
+// @Param		initData	query		string	false	"Telegram init data"
+// @Success	200			{object}	protocol.EventResult
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/event [get]
+func (w *Web) eventRoomV1Handler(c *gin.Context) {
+	result, apiErr := w.eventResult(pathRoomRef(c), queryUserAuth(c))
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// @Summary	Schedule the last holder standing tournament of the room, players register until it starts
+// @Accept		json
+// @Produce	json
+// @Param		clientId	path		string								true	"Client ID"
+// @Param		roomId		path		string								true	"Room ID"
+// @Param		body		body		protocol.ScheduleTournamentRequest	true	"Room owner, start time in unix seconds and grace period in seconds"
+// @Success	201			{object}	protocol.Tournament
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/tournament [post]
+func (w *Web) scheduleTournamentV1Handler(c *gin.Context) {
+	var req protocol.ScheduleTournamentRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	var tournament *protocol.Tournament
+	userID, apiErr := w.authVerifiedUser(req.UserAuth)
+	if apiErr == nil {
+		tournament, apiErr = w.scheduleTournament(pathRoomRef(c), userID, req.Starts, req.Grace)
+	}
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusCreated, tournament)
+}
+
+// @Summary	Get the last tournament of the room
+// @Produce	json
+// @Param		clientId	path		string	true	"Client ID"
+// @Param		roomId		path		string	true	"Room ID"
+// @Param		userId		query		string	false	"User ID of public rooms, private rooms require init data"
+// @Param		initData	query		string	false	"Telegram init data"
+// @Success	200			{object}	protocol.Tournament
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/tournament [get]
+func (w *Web) tournamentRoomV1Handler(c *gin.Context) {
+	tournament, apiErr := w.roomTournament(pathRoomRef(c), queryUserAuth(c))
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, tournament)
+}
+
+// @Summary	Register the user in the scheduled tournament of the room
+// @Accept		json
+// @Produce	json
+// @Param		clientId	path		string				true	"Client ID"
+// @Param		roomId		path		string				true	"Room ID"
+// @Param		body		body		protocol.UserAuth	true	"Registered user"
+// @Success	204
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/tournament/players [post]
+func (w *Web) registerTournamentV1Handler(c *gin.Context) {
+	var req protocol.UserAuth
+	if !bindJSON(c, &req) {
+		return
+	}
+	userID, apiErr := w.authUser(req)
+	if apiErr == nil {
+		apiErr = w.registerTournament(pathRoomRef(c), userID, len(req.InitData) > 0)
+	}
+	writeNoContent(c, apiErr)
+}
+
+// @Summary	Get final standings of the latest tournaments of the room, the latest first
+// @Produce	json
+// @Param		clientId	path		string	true	"Client ID"
+// @Param		roomId		path		string	true	"Room ID"
+// @Param		userId		query		string	false	"User ID of public rooms, private rooms require init data"
+// @Param		initData	query		string	false	"Telegram init data"
+// @Success	200			{array}		protocol.TournamentResult
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/tournaments [get]
+func (w *Web) tournamentsRoomV1Handler(c *gin.Context) {
+	results, apiErr := w.tournamentResults(pathRoomRef(c), queryUserAuth(c))
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// @Summary	Issue a new invite code of the room, the previous code stops working
+// @Accept		json
+// @Produce	json
+// @Param		clientId	path		string							true	"Client ID"
+// @Param		roomId		path		string							true	"Room ID"
+// @Param		body		body		protocol.CreateInviteRequest	true	"Room owner or moderator, invite ttl in seconds and max uses, 0 means unlimited"
+// @Success	201			{object}	protocol.RoomInvite
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/invites [post]
+func (w *Web) createInviteV1Handler(c *gin.Context) {
+	var req protocol.CreateInviteRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	var invite protocol.RoomInvite
+	userID, apiErr := w.authVerifiedUser(req.UserAuth)
+	if apiErr == nil {
+		invite, apiErr = w.createInvite(pathRoomRef(c), userID, req.TTL, req.MaxUses)
+	}
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusCreated, invite)
+}
+
+// @Summary	Get the current invite code of the room
+// @Produce	json
+// @Param		clientId	path		string	true	"Client ID"
+// @Param		roomId		path		string	true	"Room ID"
+// @Param		initData	query		string	true	"Telegram init data"
+// @Success	200			{object}	protocol.RoomInvite
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/invites [get]
+func (w *Web) inviteRoomV1Handler(c *gin.Context) {
+	var invite protocol.RoomInvite
+	userID, apiErr := w.authVerifiedUser(queryUserAuth(c))
+	if apiErr == nil {
+		invite, apiErr = w.roomInvite(pathRoomRef(c), userID)
+	}
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, invite)
+}
+
+// @Summary	Revoke the current invite code of the room, joined members keep access
+// @Accept		json
+// @Produce	json
+// @Param		clientId	path		string				true	"Client ID"
+// @Param		roomId		path		string				true	"Room ID"
+// @Param		body		body		protocol.UserAuth	true	"Room owner or moderator"
+// @Success	204
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/invites [delete]
+func (w *Web) revokeInviteV1Handler(c *gin.Context) {
+	var req protocol.UserAuth
+	if !bindJSON(c, &req) {
+		return
+	}
+	userID, apiErr := w.authVerifiedUser(req)
+	if apiErr == nil {
+		apiErr = w.revokeInvite(pathRoomRef(c), userID)
+	}
+	writeNoContent(c, apiErr)
+}
+
+// @Summary	Join the private room by invite code
+// @Accept		json
+// @Produce	json
+// @Param		clientId	path		string						true	"Client ID"
+// @Param		roomId		path		string						true	"Room ID"
+// @Param		body		body		protocol.JoinRoomRequest	true	"User and invite code"
+// @Success	204
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/members [post]
+func (w *Web) joinRoomV1Handler(c *gin.Context) {
+	var req protocol.JoinRoomRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	userID, apiErr := w.authVerifiedUser(req.UserAuth)
+	if apiErr == nil {
+		apiErr = w.joinRoom(pathRoomRef(c), userID, req.Code)
+	}
+	writeNoContent(c, apiErr)
+}
+
+// @Summary	Find the room the invite code was issued for
+// @Produce	json
+// @Param		clientId	path		string	true	"Client ID"
+// @Param		code		path		string	true	"Invite code"
+// @Success	200			{object}	protocol.RoomRef
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/clients/{clientId}/invites/{code} [get]
+func (w *Web) resolveInviteV1Handler(c *gin.Context) {
+	ref, apiErr := w.resolveInvite(protocol.ClientID(c.Param("clientId")), c.Param("code"))
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, ref)
+}
+
+// @Summary	Get motivational message pack of the room
+// @Produce	json
+// @Param		clientId	path		string	true	"Client ID"
+// @Param		roomId		path		string	true	"Room ID"
+// @Param		userId		query		string	false	"User ID of public rooms, private rooms require init data"
+// @Param		initData	query		string	false	"Telegram init data"
+// @Success	200			{object}	protocol.MessagePack
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/messages [get]
+func (w *Web) messagesRoomV1Handler(c *gin.Context) {
+	pack, apiErr := w.roomMessages(pathRoomRef(c), queryUserAuth(c))
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, pack)
+}
+
+// @Summary	Upload motivational message pack of the room, locales missing in the pack are removed
+// @Accept		json
+// @Produce	json
+// @Param		clientId	path		string						true	"Client ID"
+// @Param		roomId		path		string						true	"Room ID"
+// @Param		body		body		protocol.MessagePackRequest	true	"Room owner and messages by locale"
+// @Success	200			{object}	protocol.MessagePack
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/messages [put]
+func (w *Web) setMessagesRoomV1Handler(c *gin.Context) {
+	// Request body is limited along with the pack itself, json adds quoting and escaping
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, 2*maxRoomMessagesLength)
+	var req protocol.MessagePackRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	var pack protocol.MessagePack
+	userID, apiErr := w.authVerifiedUser(req.UserAuth)
+	if apiErr == nil {
+		pack, apiErr = w.setRoomMessages(pathRoomRef(c), userID, req.Messages)
+	}
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, pack)
+}
+
+// @Summary	Remove motivational message pack of the room
+// @Accept		json
+// @Produce	json
+// @Param		clientId	path		string				true	"Client ID"
+// @Param		roomId		path		string				true	"Room ID"
+// @Param		body		body		protocol.UserAuth	true	"Room owner"
+// @Success	204
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	403			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/rooms/{clientId}/{roomId}/messages [delete]
+func (w *Web) removeMessagesRoomV1Handler(c *gin.Context) {
+	var req protocol.UserAuth
+	if !bindJSON(c, &req) {
+		return
+	}
+	userID, apiErr := w.authVerifiedUser(req)
+	if apiErr == nil {
+		apiErr = w.removeRoomMessages(pathRoomRef(c), userID)
+	}
+	writeNoContent(c, apiErr)
+}
+
+// @Summary	Get client stats
+// @Produce	json
+// @Param		clientId	path		string	true	"Client ID"
+// @Success	200			{object}	protocol.ClientStats
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/clients/{clientId}/stats [get]
+func (w *Web) statsClientV1Handler(c *gin.Context) {
+	stats, apiErr := w.clientStats(protocol.ClientID(c.Param("clientId")))
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// @Summary	List public archived rooms of the client
+// @Produce	json
+// @Param		clientId	path		string	true	"Client ID"
+// @Param		owner		query		string	false	"Owner user ID"
+// @Success	200			{array}		protocol.ArchivedRoom
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/clients/{clientId}/archive [get]
+func (w *Web) archiveClientV1Handler(c *gin.Context) {
+	rooms, apiErr := w.archivedRooms(protocol.ClientID(c.Param("clientId")), protocol.UserID(c.Query("owner")))
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, rooms)
+}
+
+// @Summary	Get the public archived room with its leaderboard
+// @Produce	json
+// @Param		clientId	path		string	true	"Client ID"
+// @Param		roomId		path		string	true	"Room ID"
+// @Success	200			{object}	protocol.ArchivedRoom
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	404			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/clients/{clientId}/archive/{roomId} [get]
+func (w *Web) archivedRoomV1Handler(c *gin.Context) {
+	room, apiErr := w.archivedRoom(pathRoomRef(c))
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, room)
+}
+
+// @Summary	Report user's chat messages
+// @Accept		json
+// @Produce	json
+// @Param		clientId	path		string						true	"Client ID"
+// @Param		body		body		protocol.ChatReportRequest	true	"Reporter and reported user"
+// @Success	200			{object}	protocol.ChatReportResponse
+// @Failure	400			{object}	protocol.ErrorResponse
+// @Failure	500			{object}	protocol.ErrorResponse
+// @Router		/api/v1/clients/{clientId}/chat/reports [post]
+func (w *Web) reportChatV1Handler(c *gin.Context) {
+	var req protocol.ChatReportRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	var muted bool
+	userID, apiErr := w.authVerifiedUser(req.UserAuth)
+	if apiErr == nil {
+		muted, apiErr = w.reportChat(protocol.ClientID(c.Param("clientId")), userID, req.ReportedUserID)
+	}
+	if apiErr != nil {
+		writeJSONError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, protocol.ChatReportResponse{Muted: muted})
+}
+
+// @Summary	Set user's profile privacy
+// @Accept		json
+// @Produce	json
+// @Param		body	body	protocol.PrivacyRequest	true	"User and privacy setting: public, name or hidden"
+// @Success	204
+// @Failure	400		{object}	protocol.ErrorResponse
+// @Failure	500		{object}	protocol.ErrorResponse
+// @Router		/api/v1/users/privacy [put]
+func (w *Web) privacyUserV1Handler(c *gin.Context) {
+	var req protocol.PrivacyRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	userID, apiErr := w.authVerifiedUser(req.UserAuth)
+	if apiErr == nil {
+		apiErr = w.setPrivacy(userID, string(req.Privacy))
+	}
+	writeNoContent(c, apiErr)
+}
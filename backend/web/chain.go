@@ -0,0 +1,112 @@
+package web
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"buttonmania.win/protocol"
+)
+
+// Define chain parameters
+const (
+	chainStateRefresh = time.Second
+	chainRecordsLimit = 10
+)
+
+// Define chain errors
+var ErrRoomNotChain = errors.New("room is not in the chain mode")
+
+// Chain tracks the collective hold of the room, the chain lasts while anyone is holding.
+// The live chain is shared by holders of the room and refreshed at most once per second.
+type Chain struct {
+	classicMode
+	mu      sync.Mutex
+	state   *protocol.ChainState
+	updated time.Time
+}
+
+// NewChain creates a new Chain instance.
+func NewChain(room *GameRoom) *Chain {
+	return &Chain{
+		classicMode: classicMode{room: room},
+	}
+}
+
+// Name returns the name of the mode.
+func (c *Chain) Name() protocol.RoomMode {
+	return protocol.ModeChain
+}
+
+// OnJoin adds the holder to the chain.
+func (c *Chain) OnJoin(session *GameSession) error {
+	return c.join(session.userID)
+}
+
+// OnUpdate sends the live chain, stale chain is still worth showing.
+func (c *Chain) OnUpdate(session *GameSession, update *protocol.GameplayMessage) {
+	update.Chain, _ = c.State()
+}
+
+// OnRelease removes the holder from the chain and sends the record.
+func (c *Chain) OnRelease(
+	session *GameSession,
+	record *protocol.GameplayRecord,
+) (*protocol.GameplayMessage, error) {
+	if err := c.leave(session.userID); err != nil {
+		return nil, err
+	}
+	return c.classicMode.OnRelease(session, record)
+}
+
+// OnReaped removes the holder from the chain at the end of the reaped hold and scores the hold.
+func (c *Chain) OnReaped(
+	userID protocol.UserID,
+	record *protocol.GameplayRecord,
+	end int64,
+) (bool, error) {
+	if _, err := c.room.DB.LeaveChain(c.room.ClientID, c.room.RoomID, userID, end); err != nil {
+		return false, err
+	}
+	return c.classicMode.OnReaped(userID, record, end)
+}
+
+// State returns the live chain of the room, nil if nobody is holding.
+func (c *Chain) State() (*protocol.ChainState, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.updated) < chainStateRefresh {
+		return c.state, nil
+	}
+	state, err := c.room.DB.GetChain(c.room.ClientID, c.room.RoomID, time.Now().Unix())
+	if err != nil {
+		return c.state, err
+	}
+	c.state = state
+	c.updated = time.Now()
+	return state, nil
+}
+
+// join adds the holder to the chain.
+func (c *Chain) join(userID protocol.UserID) error {
+	return c.room.DB.JoinChain(c.room.ClientID, c.room.RoomID, userID, time.Now().Unix())
+}
+
+// leave removes the holder from the chain, the last holder breaks the chain and its record is stored.
+func (c *Chain) leave(userID protocol.UserID) error {
+	_, err := c.room.DB.LeaveChain(c.room.ClientID, c.room.RoomID, userID, time.Now().Unix())
+	return err
+}
+
+// ChainStats returns the live chain and the longest chains of the room.
+func (r *GameRoom) ChainStats() (protocol.ChainStats, error) {
+	if _, ok := r.mode.(*Chain); !ok {
+		return protocol.ChainStats{}, ErrRoomNotChain
+	}
+	current, currentErr := r.DB.GetChain(r.ClientID, r.RoomID, time.Now().Unix())
+	records, recordsErr := r.DB.GetChainRecords(r.ClientID, r.RoomID, chainRecordsLimit)
+	return protocol.ChainStats{
+		Current: current,
+		Records: records,
+	}, errors.Join(currentErr, recordsErr)
+}
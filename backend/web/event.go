@@ -0,0 +1,167 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"buttonmania.win/conf"
+	"buttonmania.win/protocol"
+)
+
+// Define the count of event results
+const eventResultsLimit = 100
+
+// Define event errors
+var (
+	ErrRoomNotEvent     = errors.New("room is not an event room")
+	ErrEventNotOpen     = errors.New("event is not open")
+	ErrInvalidEventConf = errors.New("invalid event window")
+)
+
+// EventWindow defines the time the event room is open.
+type EventWindow struct {
+	Opens  time.Time
+	Closes time.Time
+}
+
+// Event keeps the open windows of the time-boxed event room, the room is closed between windows.
+type Event struct {
+	windows []EventWindow
+}
+
+// NewEvent creates a new Event instance from the configured windows,
+// windows must not be empty or overlap.
+func NewEvent(confs []conf.EventConf) (*Event, error) {
+	if len(confs) == 0 {
+		return nil, fmt.Errorf("%w: no windows", ErrInvalidEventConf)
+	}
+	windows := make([]EventWindow, 0, len(confs))
+	for _, c := range confs {
+		opens, opensErr := time.Parse(time.RFC3339, c.Opens)
+		closes, closesErr := time.Parse(time.RFC3339, c.Closes)
+		if err := errors.Join(opensErr, closesErr); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidEventConf, err)
+		}
+		if !opens.Before(closes) {
+			return nil, fmt.Errorf("%w: %s closes before it opens", ErrInvalidEventConf, c.Opens)
+		}
+		windows = append(windows, EventWindow{Opens: opens, Closes: closes})
+	}
+	slices.SortFunc(windows, func(a, b EventWindow) int {
+		return a.Opens.Compare(b.Opens)
+	})
+	for i := 1; i < len(windows); i++ {
+		if windows[i].Opens.Before(windows[i-1].Closes) {
+			return nil, fmt.Errorf("%w: %s overlaps the previous window", ErrInvalidEventConf, windows[i].Opens.Format(time.RFC3339))
+		}
+	}
+	return &Event{windows: windows}, nil
+}
+
+// State returns the open or the next window of the event, the last window once every window is over.
+func (e *Event) State(now time.Time) protocol.Event {
+	for _, window := range e.windows {
+		if now.Before(window.Closes) {
+			return window.state(now)
+		}
+	}
+	return e.windows[len(e.windows)-1].state(now)
+}
+
+// Open checks if the event is open.
+func (e *Event) Open(now time.Time) bool {
+	return e.State(now).Status == protocol.EventOpen
+}
+
+// latest returns the last window opened by now, the first window if none has opened yet.
+func (e *Event) latest(now time.Time) EventWindow {
+	latest := e.windows[0]
+	for _, window := range e.windows {
+		if now.Before(window.Opens) {
+			break
+		}
+		latest = window
+	}
+	return latest
+}
+
+// state returns the status of the window and the seconds until its next change.
+func (w EventWindow) state(now time.Time) protocol.Event {
+	event := protocol.Event{
+		Opens:  w.Opens.Unix(),
+		Closes: w.Closes.Unix(),
+	}
+	switch {
+	case now.Before(w.Opens):
+		event.Status = protocol.EventUpcoming
+		event.Remaining = event.Opens - now.Unix()
+	case now.Before(w.Closes):
+		event.Status = protocol.EventOpen
+		event.Remaining = event.Closes - now.Unix()
+	default:
+		event.Status = protocol.EventClosed
+	}
+	return event
+}
+
+// SetEvent makes the room open only within windows of the event.
+func (r *GameRoom) SetEvent(event *Event) {
+	r.event.Store(event)
+}
+
+// Event returns the state of the room event, nil if the room is always open.
+func (r *GameRoom) Event() *protocol.Event {
+	event := r.event.Load()
+	if event == nil {
+		return nil
+	}
+	state := event.State(time.Now())
+	return &state
+}
+
+// finishEvent ends active sessions once the event is closed, holds are recorded as if released.
+func (r *GameRoom) finishEvent(now time.Time) {
+	event := r.event.Load()
+	if event == nil || event.Open(now) {
+		return
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, session := range r.sessions {
+		session.finish()
+	}
+}
+
+// EventResult returns the best holds of the latest event of the room with public profiles.
+func (r *GameRoom) EventResult(count int64) (protocol.EventResult, error) {
+	event := r.event.Load()
+	if event == nil {
+		return protocol.EventResult{}, ErrRoomNotEvent
+	}
+	now := time.Now()
+	window := event.latest(now)
+	result := protocol.EventResult{
+		Event:     window.state(now),
+		Standings: []protocol.LeaderboardEntry{},
+	}
+	if result.Status == protocol.EventUpcoming {
+		return result, nil
+	}
+	standings, err := r.DB.GetEventLeaderboard(
+		r.ClientID,
+		r.RoomID,
+		window.Opens,
+		window.Closes,
+		count,
+	)
+	if err != nil {
+		return result, err
+	}
+	for i := range standings {
+		standings[i].User = standings[i].User.Public()
+	}
+	result.Standings = standings
+	return result, nil
+}
@@ -2,10 +2,8 @@ package web
 
 import (
 	"errors"
-	"fmt"
 	"log"
 	"net/http"
-	"slices"
 	"strconv"
 	"time"
 
@@ -19,6 +17,9 @@ import (
 
 const (
 	userPayloadCountInStats = 3
+	usersCountInStats       = 10
+	defaultChatHistoryLimit = 50
+	maxChatHistoryLimit     = 100
 )
 
 // parseTgInitData parse string to telegram InitData structure
@@ -39,13 +40,38 @@ func (w *Web) parseTgInitData(initDataStr string) (*initdata.InitData, error) {
 	return initData, err
 }
 
+// userProfile stores the profile from telegram init data if provided,
+// otherwise retrieves the profile stored before.
+func (w *Web) userProfile(
+	userID protocol.UserID,
+	initData *initdata.InitData,
+) (protocol.UserProfile, error) {
+	if initData != nil {
+		return w.db.UpdateUserProfile(protocol.UserProfile{
+			UserID:   userID,
+			Name:     initData.User.FirstName,
+			Username: initData.User.Username,
+			PhotoURL: initData.User.PhotoURL,
+			Premium:  initData.User.IsPremium,
+			Language: initData.User.LanguageCode,
+		})
+	}
+	profiles, err := w.db.GetUserProfiles([]protocol.UserID{userID})
+	if err != nil || len(profiles) == 0 {
+		return protocol.UserProfile{UserID: userID}, err
+	}
+	return profiles[0], nil
+}
+
 // @Summary	Handles WebSocket connections
 // @Param		clientId	query	string	true	"Client ID"
 // @Param		roomId		query	string	true	"Room ID"
 // @Param		userId		query	string	false	"User ID"
 // @Param		locale		query	string	false	"User locale"
 // @Param		payload		query	string	false	"User payload"
-// @Param		initData	query	string	false	"Telegram init data"
+// @Param		initData	query	string	false	"Telegram init data, required in private rooms"
+// @Param		invite		query	string	false	"Invite code of the private room"
+// @Param		team		query	string	false	"Team of the player, required in team rooms"
 // @Router		/ws [get]
 func (w *Web) wsHandler(c *gin.Context) {
 	clientIdStr := c.Query("clientId")
@@ -54,10 +80,12 @@ func (w *Web) wsHandler(c *gin.Context) {
 	localeStr := c.Query("locale")
 	payloadStr := c.Query("payload")
 	initDataStr := c.Query("initData")
+	var initData *initdata.InitData
 
 	// Extract parameters from telegram init data
 	if len(initDataStr) > 0 {
-		initData, err := w.parseTgInitData(initDataStr)
+		var err error
+		initData, err = w.parseTgInitData(initDataStr)
 		if err != nil {
 			http.Error(c.Writer, err.Error(), http.StatusBadRequest)
 			return
@@ -85,8 +113,11 @@ func (w *Web) wsHandler(c *gin.Context) {
 	roomKey := protocol.RoomKey(tuple.New2(clientId, roomId))
 
 	// Search for room in map
-	room, exists := w.rooms[roomKey]
-	if !exists {
+	room, err := w.lookupRoom(roomKey)
+	if err != nil {
+		writePlainError(c, internalApiError(err))
+		return
+	} else if room == nil {
 		http.Error(
 			c.Writer,
 			"Room not found",
@@ -95,6 +126,25 @@ func (w *Web) wsHandler(c *gin.Context) {
 		return
 	}
 
+	// Check access to private room, the invite code is redeemed if provided
+	if apiErr := w.checkRoomAccess(room, userID, initData != nil, c.Query("invite")); apiErr != nil {
+		writePlainError(c, apiErr)
+		return
+	}
+
+	// Check the team of the team room
+	team := c.Query("team")
+	if teams, ok := room.mode.(*Teams); ok && !teams.Has(team) {
+		writePlainError(c, newApiError(http.StatusBadRequest, protocol.ApiTeamNotFound, "Team not found"))
+		return
+	}
+
+	// Refresh user profile, missing profile doesn't prevent the game
+	profile, err := w.userProfile(userID, initData)
+	if err != nil {
+		log.Println("Failed to update user profile:", err)
+	}
+
 	ws, err := w.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Println("Failed to create WebSocket connection", err)
@@ -103,7 +153,7 @@ func (w *Web) wsHandler(c *gin.Context) {
 	}
 	defer ws.Close()
 
-	if err := room.MaintainGameSession(userID, payload, locale, ws); err != nil {
+	if err := room.MaintainGameSession(userID, payload, locale, profile, team, ws); err != nil {
 		log.Println("Error occurred while maintaining the game session:", err)
 		return
 	}
@@ -113,96 +163,35 @@ func (w *Web) wsHandler(c *gin.Context) {
 // @Produce	json
 // @Param		clientId	query	string	true	"Client ID"
 // @Param		roomId		query	string	true	"Room ID"
-// @Param		userId		query	string	false	"User ID"
-// @Param		initData	query	string	false	"Telegram init data"
+// @Param		initData	query	string	true	"Telegram init data"
 // @Success	200			"ok"
-// @Failure	400			"User id not provided"
+// @Failure	400			"Telegram init data not provided"
 // @Failure	400			"Room id not provided"
 // @Failure	400			"Room id is too long"
+// @Failure	400			"Room id is invalid"
 // @Failure	400			"Client not allowed"
 // @Failure	400			"Room exists"
+// @Failure	403			"Too many rooms owned by the user"
+// @Deprecated
 // @Router		/api/room/create [get]
 func (w *Web) createRoomHandler(c *gin.Context) {
-	clientIdStr := c.Query("clientId")
-	roomIdStr := c.Query("roomId")
-	userIdStr := c.Query("userId")
-	initDataStr := c.Query("initData")
-
-	// Extract user id from telegram init data
-	if len(initDataStr) > 0 {
-		initData, err := w.parseTgInitData(initDataStr)
-		if err != nil {
-			http.Error(c.Writer, err.Error(), http.StatusBadRequest)
-			return
-		}
-		userIdStr = strconv.FormatInt(initData.User.ID, 10)
+	// Clients of the deprecated route keep the requested id, so it must be a slug already
+	ref := queryRoomRef(c)
+	userID, apiErr := w.authVerifiedUser(queryUserAuth(c))
+	if slug, ok := slugRoomId(ref.RoomID); apiErr == nil && ref.RoomID != "" && (!ok || slug != ref.RoomID) {
+		apiErr = newApiError(http.StatusBadRequest, protocol.ApiRoomIdInvalid, "Room id is invalid")
 	}
-
-	// Check userId
-	if len(userIdStr) == 0 {
-		http.Error(
-			c.Writer,
-			"User id not provided",
-			http.StatusNotFound,
-		)
-		return
+	if apiErr == nil {
+		_, apiErr = w.createRoom(ref, userID, protocol.RoomMetaUpdate{}, "", nil)
 	}
-
-	// Check room id
-	if roomIdStr == "" {
-		http.Error(
-			c.Writer,
-			"Room id not provided",
-			http.StatusBadRequest,
-		)
-		return
-	} else if len(roomIdStr) > 36 {
-		http.Error(
-			c.Writer,
-			"Room id is too long",
-			http.StatusBadRequest,
-		)
-		return
+	// The deprecated route has always answered taken ids with 400
+	if apiErr != nil && apiErr.Status == http.StatusConflict {
+		apiErr.Status = http.StatusBadRequest
 	}
-
-	userID := protocol.UserID(userIdStr)
-	clientId := protocol.ClientID(clientIdStr)
-	roomId := protocol.RoomID(roomIdStr)
-	// Check if client allowed
-	if !slices.Contains(w.clients, clientId) {
-		http.Error(
-			c.Writer,
-			"Client not allowed",
-			http.StatusBadRequest,
-		)
+	if apiErr != nil {
+		writePlainError(c, apiErr)
 		return
 	}
-
-	// Check if the room is already created
-	roomKey := protocol.RoomKey(tuple.New2(clientId, roomId))
-	_, exists := w.rooms[roomKey]
-	if exists {
-		http.Error(
-			c.Writer,
-			"Room exists",
-			http.StatusBadRequest,
-		)
-		return
-	}
-
-	// Create new record in db
-	err := w.db.AddCustomGameRoom(clientId, roomId, userID)
-	if err != nil {
-		http.Error(
-			c.Writer,
-			err.Error(),
-			http.StatusInternalServerError,
-		)
-		return
-	}
-
-	// Create room and add to map
-	w.rooms[roomKey], _ = NewGameRoom(clientId, roomId, w.db, nil)
 	c.String(http.StatusOK, "ok")
 }
 
@@ -210,105 +199,25 @@ func (w *Web) createRoomHandler(c *gin.Context) {
 // @Produce	json
 // @Param		clientId	query	string	true	"Client ID"
 // @Param		roomId		query	string	true	"Room ID"
-// @Param		userId		query	string	false	"User ID"
-// @Param		initData	query	string	false	"Telegram init data"
+// @Param		initData	query	string	true	"Telegram init data"
 // @Success	200			"ok"
-// @Failure	400			"User id not provided"
+// @Failure	400			"Telegram init data not provided"
 // @Failure	400			"Room id not provided"
 // @Failure	400			"Room id is too long"
 // @Failure	400			"Room cannot be deleted"
+// @Failure	403			"Room does not belong to the user"
 // @Failure	404			"Room not found"
+// @Deprecated
 // @Router		/api/room/delete [get]
 func (w *Web) deleteRoomHandler(c *gin.Context) {
-	clientIdStr := c.Query("clientId")
-	roomIdStr := c.Query("roomId")
-	userIdStr := c.Query("userId")
-	initDataStr := c.Query("initData")
-
-	// Extract user id from telegram init data
-	if len(initDataStr) > 0 {
-		initData, err := w.parseTgInitData(initDataStr)
-		if err != nil {
-			http.Error(
-				c.Writer,
-				err.Error(),
-				http.StatusBadRequest,
-			)
-			return
-		}
-		userIdStr = strconv.FormatInt(initData.User.ID, 10)
-	}
-
-	// Check userId
-	if len(userIdStr) == 0 {
-		http.Error(
-			c.Writer,
-			"User id not provided",
-			http.StatusNotFound,
-		)
-		return
-	}
-
-	// Check room id
-	if roomIdStr == "" {
-		http.Error(
-			c.Writer,
-			"Room id not provided",
-			http.StatusBadRequest,
-		)
-		return
-	} else if len(roomIdStr) > 36 {
-		http.Error(
-			c.Writer,
-			"Room id is too long",
-			http.StatusBadRequest,
-		)
-		return
-	}
-
-	userID := protocol.UserID(userIdStr)
-	clientId := protocol.ClientID(clientIdStr)
-	roomId := protocol.RoomID(roomIdStr)
-	// Check room key (predefined rooms cannot be deleted)
-	for _, clientsConf := range w.conf.Clients {
-		for _, r := range clientsConf.Rooms {
-			if clientId == clientsConf.ClientId && r == roomId {
-				http.Error(
-					c.Writer,
-					"Room cannot be deleted",
-					http.StatusBadRequest,
-				)
-				return
-			}
-		}
+	userID, apiErr := w.authVerifiedUser(queryUserAuth(c))
+	if apiErr == nil {
+		apiErr = w.deleteRoom(queryRoomRef(c), userID)
 	}
-
-	// Remove record from db
-	err := w.db.RemoveCustomGameRoom(clientId, roomId, userID)
-	if err != nil {
-		http.Error(
-			c.Writer,
-			err.Error(),
-			http.StatusInternalServerError,
-		)
+	if apiErr != nil {
+		writePlainError(c, apiErr)
 		return
 	}
-
-	// Close room and delete from map
-	roomKey := protocol.RoomKey(tuple.New2(clientId, roomId))
-	room, exists := w.rooms[roomKey]
-	if !exists {
-		http.Error(
-			c.Writer,
-			"Room not found",
-			http.StatusNotFound,
-		)
-		return
-	} else {
-		room.closed = true
-		delete(w.rooms, roomKey)
-	}
-
 	c.String(http.StatusOK, "ok")
 }
 
@@ -316,58 +225,52 @@ func (w *Web) deleteRoomHandler(c *gin.Context) {
 // @Produce	json
 // @Param		clientId	query		string	true	"Client ID"
 // @Param		roomId		query		string	true	"Room ID"
+// @Param		userId		query		string	false	"User ID of public rooms, private rooms require init data"
+// @Param		initData	query		string	false	"Telegram init data"
 // @Success	200			{object}	protocol.GameRoomStats
 // @Failure	400			"Room id not provided"
 // @Failure	400			"Room id is too long"
+// @Failure	403			"Room is private"
 // @Failure	404			"Room not found"
+// @Deprecated
 // @Router		/api/room/stats [get]
 func (w *Web) statsRoomHandler(c *gin.Context) {
-	clientIdStr := c.Query("clientId")
-	roomIdStr := c.Query("roomId")
-
-	// Check room id
-	if roomIdStr == "" {
-		http.Error(
-			c.Writer,
-			"Room id not provided",
-			http.StatusBadRequest,
-		)
-		return
-	} else if len(roomIdStr) > 36 {
-		http.Error(
-			c.Writer,
-			"Room id is too long",
-			http.StatusBadRequest,
-		)
+	stats, apiErr := w.roomStats(queryRoomRef(c), queryUserAuth(c))
+	if apiErr != nil {
+		writePlainError(c, apiErr)
 		return
 	}
+	c.JSON(http.StatusOK, stats)
+}
 
-	// Get room by key
-	clientId := protocol.ClientID(clientIdStr)
-	roomId := protocol.RoomID(roomIdStr)
-	roomKey := protocol.RoomKey(tuple.New2(clientId, roomId))
-	room, exists := w.rooms[roomKey]
-	if !exists {
-		http.Error(
-			c.Writer,
-			"Room not found",
-			http.StatusNotFound,
-		)
-		return
+// @Summary	Get room chat history
+// @Produce	json
+// @Param		clientId	query		string	true	"Client ID"
+// @Param		roomId		query		string	true	"Room ID"
+// @Param		userId		query		string	false	"User ID of public rooms, private rooms require init data"
+// @Param		initData	query		string	false	"Telegram init data"
+// @Param		before		query		int		false	"Return messages sent before the message with given id"
+// @Param		limit		query		int		false	"Count of messages in the page"
+// @Success	200			{object}	protocol.ChatHistory
+// @Failure	400			"Room id not provided"
+// @Failure	400			"Room id is too long"
+// @Failure	400			"Invalid before message id"
+// @Failure	400			"Invalid limit"
+// @Failure	403			"Room is private"
+// @Failure	404			"Room not found"
+// @Deprecated
+// @Router		/api/room/chat [get]
+func (w *Web) chatRoomHandler(c *gin.Context) {
+	var history protocol.ChatHistory
+	before, limit, apiErr := parseChatPage(c)
+	if apiErr == nil {
+		history, apiErr = w.roomChat(queryRoomRef(c), queryUserAuth(c), before, limit)
 	}
-
-	// Retrive room stats
-	stats, err := room.Stats(userPayloadCountInStats)
-	if err != nil {
-		http.Error(
-			c.Writer,
-			fmt.Sprintln("Failed to get room stats:", err),
-			http.StatusInternalServerError,
-		)
+	if apiErr != nil {
+		writePlainError(c, apiErr)
 		return
 	}
-
-	c.JSON(http.StatusOK, stats)
+	c.JSON(http.StatusOK, history)
 }
 
 // @Summary	Get client stats
@@ -375,51 +278,259 @@ func (w *Web) statsRoomHandler(c *gin.Context) {
 // @Param		clientId	query		string	true	"Client ID"
 // @Success	200			{object}	protocol.ClientStats
 // @Failure	404			"Client not found"
+// @Deprecated
 // @Router		/api/stats [get]
 func (w *Web) statsHandler(c *gin.Context) {
-	exists := false
-	clientIdStr := c.Query("clientId")
-	clientId := protocol.ClientID(clientIdStr)
-
-	for _, clientsConf := range w.conf.Clients {
-		if clientId == clientsConf.ClientId {
-			exists = true
-			break
-		}
+	stats, apiErr := w.clientStats(protocol.ClientID(c.Query("clientId")))
+	if apiErr != nil {
+		writePlainError(c, apiErr)
+		return
 	}
+	c.JSON(http.StatusOK, stats)
+}
 
-	if !exists {
-		http.Error(
-			c.Writer,
-			"Client not found",
-			http.StatusNotFound,
+// @Summary	Report user's chat messages
+// @Produce	json
+// @Param		clientId		query	string	true	"Client ID"
+// @Param		initData		query	string	true	"Telegram init data"
+// @Param		reportedUserId	query	string	true	"Reported user ID"
+// @Success	200				"ok"
+// @Success	200				"muted"
+// @Failure	400				"Telegram init data not provided"
+// @Failure	400				"Reported user id not provided"
+// @Failure	400				"Client not allowed"
+// @Failure	400				"Users cannot report themselves"
+// @Deprecated
+// @Router		/api/chat/report [get]
+func (w *Web) reportChatHandler(c *gin.Context) {
+	var muted bool
+	userID, apiErr := w.authVerifiedUser(queryUserAuth(c))
+	if apiErr == nil {
+		muted, apiErr = w.reportChat(
+			protocol.ClientID(c.Query("clientId")),
+			userID,
+			protocol.UserID(c.Query("reportedUserId")),
 		)
+	}
+	if apiErr != nil {
+		writePlainError(c, apiErr)
 		return
 	}
+	if muted {
+		c.String(http.StatusOK, "muted")
+		return
+	}
+	c.String(http.StatusOK, "ok")
+}
 
-	// Get rooms by key
-	customRooms, err := w.db.ListCustomGameRooms()
-	if err != nil {
-		http.Error(
-			c.Writer,
-			err.Error(),
-			http.StatusInternalServerError,
-		)
+// @Summary	Set user's profile privacy
+// @Produce	json
+// @Param		initData	query	string	true	"Telegram init data"
+// @Param		privacy		query	string	true	"Privacy setting: public, name or hidden"
+// @Success	200			"ok"
+// @Failure	400			"Telegram init data not provided"
+// @Failure	400			"Invalid privacy setting"
+// @Deprecated
+// @Router		/api/user/privacy [get]
+func (w *Web) privacyUserHandler(c *gin.Context) {
+	userID, apiErr := w.authVerifiedUser(queryUserAuth(c))
+	if apiErr == nil {
+		apiErr = w.setPrivacy(userID, c.Query("privacy"))
+	}
+	if apiErr != nil {
+		writePlainError(c, apiErr)
 		return
 	}
+	c.String(http.StatusOK, "ok")
+}
+
+// queryUserAuth extracts the user identification from query parameters.
+func queryUserAuth(c *gin.Context) protocol.UserAuth {
+	return protocol.UserAuth{
+		UserID:   protocol.UserID(c.Query("userId")),
+		InitData: c.Query("initData"),
+	}
+}
+
+// queryRoomRef extracts the room reference from query parameters.
+func queryRoomRef(c *gin.Context) protocol.RoomRef {
+	return protocol.RoomRef{
+		ClientID: protocol.ClientID(c.Query("clientId")),
+		RoomID:   protocol.RoomID(c.Query("roomId")),
+	}
+}
+
+// ownerQueryRequest extracts the room and the user acting as the room owner from query parameters,
+// the user is identified by telegram init data only.
+func (w *Web) ownerQueryRequest(c *gin.Context) (*GameRoom, protocol.UserID, *ApiError) {
+	userID, apiErr := w.authVerifiedUser(queryUserAuth(c))
+	if apiErr != nil {
+		return nil, "", apiErr
+	}
+	room, apiErr := w.findRoom(queryRoomRef(c))
+	return room, userID, apiErr
+}
 
-	onlineUsersCount, err := w.db.GetOnlineUsersCount(clientId)
+// ownerTargetQueryRequest extracts the room, the room owner, the target user
+// and the optional duration in seconds from query parameters.
+func (w *Web) ownerTargetQueryRequest(c *gin.Context) (*GameRoom, protocol.UserID, protocol.UserID, time.Duration, *ApiError) {
+	room, userID, apiErr := w.ownerQueryRequest(c)
+	if apiErr != nil {
+		return nil, "", "", 0, apiErr
+	}
+	targetID := protocol.UserID(c.Query("targetUserId"))
+	seconds, err := strconv.ParseInt(c.DefaultQuery("duration", "0"), 10, 64)
 	if err != nil {
-		http.Error(
-			c.Writer,
-			err.Error(),
-			http.StatusInternalServerError,
-		)
+		seconds = -1
+	}
+	duration, apiErr := checkTarget(targetID, seconds)
+	return room, userID, targetID, duration, apiErr
+}
+
+// writeOwnerQueryResult writes the plain text response of the room owner's action.
+func writeOwnerQueryResult(c *gin.Context, apiErr *ApiError) {
+	if apiErr != nil {
+		writePlainError(c, apiErr)
 		return
 	}
+	c.String(http.StatusOK, "ok")
+}
 
-	usersOnline := int64(onlineUsersCount)
-	roomsCount := int64(len(customRooms))
-	stats := protocol.NewClientStats(&usersOnline, &roomsCount)
-	c.JSON(http.StatusOK, stats)
+// @Summary	Kick the holder from the room, the session ends without a record
+// @Produce	json
+// @Param		clientId		query	string	true	"Client ID"
+// @Param		roomId			query	string	true	"Room ID"
+// @Param		initData		query	string	true	"Telegram init data"
+// @Param		targetUserId	query	string	true	"Kicked user ID"
+// @Success	200				"ok"
+// @Failure	400				"Telegram init data not provided"
+// @Failure	400				"Target user id not provided"
+// @Failure	400				"User cannot target themselves"
+// @Failure	403				"Room does not belong to the user"
+// @Failure	404				"Room not found"
+// @Deprecated
+// @Router		/api/room/kick [get]
+func (w *Web) kickRoomHandler(c *gin.Context) {
+	room, userID, targetID, _, apiErr := w.ownerTargetQueryRequest(c)
+	if apiErr == nil {
+		apiErr = ownerApiError(room.Kick(userID, targetID))
+	}
+	writeOwnerQueryResult(c, apiErr)
+}
+
+// @Summary	Ban the user from playing and chatting in the room
+// @Produce	json
+// @Param		clientId		query	string	true	"Client ID"
+// @Param		roomId			query	string	true	"Room ID"
+// @Param		initData		query	string	true	"Telegram init data"
+// @Param		targetUserId	query	string	true	"Banned user ID"
+// @Param		duration		query	int		false	"Ban duration in seconds, 0 bans forever"
+// @Success	200				"ok"
+// @Failure	400				"Telegram init data not provided"
+// @Failure	400				"Target user id not provided"
+// @Failure	400				"Invalid duration"
+// @Failure	400				"User cannot target themselves"
+// @Failure	403				"Room does not belong to the user"
+// @Failure	404				"Room not found"
+// @Deprecated
+// @Router		/api/room/ban [get]
+func (w *Web) banRoomHandler(c *gin.Context) {
+	room, userID, targetID, duration, apiErr := w.ownerTargetQueryRequest(c)
+	if apiErr == nil {
+		apiErr = ownerApiError(room.Ban(userID, targetID, duration))
+	}
+	writeOwnerQueryResult(c, apiErr)
+}
+
+// @Summary	Lift the user's ban in the room
+// @Produce	json
+// @Param		clientId		query	string	true	"Client ID"
+// @Param		roomId			query	string	true	"Room ID"
+// @Param		initData		query	string	true	"Telegram init data"
+// @Param		targetUserId	query	string	true	"Unbanned user ID"
+// @Success	200				"ok"
+// @Failure	400				"Telegram init data not provided"
+// @Failure	400				"Target user id not provided"
+// @Failure	400				"User cannot target themselves"
+// @Failure	403				"Room does not belong to the user"
+// @Failure	404				"Room not found"
+// @Deprecated
+// @Router		/api/room/unban [get]
+func (w *Web) unbanRoomHandler(c *gin.Context) {
+	room, userID, targetID, _, apiErr := w.ownerTargetQueryRequest(c)
+	if apiErr == nil {
+		apiErr = ownerApiError(room.Unban(userID, targetID))
+	}
+	writeOwnerQueryResult(c, apiErr)
+}
+
+// @Summary	Mute the user in the room chat
+// @Produce	json
+// @Param		clientId		query	string	true	"Client ID"
+// @Param		roomId			query	string	true	"Room ID"
+// @Param		initData		query	string	true	"Telegram init data"
+// @Param		targetUserId	query	string	true	"Muted user ID"
+// @Param		duration		query	int		false	"Mute duration in seconds, 0 mutes forever"
+// @Success	200				"ok"
+// @Failure	400				"Telegram init data not provided"
+// @Failure	400				"Target user id not provided"
+// @Failure	400				"Invalid duration"
+// @Failure	400				"User cannot target themselves"
+// @Failure	403				"Room does not belong to the user"
+// @Failure	404				"Room not found"
+// @Deprecated
+// @Router		/api/room/mute [get]
+func (w *Web) muteRoomHandler(c *gin.Context) {
+	room, userID, targetID, duration, apiErr := w.ownerTargetQueryRequest(c)
+	if apiErr == nil {
+		apiErr = ownerApiError(room.Mute(userID, targetID, duration))
+	}
+	writeOwnerQueryResult(c, apiErr)
+}
+
+// @Summary	Lift the user's mute in the room chat
+// @Produce	json
+// @Param		clientId		query	string	true	"Client ID"
+// @Param		roomId			query	string	true	"Room ID"
+// @Param		initData		query	string	true	"Telegram init data"
+// @Param		targetUserId	query	string	true	"Unmuted user ID"
+// @Success	200				"ok"
+// @Failure	400				"Telegram init data not provided"
+// @Failure	400				"Target user id not provided"
+// @Failure	400				"User cannot target themselves"
+// @Failure	403				"Room does not belong to the user"
+// @Failure	404				"Room not found"
+// @Deprecated
+// @Router		/api/room/unmute [get]
+func (w *Web) unmuteRoomHandler(c *gin.Context) {
+	room, userID, targetID, _, apiErr := w.ownerTargetQueryRequest(c)
+	if apiErr == nil {
+		apiErr = ownerApiError(room.Unmute(userID, targetID))
+	}
+	writeOwnerQueryResult(c, apiErr)
+}
+
+// @Summary	List users holding the button in the room
+// @Produce	json
+// @Param		clientId	query		string	true	"Client ID"
+// @Param		roomId		query		string	true	"Room ID"
+// @Param		initData	query		string	true	"Telegram init data"
+// @Success	200			{array}		protocol.RoomHolder
+// @Failure	400			"Telegram init data not provided"
+// @Failure	403			"Room does not belong to the user"
+// @Failure	404			"Room not found"
+// @Deprecated
+// @Router		/api/room/holders [get]
+func (w *Web) holdersRoomHandler(c *gin.Context) {
+	room, userID, apiErr := w.ownerQueryRequest(c)
+	if apiErr != nil {
+		writePlainError(c, apiErr)
+		return
+	}
+	holders, err := room.Holders(userID)
+	if apiErr = ownerApiError(err); apiErr != nil {
+		writePlainError(c, apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, holders)
 }
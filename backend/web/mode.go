@@ -0,0 +1,149 @@
+package web
+
+import (
+	"errors"
+	"time"
+
+	"buttonmania.win/db"
+	"buttonmania.win/protocol"
+)
+
+// Define the interval between ticks of game modes
+const modeTickInterval = time.Second
+
+// Define game mode errors
+var ErrUnknownMode = errors.New("unknown game mode")
+
+// GameMode defines gameplay rules of the room, GameRoom and its sessions delegate to the mode
+// everything beyond holding the button.
+type GameMode interface {
+	// Name returns the name of the mode.
+	Name() protocol.RoomMode
+	// OnJoin admits the holder to the new session, the error prevents the session start.
+	OnJoin(session *GameSession) error
+	// OnUpdate adds the state of the mode to the gameplay update sent to the holder.
+	OnUpdate(session *GameSession, update *protocol.GameplayMessage)
+	// Score stores the finished hold, holds ended by moderators are not scored.
+	Score(session *GameSession, record protocol.GameplayRecord) error
+	// OnRelease ends the hold in the mode, the record is nil for holds ended by moderators.
+	// Returns the message sent to the holder, nil sends nothing.
+	OnRelease(session *GameSession, record *protocol.GameplayRecord) (*protocol.GameplayMessage, error)
+	// OnReaped ends the hold of the holder gone without releasing, found by the reaper. The hold
+	// ended at end, the record is nil if the hold is discarded. Returns whether the hold is scored.
+	OnReaped(userID protocol.UserID, record *protocol.GameplayRecord, end int64) (bool, error)
+	// OnTick is called every second while the room is open.
+	OnTick(now time.Time) error
+	// Close stops the mode of the closed room.
+	Close() error
+}
+
+// NewGameMode creates the game mode of the room, empty mode is classic.
+func NewGameMode(room *GameRoom, mode protocol.RoomMode, teams []string) (GameMode, error) {
+	switch mode {
+	case "", protocol.ModeClassic:
+		return &classicMode{room: room}, nil
+	case protocol.ModeTournament:
+		return NewTournament(room), nil
+	case protocol.ModeTeams:
+		return NewTeams(room, teams), nil
+	case protocol.ModeChain:
+		return NewChain(room), nil
+	}
+	return nil, ErrUnknownMode
+}
+
+// modeErrorCode returns the localized error code of the session rejected by the mode, false for other errors.
+func modeErrorCode(err error) (protocol.ErrorCode, bool) {
+	switch {
+	case errors.Is(err, db.ErrTournamentNotScheduled):
+		return protocol.TournamentNotScheduled, true
+	case errors.Is(err, db.ErrTournamentNotRegistered):
+		return protocol.TournamentNotRegistered, true
+	case errors.Is(err, db.ErrTournamentNotStarted):
+		return protocol.TournamentNotStarted, true
+	case errors.Is(err, db.ErrTournamentGraceOver):
+		return protocol.TournamentGraceOver, true
+	case errors.Is(err, db.ErrTournamentPlayed):
+		return protocol.TournamentPlayed, true
+	}
+	return "", false
+}
+
+// classicMode records every hold in the room leaderboard, other modes build on it.
+type classicMode struct {
+	room *GameRoom
+}
+
+// Name returns the name of the mode.
+func (m *classicMode) Name() protocol.RoomMode {
+	return protocol.ModeClassic
+}
+
+// OnJoin admits every holder.
+func (m *classicMode) OnJoin(session *GameSession) error {
+	return nil
+}
+
+// OnUpdate sends no state of the mode.
+func (m *classicMode) OnUpdate(session *GameSession, update *protocol.GameplayMessage) {}
+
+// Score adds the hold to the room leaderboard.
+func (m *classicMode) Score(session *GameSession, record protocol.GameplayRecord) error {
+	return m.room.DB.AddRecordToLeaderboard(
+		m.room.ClientID,
+		m.room.RoomID,
+		session.userID,
+		record,
+	)
+}
+
+// OnRelease sends the record with its place in the leaderboard.
+func (m *classicMode) OnRelease(
+	session *GameSession,
+	record *protocol.GameplayRecord,
+) (*protocol.GameplayMessage, error) {
+	if record == nil {
+		return nil, nil
+	}
+	msg := session.gameplayRecord(record)
+	m.room.emit(protocol.WebhookRecord, session.userID, record)
+	if msg.WorldRecord != nil && *msg.WorldRecord {
+		m.room.emit(protocol.WebhookWorldRecord, session.userID, record)
+	}
+	return &msg, nil
+}
+
+// OnReaped adds the reaped hold to the room leaderboard and announces the record like released ones.
+func (m *classicMode) OnReaped(
+	userID protocol.UserID,
+	record *protocol.GameplayRecord,
+	end int64,
+) (bool, error) {
+	if record == nil {
+		return false, nil
+	}
+	if err := m.room.DB.AddRecordToLeaderboard(
+		m.room.ClientID,
+		m.room.RoomID,
+		userID,
+		*record,
+	); err != nil {
+		return false, err
+	}
+	m.room.emit(protocol.WebhookRecord, userID, record)
+	place, _ := m.room.DB.GetDurationPlaceInLeaderboard(m.room.ClientID, m.room.RoomID, record.Duration)
+	if place == 1 {
+		m.room.emit(protocol.WebhookWorldRecord, userID, record)
+	}
+	return true, nil
+}
+
+// OnTick does nothing.
+func (m *classicMode) OnTick(now time.Time) error {
+	return nil
+}
+
+// Close does nothing.
+func (m *classicMode) Close() error {
+	return nil
+}
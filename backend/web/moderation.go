@@ -0,0 +1,206 @@
+package web
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"buttonmania.win/conf"
+	"buttonmania.win/db"
+	"buttonmania.win/localization"
+	"buttonmania.win/protocol"
+)
+
+// Define chat moderation defaults
+const (
+	defaultChatMaxLength     = 200
+	defaultChatRateLimit     = 5
+	defaultChatRatePeriod    = 10
+	defaultChatReportsToMute = 3
+	defaultChatReportsPeriod = 600
+	defaultChatMuteDuration  = 900
+)
+
+// Define chat moderation errors
+var (
+	ErrChatReportYourself = errors.New("users cannot report themselves")
+)
+
+// linkRegexp matches urls, www prefixed hosts and bare domains of popular zones.
+var linkRegexp = regexp.MustCompile(
+	`(?i)([a-z][a-z0-9+.-]*://|www\.|t\.me/|[\p{L}\p{N}-]+\.(com|net|org|info|biz|io|me|ru|su|рф|xyz|ly|gg|app|dev|site|online|top|win)\b)`,
+)
+
+// ChatModerator validates chat messages against the client's chat policy.
+type ChatModerator struct {
+	clientId      protocol.ClientID
+	db            *db.DB
+	loc           *localization.ModerationLocalization
+	maxLength     int
+	rateLimit     int64
+	ratePeriod    time.Duration
+	allowLinks    bool
+	wordFilters   map[protocol.UserLocale]map[string]bool
+	reportsToMute int64
+	reportsPeriod time.Duration
+	muteDuration  time.Duration
+}
+
+// valueOrDefault returns the value if positive, otherwise the default.
+func valueOrDefault(value int, defaultValue int) int {
+	if value > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// NewChatModerator creates a new ChatModerator instance.
+func NewChatModerator(
+	clientId protocol.ClientID,
+	chatConf conf.ChatConf,
+	db *db.DB,
+	loc *localization.ModerationLocalization,
+) *ChatModerator {
+	wordFilters := make(map[protocol.UserLocale]map[string]bool)
+	for localeStr, words := range chatConf.WordFilters {
+		locale := protocol.NewUserLocale(localeStr)
+		if wordFilters[locale] == nil {
+			wordFilters[locale] = make(map[string]bool)
+		}
+		for _, word := range words {
+			wordFilters[locale][strings.ToLower(word)] = true
+		}
+	}
+	return &ChatModerator{
+		clientId:      clientId,
+		db:            db,
+		loc:           loc,
+		maxLength:     valueOrDefault(chatConf.MaxLength, defaultChatMaxLength),
+		rateLimit:     int64(valueOrDefault(chatConf.RateLimit, defaultChatRateLimit)),
+		ratePeriod:    time.Duration(valueOrDefault(chatConf.RatePeriod, defaultChatRatePeriod)) * time.Second,
+		allowLinks:    chatConf.AllowLinks,
+		wordFilters:   wordFilters,
+		reportsToMute: int64(valueOrDefault(chatConf.ReportsToMute, defaultChatReportsToMute)),
+		reportsPeriod: time.Duration(valueOrDefault(chatConf.ReportsPeriod, defaultChatReportsPeriod)) * time.Second,
+		muteDuration:  time.Duration(valueOrDefault(chatConf.MuteDuration, defaultChatMuteDuration)) * time.Second,
+	}
+}
+
+// NewDefaultChatModerator creates a new ChatModerator instance with default policy.
+func NewDefaultChatModerator(
+	clientId protocol.ClientID,
+	db *db.DB,
+	loc *localization.ModerationLocalization,
+) *ChatModerator {
+	return NewChatModerator(clientId, conf.ChatConf{}, db, loc)
+}
+
+// gameplayError creates localized moderation error.
+func (m *ChatModerator) gameplayError(
+	locale protocol.UserLocale,
+	code protocol.ErrorCode,
+) *protocol.GameplayError {
+	msg := protocol.GameMessage(m.loc.LocalizedErrorString(locale, code))
+	gameError := protocol.NewGameplayErrorWithCode(msg, code)
+	return &gameError
+}
+
+// containsForbiddenWord checks message words against the filter of the locale.
+func (m *ChatModerator) containsForbiddenWord(
+	locale protocol.UserLocale,
+	message string,
+) bool {
+	filter := m.wordFilters[locale]
+	if len(filter) == 0 {
+		return false
+	}
+	words := strings.FieldsFunc(message, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, word := range words {
+		if filter[strings.ToLower(word)] {
+			return true
+		}
+	}
+	return false
+}
+
+// containsForbiddenSlug checks the room id slug against filters of every locale, words hidden
+// inside the slug are found once dashes are stripped.
+func (m *ChatModerator) containsForbiddenSlug(slug protocol.RoomID) bool {
+	joined := strings.ReplaceAll(string(slug), "-", "")
+	for _, filter := range m.wordFilters {
+		for word := range filter {
+			if strings.Contains(joined, word) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkContent checks the text for links and forbidden words, returns the code of the violation.
+func (m *ChatModerator) checkContent(
+	locale protocol.UserLocale,
+	text string,
+) protocol.ErrorCode {
+	if !m.allowLinks && linkRegexp.MatchString(text) {
+		return protocol.ChatLink
+	}
+	if m.containsForbiddenWord(locale, text) {
+		return protocol.ChatForbiddenWord
+	}
+	return ""
+}
+
+// Moderate checks the chat message sent by the user, returns localized gameplay error
+// if the message is rejected. Checks go from cheapest to the ones hitting the database.
+func (m *ChatModerator) Moderate(
+	userID protocol.UserID,
+	locale protocol.UserLocale,
+	chatMessage *protocol.ChatMessage,
+) (*protocol.GameplayError, error) {
+	chatMessage.Message = strings.TrimSpace(chatMessage.Message)
+	if utf8.RuneCountInString(chatMessage.Message) > m.maxLength {
+		return m.gameplayError(locale, protocol.ChatTooLong), nil
+	}
+	if code := m.checkContent(locale, chatMessage.Message); code != "" {
+		return m.gameplayError(locale, code), nil
+	}
+	muted, err := m.db.IsChatMuted(m.clientId, userID)
+	if err != nil {
+		return nil, err
+	}
+	if muted {
+		return m.gameplayError(locale, protocol.ChatMuted), nil
+	}
+	count, err := m.db.IncrementChatRate(m.clientId, userID, m.ratePeriod)
+	if err != nil {
+		return nil, err
+	}
+	if count > m.rateLimit {
+		return m.gameplayError(locale, protocol.ChatRateLimited), nil
+	}
+	return nil, nil
+}
+
+// Report registers the report on the user's chat behaviour, returns true if the user got muted.
+func (m *ChatModerator) Report(
+	reporterID protocol.UserID,
+	userID protocol.UserID,
+) (bool, error) {
+	if reporterID == userID {
+		return false, ErrChatReportYourself
+	}
+	return m.db.ReportChatUser(
+		m.clientId,
+		reporterID,
+		userID,
+		m.reportsToMute,
+		m.reportsPeriod,
+		m.muteDuration,
+	)
+}
@@ -0,0 +1,54 @@
+package web
+
+import (
+	"slices"
+	"time"
+
+	"buttonmania.win/conf"
+)
+
+// Define quick reactions defaults
+const (
+	defaultReactionsWindow = 1000
+	// Minimal interval between reactions of the same holder
+	reactionsMinInterval = 250 * time.Millisecond
+)
+
+// Define default quick reactions
+var (
+	defaultReactionsSet = []string{"👍", "🔥", "😂", "😮", "❤️"}
+)
+
+// ReactionSet represents quick reactions available to holders of the client rooms.
+type ReactionSet struct {
+	list   []string
+	window time.Duration
+}
+
+// NewReactionSet creates a new ReactionSet instance.
+func NewReactionSet(reactionsConf conf.ReactionsConf) *ReactionSet {
+	list := reactionsConf.Set
+	if len(list) == 0 {
+		list = defaultReactionsSet
+	}
+	window := valueOrDefault(reactionsConf.Window, defaultReactionsWindow)
+	return &ReactionSet{
+		list:   slices.Clone(list),
+		window: time.Duration(window) * time.Millisecond,
+	}
+}
+
+// NewDefaultReactionSet creates a new ReactionSet instance with default reactions.
+func NewDefaultReactionSet() *ReactionSet {
+	return NewReactionSet(conf.ReactionsConf{})
+}
+
+// List returns available reactions.
+func (s *ReactionSet) List() []string {
+	return slices.Clone(s.list)
+}
+
+// Has checks if the reaction is available.
+func (s *ReactionSet) Has(reaction string) bool {
+	return slices.Contains(s.list, reaction)
+}
@@ -2,55 +2,250 @@ package web
 
 import (
 	"errors"
+	"log"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"buttonmania.win/db"
 	"buttonmania.win/localization"
 	"buttonmania.win/protocol"
+	"buttonmania.win/webhook"
 	"github.com/gorilla/websocket"
 )
 
 // GameRoom represents a room for managing game sessions.
 type GameRoom struct {
-	ClientID protocol.ClientID
-	RoomID   protocol.RoomID
-	MsgLoc   *localization.MessagesLocalization
-	DB       *db.DB
-	sessions map[protocol.UserID]*GameSession
-	closed   bool
+	ClientID  protocol.ClientID
+	RoomID    protocol.RoomID
+	DB        *db.DB
+	Mod       *ChatModerator
+	Reactions *ReactionSet
+	Hooks     *webhook.Dispatcher
+	msgLoc    atomic.Pointer[localization.MessagesLocalization]
+	mode      GameMode
+	event     atomic.Pointer[Event]
+	sessions  map[protocol.UserID]*GameSession
+	mu        sync.RWMutex
+	closed    atomic.Bool
+	chatStop  func() error
+	reactStop func() error
+	ctrlStop  func() error
 }
 
-// NewGameRoom creates a new GameRoom instance.
+// NewGameRoom creates a new GameRoom instance in the game mode, empty mode is classic.
 func NewGameRoom(
 	clientId protocol.ClientID,
 	roomId protocol.RoomID,
 	db *db.DB,
 	msgLoc *localization.MessagesLocalization,
+	mod *ChatModerator,
+	reactions *ReactionSet,
+	hooks *webhook.Dispatcher,
+	mode protocol.RoomMode,
+	teams []string,
 ) (*GameRoom, error) {
 	sessions := make(map[protocol.UserID]*GameSession)
-	err := db.InitChatConsumerGroup(clientId, roomId)
-	return &GameRoom{
-		ClientID: clientId,
-		RoomID:   roomId,
-		MsgLoc:   msgLoc,
-		DB:       db,
-		sessions: sessions,
-		closed:   false,
-	}, err
+	room := &GameRoom{
+		ClientID:  clientId,
+		RoomID:    roomId,
+		DB:        db,
+		Mod:       mod,
+		Reactions: reactions,
+		Hooks:     hooks,
+		sessions:  sessions,
+	}
+	gameMode, err := NewGameMode(room, mode, teams)
+	if err != nil {
+		return nil, err
+	}
+	chat, chatStop := db.SubscribeChatMessages(clientId, roomId)
+	react, reactStop := db.SubscribeReactions(clientId, roomId)
+	ctrl, ctrlStop := db.SubscribeRoomControl(clientId, roomId)
+	room.mode = gameMode
+	room.chatStop = chatStop
+	room.reactStop = reactStop
+	room.ctrlStop = ctrlStop
+	room.msgLoc.Store(msgLoc)
+	go room.broadcastChatMessages(chat)
+	go room.broadcastReactions(react)
+	go room.broadcastRoomControl(ctrl)
+	return room, nil
+}
+
+// NewCustomGameRoom creates a new GameRoom instance of the user created room
+// in the mode chosen by the owner with the uploaded message pack.
+func NewCustomGameRoom(
+	clientId protocol.ClientID,
+	roomId protocol.RoomID,
+	db *db.DB,
+	mod *ChatModerator,
+	reactions *ReactionSet,
+	hooks *webhook.Dispatcher,
+) (*GameRoom, error) {
+	meta, err := db.GetRoomMeta(clientId, roomId)
+	if err != nil {
+		return nil, err
+	}
+	room, err := NewGameRoom(clientId, roomId, db, nil, mod, reactions, hooks, meta.Mode, meta.Teams)
+	if err != nil {
+		return nil, err
+	}
+	return room, room.LoadMessages()
+}
+
+// tick ticks the game mode and the event of the room, the closed room is not ticked.
+func (r *GameRoom) tick(now time.Time) {
+	if r.Closed() {
+		return
+	}
+	if err := r.mode.OnTick(now); err != nil {
+		log.Println("Failed to tick game mode:", err)
+	}
+	r.finishEvent(now)
+}
+
+// emit delivers the event of the room to webhooks of the client.
+func (r *GameRoom) emit(
+	eventType protocol.WebhookEventType,
+	userID protocol.UserID,
+	record *protocol.GameplayRecord,
+) {
+	r.Hooks.Emit(protocol.WebhookEvent{
+		Type:     eventType,
+		ClientID: r.ClientID,
+		RoomID:   r.RoomID,
+		UserID:   userID,
+		Record:   record,
+	})
+}
+
+// Messages returns motivational messages of the room, nil if the room has none.
+func (r *GameRoom) Messages() *localization.MessagesLocalization {
+	return r.msgLoc.Load()
+}
+
+// LoadMessages loads the message pack uploaded by the room owner.
+func (r *GameRoom) LoadMessages() error {
+	pack, err := r.DB.GetRoomMessages(r.ClientID, r.RoomID)
+	if err != nil {
+		return err
+	}
+	if len(pack) == 0 {
+		r.msgLoc.Store(nil)
+		return nil
+	}
+	r.msgLoc.Store(localization.NewCustomMessagesLocalization(r.RoomID, pack))
+	return nil
+}
+
+// broadcastChatMessages delivers chat messages of the room to every holder except the author.
+func (r *GameRoom) broadcastChatMessages(chat <-chan protocol.ChatMessage) {
+	for chatMessage := range chat {
+		r.mu.RLock()
+		for userID, session := range r.sessions {
+			if userID != chatMessage.UserID {
+				session.deliverChatMessage(chatMessage)
+			}
+		}
+		r.mu.RUnlock()
+	}
+}
+
+// broadcastReactions counts reactions of the room over the window
+// and delivers the counts to every holder.
+func (r *GameRoom) broadcastReactions(react <-chan string) {
+	ticker := time.NewTicker(r.Reactions.window)
+	defer ticker.Stop()
+	counts := make(map[string]int64)
+	for {
+		select {
+		case reaction, ok := <-react:
+			if !ok {
+				return
+			}
+			if r.Reactions.Has(reaction) {
+				counts[reaction]++
+			}
+		case <-ticker.C:
+			if len(counts) == 0 {
+				continue
+			}
+			r.mu.RLock()
+			for _, session := range r.sessions {
+				session.deliverReactions(maps.Clone(counts))
+			}
+			r.mu.RUnlock()
+			clear(counts)
+		}
+	}
+}
+
+// Close closes the room, active sessions are finished on their next update.
+// Closing the closed room has no effect.
+func (r *GameRoom) Close() error {
+	if r.closed.Swap(true) {
+		return nil
+	}
+	return errors.Join(r.chatStop(), r.reactStop(), r.ctrlStop(), r.mode.Close())
+}
+
+// Closed checks if the room is closed.
+func (r *GameRoom) Closed() bool {
+	return r.closed.Load()
+}
+
+// bestHolders returns public profiles of users holding the button longest.
+func (r *GameRoom) bestHolders(count int64) ([]protocol.UserProfile, error) {
+	userIDs, err := r.DB.GetBestActiveUsers(r.ClientID, r.RoomID, count)
+	if err != nil || len(userIDs) == 0 {
+		return []protocol.UserProfile{}, err
+	}
+	profiles, err := r.DB.GetUserProfiles(userIDs)
+	for i := range profiles {
+		profiles[i] = profiles[i].Public()
+	}
+	return profiles, err
+}
+
+// leaderboard returns the best records of the room with public profiles.
+func (r *GameRoom) leaderboard(count int64) ([]protocol.LeaderboardEntry, error) {
+	entries, err := r.DB.GetLeaderboard(r.ClientID, r.RoomID, count)
+	for i := range entries {
+		entries[i].User = entries[i].User.Public()
+	}
+	return entries, err
+}
+
+// Meta returns metadata of the custom game room, predefined rooms have no metadata.
+func (r *GameRoom) Meta() (*protocol.RoomMeta, error) {
+	meta, err := r.DB.GetRoomMeta(r.ClientID, r.RoomID)
+	if err != nil || meta.Owner == "" {
+		return nil, err
+	}
+	return &meta, nil
 }
 
 // Stats returns the statistics for the game room.
-func (r *GameRoom) Stats(payloadCount int64) (protocol.GameRoomStats, error) {
+func (r *GameRoom) Stats(payloadCount int64, usersCount int64) (protocol.GameRoomStats, error) {
 	countActive, countActiveErr := r.DB.GetUsersCountInActiveSessions(r.ClientID, r.RoomID)
 	countLeaderboard, countLeaderboardErr := r.DB.GetUsersCountInLeaderboard(r.ClientID, r.RoomID)
 	bestOverallDuration, bestOverallDurationErr := r.DB.GetBestOverallDurationInLeaderboard(r.ClientID, r.RoomID)
 	bestTodaysDuration, bestTodaysDurationErr := r.DB.GetTodaysDurationInLeaderboard(r.ClientID, r.RoomID)
 	bestUsersPayloads, bestUsersPayloadsErr := r.DB.GetBestUsersPayloads(r.ClientID, r.RoomID, payloadCount)
+	bestHolders, bestHoldersErr := r.bestHolders(usersCount)
+	leaderboard, leaderboardErr := r.leaderboard(usersCount)
+	meta, metaErr := r.Meta()
 	err := errors.Join(
 		countActiveErr,
 		countLeaderboardErr,
 		bestOverallDurationErr,
 		bestTodaysDurationErr,
 		bestUsersPayloadsErr,
+		bestHoldersErr,
+		leaderboardErr,
+		metaErr,
 	)
 	return protocol.NewGameRoomStats(
 		&countActive,
@@ -58,22 +253,31 @@ func (r *GameRoom) Stats(payloadCount int64) (protocol.GameRoomStats, error) {
 		&bestOverallDuration,
 		&bestTodaysDuration,
 		&bestUsersPayloads,
+		&bestHolders,
+		&leaderboard,
+		meta,
 	), err
 }
 
 // HasGameSession checks if a game session exists for a user.
 func (r *GameRoom) HasGameSession(userID protocol.UserID) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	_, exists := r.sessions[userID]
 	return exists
 }
 
 // AddGameSession adds a game session to the room.
 func (r *GameRoom) AddGameSession(userID protocol.UserID, session *GameSession) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.sessions[userID] = session
 }
 
 // RemoveGameSession removes a game session from the room.
 func (r *GameRoom) RemoveGameSession(userID protocol.UserID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	delete(r.sessions, userID)
 }
 
@@ -82,12 +286,16 @@ func (r *GameRoom) MaintainGameSession(
 	userID protocol.UserID,
 	UserPayload protocol.UserPayload,
 	UserLocale protocol.UserLocale,
+	UserProfile protocol.UserProfile,
+	team string,
 	ws *websocket.Conn,
 ) error {
 	session := NewGameSession(
 		userID,
 		UserPayload,
 		UserLocale,
+		UserProfile,
+		team,
 		r,
 		ws,
 	)
@@ -0,0 +1,127 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"buttonmania.win/conf"
+	"buttonmania.win/protocol"
+
+	tuple "github.com/barweiss/go-tuple"
+)
+
+// Define room id policy parameters
+const (
+	minRoomIdLength    = 3
+	roomIdSuggestions  = 3
+	maxRoomIdSuggested = 20
+)
+
+// Define room ids reserved for every client
+var defaultReservedRoomIds = []protocol.RoomID{
+	"admin",
+	"api",
+	"buttonmania",
+	"official",
+	"support",
+	"swagger",
+}
+
+// slugRoomId normalizes the room id to the slug of lowercase latin letters and digits
+// separated by single dashes, spaces, dots and underscores become dashes.
+// Returns false if the id has other characters.
+func slugRoomId(roomId protocol.RoomID) (protocol.RoomID, bool) {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(string(roomId))) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		case r == '-', r == '_', r == '.', r == ' ':
+			dash = true
+		default:
+			return "", false
+		}
+	}
+	return protocol.RoomID(b.String()), true
+}
+
+// checkNewRoomId validates the id of the new room, returns the id normalized to the slug.
+func checkNewRoomId(roomId protocol.RoomID) (protocol.RoomID, *ApiError) {
+	if apiErr := checkRoomId(roomId); apiErr != nil {
+		return "", apiErr
+	}
+	slug, ok := slugRoomId(roomId)
+	if !ok || slug == "" {
+		return "", newApiError(http.StatusBadRequest, protocol.ApiRoomIdInvalid, "Room id may contain only latin letters, digits, dashes and underscores")
+	} else if len(slug) < minRoomIdLength {
+		return "", newApiError(http.StatusBadRequest, protocol.ApiRoomIdTooShort, "Room id is too short")
+	}
+	return slug, nil
+}
+
+// newReservedRoomIds collects ids custom rooms of each client cannot take:
+// predefined rooms of every client, default and client reserved ids.
+func newReservedRoomIds(cfg conf.Conf) map[protocol.ClientID]map[protocol.RoomID]bool {
+	common := make(map[protocol.RoomID]bool)
+	for _, roomId := range defaultReservedRoomIds {
+		common[roomId] = true
+	}
+	for _, c := range cfg.Clients {
+		for _, roomId := range c.Rooms {
+			common[roomId] = true
+		}
+	}
+	reserved := make(map[protocol.ClientID]map[protocol.RoomID]bool)
+	for _, c := range cfg.Clients {
+		ids := make(map[protocol.RoomID]bool, len(common)+len(c.CustomRooms.Reserved))
+		for roomId := range common {
+			ids[roomId] = true
+		}
+		for _, roomId := range c.CustomRooms.Reserved {
+			if slug, ok := slugRoomId(roomId); ok {
+				ids[slug] = true
+			}
+		}
+		reserved[c.ClientId] = ids
+	}
+	return reserved
+}
+
+// roomIdTaken checks if the room id is reserved, archived or used by an existing room of the client.
+func (w *Web) roomIdTaken(ref protocol.RoomRef) (bool, error) {
+	if w.reserved[ref.ClientID][ref.RoomID] {
+		return true, nil
+	}
+	if archived, err := w.db.IsRoomArchived(ref.ClientID, ref.RoomID); err != nil || archived {
+		return archived, err
+	}
+	room, err := w.lookupRoom(protocol.RoomKey(tuple.New2(ref.ClientID, ref.RoomID)))
+	return room != nil, err
+}
+
+// suggestRoomIds returns free ids derived from the taken one by numeric suffixes.
+func (w *Web) suggestRoomIds(ref protocol.RoomRef) []protocol.RoomID {
+	suggestions := []protocol.RoomID{}
+	for i := 2; i < 2+maxRoomIdSuggested && len(suggestions) < roomIdSuggestions; i++ {
+		suffix := fmt.Sprintf("-%d", i)
+		base := strings.TrimSuffix(string(ref.RoomID)[:min(len(ref.RoomID), maxRoomIdLength-len(suffix))], "-")
+		candidate := protocol.RoomRef{ClientID: ref.ClientID, RoomID: protocol.RoomID(base + suffix)}
+		if taken, err := w.roomIdTaken(candidate); err == nil && !taken {
+			suggestions = append(suggestions, candidate.RoomID)
+		}
+	}
+	return suggestions
+}
+
+// roomIdConflictApiError creates the error of the taken room id with suggested free ids.
+func (w *Web) roomIdConflictApiError(ref protocol.RoomRef, code protocol.ErrorCode, message string) *ApiError {
+	apiErr := newApiError(http.StatusConflict, code, message)
+	apiErr.Suggestions = w.suggestRoomIds(ref)
+	return apiErr
+}
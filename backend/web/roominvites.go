@@ -0,0 +1,81 @@
+package web
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"buttonmania.win/protocol"
+)
+
+// Define the count of random bytes in invite codes
+const (
+	inviteCodeBytes = 6
+)
+
+// newInviteCode generates a random invite code, which is safe to use in links.
+func newInviteCode() (string, error) {
+	b := make([]byte, inviteCodeBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HasAccess checks if the user can hold the button and view the room,
+// private rooms are available to the owner, moderators and invited members only.
+func (r *GameRoom) HasAccess(userID protocol.UserID, meta *protocol.RoomMeta) (bool, error) {
+	if meta == nil || !meta.Private || meta.Owner == userID {
+		return true, nil
+	}
+	if userID == "" {
+		return false, nil
+	}
+	member, err := r.DB.IsRoomMember(r.ClientID, r.RoomID, userID)
+	if err != nil || member {
+		return member, err
+	}
+	role, err := r.DB.GetRoomRole(r.ClientID, r.RoomID, userID)
+	return role == protocol.RoleModerator, err
+}
+
+// CreateInvite issues a new invite code of the room, the previous code stops working.
+// Zero ttl and max uses mean unlimited.
+func (r *GameRoom) CreateInvite(moderatorID protocol.UserID, ttl time.Duration, maxUses int64) (protocol.RoomInvite, error) {
+	if err := r.checkRole(moderatorID, "", protocol.RoleModerator); err != nil {
+		return protocol.RoomInvite{}, err
+	}
+	code, err := newInviteCode()
+	if err != nil {
+		return protocol.RoomInvite{}, err
+	}
+	invite := protocol.RoomInvite{
+		Code:    code,
+		MaxUses: maxUses,
+	}
+	if ttl > 0 {
+		invite.Expires = time.Now().Add(ttl).Unix()
+	}
+	return invite, r.DB.SetRoomInvite(r.ClientID, r.RoomID, invite)
+}
+
+// Invite returns the current invite of the room, nil if not issued.
+func (r *GameRoom) Invite(moderatorID protocol.UserID) (*protocol.RoomInvite, error) {
+	if err := r.checkRole(moderatorID, "", protocol.RoleModerator); err != nil {
+		return nil, err
+	}
+	return r.DB.GetRoomInvite(r.ClientID, r.RoomID)
+}
+
+// RevokeInvite revokes the current invite of the room, joined members keep access.
+func (r *GameRoom) RevokeInvite(moderatorID protocol.UserID) error {
+	if err := r.checkRole(moderatorID, "", protocol.RoleModerator); err != nil {
+		return err
+	}
+	return r.DB.RemoveRoomInvite(r.ClientID, r.RoomID)
+}
+
+// Join redeems the invite code making the user a room member.
+func (r *GameRoom) Join(userID protocol.UserID, code string) error {
+	return r.DB.RedeemRoomInvite(r.ClientID, r.RoomID, code, userID)
+}
@@ -0,0 +1,245 @@
+package web
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"buttonmania.win/protocol"
+)
+
+// Define the count of webhook deliveries shown to the room owner
+const webhookDeliveriesLimit = 100
+
+// Define room roles errors
+var (
+	ErrRoomNotOwner        = errors.New("room does not belong to the user")
+	ErrRoomNotModerator    = errors.New("user does not moderate the room")
+	ErrRoomOwnerSelf       = errors.New("user cannot apply the action to themselves")
+	ErrRoomTargetProtected = errors.New("moderators cannot apply the action to the owner or other moderators")
+	ErrRoomUserBanned      = errors.New("user is banned from the room")
+	// Ownership is transferred to moderators only, so mistyped user ids are not accepted
+	ErrRoomTransferNotModerator = errors.New("room can be transferred to its moderator only")
+)
+
+// checkRole checks the user has the role in the room, the owner has every role.
+// Moderators cannot target the owner and other moderators, predefined rooms have no roles.
+func (r *GameRoom) checkRole(userID protocol.UserID, targetID protocol.UserID, role protocol.RoomRole) error {
+	userRole, err := r.DB.GetRoomRole(r.ClientID, r.RoomID, userID)
+	if err != nil {
+		return err
+	}
+	if userRole == "" && role == protocol.RoleModerator {
+		return ErrRoomNotModerator
+	} else if userRole != protocol.RoleOwner && role == protocol.RoleOwner {
+		return ErrRoomNotOwner
+	}
+	if targetID == "" {
+		return nil
+	} else if targetID == userID {
+		return ErrRoomOwnerSelf
+	}
+	if userRole == protocol.RoleModerator {
+		targetRole, err := r.DB.GetRoomRole(r.ClientID, r.RoomID, targetID)
+		if err != nil {
+			return err
+		} else if targetRole != "" {
+			return ErrRoomTargetProtected
+		}
+	}
+	return nil
+}
+
+// applyControl ends the session of the user held on this instance, reloads the room message pack
+// or closes the room removed by another instance.
+func (r *GameRoom) applyControl(control protocol.RoomControl) {
+	switch control.Action {
+	case protocol.ControlMessages:
+		if err := r.LoadMessages(); err != nil {
+			log.Println("Failed to reload room messages:", err)
+		}
+		return
+	case protocol.ControlClose:
+		if err := r.Close(); err != nil {
+			log.Println("Failed to close room:", err)
+		}
+		return
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	session, exists := r.sessions[control.UserID]
+	if !exists {
+		return
+	}
+	switch control.Action {
+	case protocol.ControlKick:
+		session.kick(protocol.RoomKicked)
+	case protocol.ControlBan:
+		session.kick(protocol.RoomBanned)
+	}
+}
+
+// broadcastRoomControl applies room owner's actions published by any instance.
+func (r *GameRoom) broadcastRoomControl(controls <-chan protocol.RoomControl) {
+	for control := range controls {
+		r.applyControl(control)
+	}
+}
+
+// Kick ends the holder's session without a record.
+func (r *GameRoom) Kick(moderatorID protocol.UserID, userID protocol.UserID) error {
+	if err := r.checkRole(moderatorID, userID, protocol.RoleModerator); err != nil {
+		return err
+	}
+	return r.DB.PublishRoomControl(r.ClientID, r.RoomID, protocol.RoomControl{
+		Action: protocol.ControlKick,
+		UserID: userID,
+	})
+}
+
+// Ban bans the user from playing and chatting in the room, the current session is ended without a record.
+// Zero duration bans forever.
+func (r *GameRoom) Ban(moderatorID protocol.UserID, userID protocol.UserID, duration time.Duration) error {
+	if err := r.checkRole(moderatorID, userID, protocol.RoleModerator); err != nil {
+		return err
+	}
+	if err := r.DB.BanRoomUser(r.ClientID, r.RoomID, userID, duration); err != nil {
+		return err
+	}
+	return r.DB.PublishRoomControl(r.ClientID, r.RoomID, protocol.RoomControl{
+		Action: protocol.ControlBan,
+		UserID: userID,
+	})
+}
+
+// Unban lifts the user's ban in the room.
+func (r *GameRoom) Unban(moderatorID protocol.UserID, userID protocol.UserID) error {
+	if err := r.checkRole(moderatorID, userID, protocol.RoleModerator); err != nil {
+		return err
+	}
+	return r.DB.UnbanRoomUser(r.ClientID, r.RoomID, userID)
+}
+
+// Mute mutes the user in the room chat, zero duration mutes forever.
+func (r *GameRoom) Mute(moderatorID protocol.UserID, userID protocol.UserID, duration time.Duration) error {
+	if err := r.checkRole(moderatorID, userID, protocol.RoleModerator); err != nil {
+		return err
+	}
+	return r.DB.MuteRoomUser(r.ClientID, r.RoomID, userID, duration)
+}
+
+// Unmute lifts the user's mute in the room chat.
+func (r *GameRoom) Unmute(moderatorID protocol.UserID, userID protocol.UserID) error {
+	if err := r.checkRole(moderatorID, userID, protocol.RoleModerator); err != nil {
+		return err
+	}
+	return r.DB.UnmuteRoomUser(r.ClientID, r.RoomID, userID)
+}
+
+// UpdateMeta edits metadata of the room.
+func (r *GameRoom) UpdateMeta(ownerID protocol.UserID, update protocol.RoomMetaUpdate) error {
+	if err := r.checkRole(ownerID, "", protocol.RoleOwner); err != nil {
+		return err
+	}
+	return r.DB.UpdateRoomMeta(r.ClientID, r.RoomID, update)
+}
+
+// SetMessages replaces the room message pack, every instance reloads it.
+func (r *GameRoom) SetMessages(ownerID protocol.UserID, pack map[protocol.UserLocale][]string) error {
+	if err := r.checkRole(ownerID, "", protocol.RoleOwner); err != nil {
+		return err
+	}
+	if err := r.DB.SetRoomMessages(r.ClientID, r.RoomID, pack); err != nil {
+		return err
+	}
+	return r.DB.PublishRoomControl(r.ClientID, r.RoomID, protocol.RoomControl{
+		Action: protocol.ControlMessages,
+	})
+}
+
+// RemoveMessages removes the room message pack, every instance stops sending messages.
+func (r *GameRoom) RemoveMessages(ownerID protocol.UserID) error {
+	if err := r.checkRole(ownerID, "", protocol.RoleOwner); err != nil {
+		return err
+	}
+	if err := r.DB.RemoveRoomMessages(r.ClientID, r.RoomID); err != nil {
+		return err
+	}
+	return r.DB.PublishRoomControl(r.ClientID, r.RoomID, protocol.RoomControl{
+		Action: protocol.ControlMessages,
+	})
+}
+
+// Holders lists users holding the button in the room with their public profiles.
+func (r *GameRoom) Holders(moderatorID protocol.UserID) ([]protocol.RoomHolder, error) {
+	if err := r.checkRole(moderatorID, "", protocol.RoleModerator); err != nil {
+		return nil, err
+	}
+	holders, err := r.DB.GetActiveSessions(r.ClientID, r.RoomID)
+	if err != nil || len(holders) == 0 {
+		return holders, err
+	}
+	userIDs := make([]protocol.UserID, len(holders))
+	for i, holder := range holders {
+		userIDs[i] = holder.User.UserID
+	}
+	profiles, err := r.DB.GetUserProfiles(userIDs)
+	for i := range profiles {
+		holders[i].User = profiles[i].Public()
+	}
+	return holders, err
+}
+
+// Moderators lists moderators of the room with their public profiles.
+func (r *GameRoom) Moderators(moderatorID protocol.UserID) ([]protocol.UserProfile, error) {
+	if err := r.checkRole(moderatorID, "", protocol.RoleModerator); err != nil {
+		return nil, err
+	}
+	moderators, err := r.DB.GetRoomModerators(r.ClientID, r.RoomID)
+	if err != nil || len(moderators) == 0 {
+		return []protocol.UserProfile{}, err
+	}
+	profiles, err := r.DB.GetUserProfiles(moderators)
+	for i := range profiles {
+		profiles[i] = profiles[i].Public()
+	}
+	return profiles, err
+}
+
+// WebhookDeliveries returns the latest attempts to deliver events of the room to webhooks of the client.
+func (r *GameRoom) WebhookDeliveries(ownerID protocol.UserID) ([]protocol.WebhookDelivery, error) {
+	if err := r.checkRole(ownerID, "", protocol.RoleOwner); err != nil {
+		return nil, err
+	}
+	return r.DB.GetWebhookDeliveries(r.ClientID, r.RoomID, webhookDeliveriesLimit)
+}
+
+// AddModerator grants the user the moderator role in the room.
+func (r *GameRoom) AddModerator(ownerID protocol.UserID, userID protocol.UserID) error {
+	if err := r.checkRole(ownerID, userID, protocol.RoleOwner); err != nil {
+		return err
+	}
+	return r.DB.AddRoomModerator(r.ClientID, r.RoomID, userID)
+}
+
+// RemoveModerator revokes the moderator role of the user in the room.
+func (r *GameRoom) RemoveModerator(ownerID protocol.UserID, userID protocol.UserID) error {
+	if err := r.checkRole(ownerID, userID, protocol.RoleOwner); err != nil {
+		return err
+	}
+	return r.DB.RemoveRoomModerator(r.ClientID, r.RoomID, userID)
+}
+
+// Transfer hands the room over to its moderator, the previous owner becomes a moderator.
+func (r *GameRoom) Transfer(ownerID protocol.UserID, userID protocol.UserID, maxRooms int64) error {
+	if err := r.checkRole(ownerID, userID, protocol.RoleOwner); err != nil {
+		return err
+	}
+	role, err := r.DB.GetRoomRole(r.ClientID, r.RoomID, userID)
+	if err != nil {
+		return err
+	} else if role != protocol.RoleModerator {
+		return ErrRoomTransferNotModerator
+	}
+	return r.DB.TransferCustomGameRoom(r.ClientID, r.RoomID, ownerID, userID, maxRooms)
+}
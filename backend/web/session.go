@@ -3,6 +3,9 @@ package web
 import (
 	"errors"
 	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"buttonmania.win/protocol"
@@ -20,6 +23,13 @@ var (
 	ErrGameSessionInvalidHoldDuration  = fmt.Errorf("%w: invalid hold duration", ErrGameSessionInvalidUpdate)
 )
 
+// Define the count of chat messages, reactions counts and tournament events waiting to be sent to the client
+const (
+	chatMessagesQueueSize      = 16
+	reactionsQueueSize         = 4
+	tournamentUpdatesQueueSize = 16
+)
+
 // Define message update frequencies and intervals
 var (
 	MessageUpdateFrequencies   = [...]int64{5, 10, 30, 60, 90, 120, 160, 180, 240, 320}
@@ -34,7 +44,18 @@ type GameSession struct {
 	userID      protocol.UserID
 	payload     protocol.UserPayload
 	locale      protocol.UserLocale
+	profile     protocol.UserProfile
+	team        string
 	lastMsgTime int64
+	placeActive int64
+	countActive int64
+	chat        chan protocol.ChatMessage
+	reactions   chan map[string]int64
+	tournament  chan protocol.TournamentUpdate
+	lastReact   time.Time
+	kickCode    atomic.Value
+	finished    atomic.Bool
+	writeMu     sync.Mutex
 }
 
 // NewGameSession creates a new GameSession instance.
@@ -42,17 +63,24 @@ func NewGameSession(
 	userID protocol.UserID,
 	UserPayload protocol.UserPayload,
 	UserLocale protocol.UserLocale,
+	UserProfile protocol.UserProfile,
+	team string,
 	room *GameRoom,
 	ws *websocket.Conn,
-) GameSession {
-	return GameSession{
+) *GameSession {
+	return &GameSession{
 		ctx:         nil,
 		ws:          ws,
 		userID:      userID,
 		payload:     UserPayload,
 		locale:      UserLocale,
+		profile:     UserProfile.Public(),
+		team:        team,
 		room:        room,
 		lastMsgTime: time.Now().Unix(),
+		chat:        make(chan protocol.ChatMessage, chatMessagesQueueSize),
+		reactions:   make(chan map[string]int64, reactionsQueueSize),
+		tournament:  make(chan protocol.TournamentUpdate, tournamentUpdatesQueueSize),
 	}
 }
 
@@ -105,7 +133,6 @@ func (s *GameSession) shouldSendNewRandomMessage() bool {
 // gameplayUpdate creates a gameplay update message.
 func (s *GameSession) gameplayUpdate(
 	gameplayCtx *protocol.GameplayContext,
-	chatMessage *protocol.ChatMessage,
 ) protocol.GameplayMessage {
 	var msg *string
 	var placeInActiveSessionsPtr *int64
@@ -113,27 +140,24 @@ func (s *GameSession) gameplayUpdate(
 	var countInActiveSessionsPtr *int64
 	var countInLeaderboardPtr *int64
 
-	db := s.room.DB
-	msgLoc := s.room.MsgLoc
-	clientId := s.room.ClientID
-	roodId := s.room.RoomID
+	msgLoc := s.room.Messages()
 
 	if msgLoc != nil && s.shouldSendNewRandomMessage() {
 		msg = msgLoc.RandomLocalizedMessage(s.locale)
 		s.lastMsgTime = time.Now().Unix()
 	}
 
-	place, _ := db.GetUserPlaceInActiveSessions(clientId, roodId, s.userID)
-	count, _ := db.GetUsersCountInActiveSessions(clientId, roodId)
+	place := s.placeActive
+	count := s.countActive
 
 	placeInActiveSessionsPtr = &place
 	countInActiveSessionsPtr = &count
 
-	return protocol.NewGameplayMessage(
+	update := protocol.NewGameplayMessage(
 		gameplayCtx,
 		nil,
 		nil,
-		chatMessage,
+		nil,
 		((*protocol.GameMessage)(msg)),
 		placeInActiveSessionsPtr,
 		countInActiveSessionsPtr,
@@ -142,6 +166,9 @@ func (s *GameSession) gameplayUpdate(
 		nil,
 		protocol.Update,
 	)
+	update.Event = s.room.Event()
+	s.room.mode.OnUpdate(s, &update)
+	return update
 }
 
 // gameplayRecord creates a gameplay record message.
@@ -200,6 +227,88 @@ func (s *GameSession) gameplayError(
 	)
 }
 
+// gameplayChat creates a chat message.
+func (s *GameSession) gameplayChat(
+	chatMessage *protocol.ChatMessage,
+) protocol.GameplayMessage {
+	return protocol.NewGameplayMessage(
+		nil,
+		nil,
+		nil,
+		chatMessage,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		protocol.Chat,
+	)
+}
+
+// gameplayReactions creates a reactions counts message.
+func (s *GameSession) gameplayReactions(
+	reactions map[string]int64,
+) protocol.GameplayMessage {
+	msg := protocol.NewGameplayMessage(
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		protocol.Reactions,
+	)
+	msg.Reactions = reactions
+	return msg
+}
+
+// gameplayTournament creates a tournament event message.
+func (s *GameSession) gameplayTournament(
+	update protocol.TournamentUpdate,
+) protocol.GameplayMessage {
+	msg := protocol.NewGameplayMessage(
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		protocol.Elimination,
+	)
+	msg.Tournament = &update
+	return msg
+}
+
+// gameplayCountdown creates a message with the state of the event that is not open.
+func (s *GameSession) gameplayCountdown(
+	event protocol.Event,
+) protocol.GameplayMessage {
+	msg := protocol.NewGameplayMessage(
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		protocol.Countdown,
+	)
+	msg.Event = &event
+	return msg
+}
+
 // writeNetworkMessage sends a gameplay message to the client.
 func (s *GameSession) writeNetworkMessage(
 	gameplayCtx *protocol.GameplayContext,
@@ -215,11 +324,156 @@ func (s *GameSession) writeNetworkMessage(
 	} else if gameplayRecord != nil {
 		msg = s.gameplayRecord(gameplayRecord)
 	} else if gameplayCtx != nil {
-		msg = s.gameplayUpdate(gameplayCtx, chatMessage)
+		msg = s.gameplayUpdate(gameplayCtx)
+	} else if chatMessage != nil {
+		msg = s.gameplayChat(chatMessage)
 	}
+	return s.writeGameplayMessage(msg)
+}
+
+// writeGameplayMessage writes the message to the websocket.
+func (s *GameSession) writeGameplayMessage(msg protocol.GameplayMessage) error {
+	// Room messages are written concurrently with gameplay updates
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 	return s.ws.WriteJSON(msg)
 }
 
+// deliverChatMessage queues chat message for sending, drops it if the client is too slow.
+func (s *GameSession) deliverChatMessage(chatMessage protocol.ChatMessage) {
+	select {
+	case s.chat <- chatMessage:
+	default:
+	}
+}
+
+// deliverReactions queues reactions counts for sending, drops them if the client is too slow.
+func (s *GameSession) deliverReactions(reactions map[string]int64) {
+	select {
+	case s.reactions <- reactions:
+	default:
+	}
+}
+
+// deliverTournamentUpdate queues tournament event for sending, drops it if the client is too slow.
+// The session of the winner is finished on the next update once the tournament is over.
+func (s *GameSession) deliverTournamentUpdate(update protocol.TournamentUpdate) {
+	if update.Status == protocol.TournamentFinished {
+		s.finished.Store(true)
+	}
+	select {
+	case s.tournament <- update:
+	default:
+	}
+}
+
+// pushRoomMessages sends queued chat messages, reactions counts and tournament events to the client until done.
+func (s *GameSession) pushRoomMessages(done <-chan struct{}) {
+	for {
+		var err error
+		select {
+		case <-done:
+			return
+		case chatMessage := <-s.chat:
+			err = s.writeNetworkMessage(nil, nil, nil, &chatMessage)
+		case reactions := <-s.reactions:
+			err = s.writeGameplayMessage(s.gameplayReactions(reactions))
+		case update := <-s.tournament:
+			err = s.writeGameplayMessage(s.gameplayTournament(update))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// sendReaction publishes holder's reaction to the room, unknown and too frequent reactions are ignored.
+func (s *GameSession) sendReaction(reaction string) error {
+	now := time.Now()
+	if !s.room.Reactions.Has(reaction) || now.Sub(s.lastReact) < reactionsMinInterval {
+		return nil
+	}
+	s.lastReact = now
+	return s.room.DB.PublishReaction(
+		s.room.ClientID,
+		s.room.RoomID,
+		reaction,
+	)
+}
+
+// kick ends the session by the room owner at once, without waiting for the next update.
+func (s *GameSession) kick(code protocol.ErrorCode) {
+	s.kickCode.Store(code)
+	s.interrupt()
+}
+
+// finish ends the session at once, the hold is recorded as if released.
+func (s *GameSession) finish() {
+	if !s.finished.Swap(true) {
+		s.interrupt()
+	}
+}
+
+// interrupt fails the pending read of the client update, so the session ends right away.
+func (s *GameSession) interrupt() {
+	if err := s.ws.SetReadDeadline(time.Now()); err != nil {
+		log.Println("Failed to interrupt game session:", err)
+	}
+}
+
+// kicked returns the reason the session was ended by the room owner.
+func (s *GameSession) kicked() (protocol.ErrorCode, bool) {
+	code, ok := s.kickCode.Load().(protocol.ErrorCode)
+	return code, ok
+}
+
+// sendChatMessage moderates chat message and pushes it to the room,
+// rejected messages are reported back to the sender without finishing the session.
+func (s *GameSession) sendChatMessage(
+	chatMessage *protocol.ChatMessage,
+	nowTimestamp int64,
+) error {
+	chatMessage.UserID = s.userID
+	chatMessage.User = &s.profile
+	chatMessage.Timestamp = nowTimestamp
+	if chatMessage.Message == "" {
+		return nil
+	}
+	// Users muted by the room owner are rejected before other checks
+	muted, err := s.room.DB.IsRoomUserMuted(s.room.ClientID, s.room.RoomID, s.userID)
+	if err != nil {
+		return err
+	}
+	if muted {
+		return s.writeNetworkMessage(
+			nil,
+			nil,
+			s.room.Mod.gameplayError(s.locale, protocol.ChatMuted),
+			nil,
+		)
+	}
+	gameplayErr, err := s.room.Mod.Moderate(s.userID, s.locale, chatMessage)
+	if err != nil {
+		return err
+	}
+	if gameplayErr != nil {
+		return s.writeNetworkMessage(
+			nil,
+			nil,
+			gameplayErr,
+			nil,
+		)
+	}
+	if chatMessage.Message == "" {
+		return nil
+	}
+	return s.room.DB.PushChatMessage(
+		s.room.ClientID,
+		s.room.RoomID,
+		*chatMessage,
+	)
+}
+
 // updateGameSession updates the game session state.
 func (s *GameSession) updateGameSession(
 	gameplayCtx *protocol.GameplayContext,
@@ -245,36 +499,29 @@ func (s *GameSession) updateGameSession(
 		return nil, err
 	}
 
-	var chatMsg *protocol.ChatMessage
 	if gameplayMessageCtx.ChatMessage != nil {
-		gameplayMessageCtx.ChatMessage.UserID = userId
-		err = db.PushChatMessage(
-			clientId,
-			roodId,
-			*gameplayMessageCtx.ChatMessage,
-		)
+		err = s.sendChatMessage(gameplayMessageCtx.ChatMessage, nowTimestamp)
 		if err != nil {
 			return nil, err
 		}
 		gameplayMessageCtx.ChatMessage = nil
-	} else {
-		chatMessage, _ := db.PopChatMessage(
-			clientId,
-			roodId,
-			userId,
-		)
+	}
 
-		if chatMessage.Message != "" && chatMessage.UserID != userId {
-			chatMsg = &chatMessage
+	if gameplayMessageCtx.Reaction != "" {
+		err = s.sendReaction(gameplayMessageCtx.Reaction)
+		if err != nil {
+			return nil, err
 		}
+		gameplayMessageCtx.Reaction = ""
 	}
 
-	err = s.room.DB.SetUserDurationToActiveSessions(
+	s.placeActive, s.countActive, err = db.UpdateUserActiveSession(
 		clientId,
 		roodId,
 		userId,
 		holdDuration,
 		nowTimestamp,
+		pushTimestamp,
 	)
 	if err != nil {
 		return nil, err
@@ -286,7 +533,7 @@ func (s *GameSession) updateGameSession(
 			gameplayMessageCtx,
 			nil,
 			nil,
-			chatMsg,
+			nil,
 		)
 	}
 	return gameplayMessageCtx, err
@@ -297,48 +544,62 @@ func (s *GameSession) closeGameSession() error {
 	var err error
 	var gameRecordPtr *protocol.GameplayRecord
 	var gameErrorPtr *protocol.GameplayError
+	var modeMsgPtr *protocol.GameplayMessage
 
 	gameplayCtx := s.ctx
 	clientId := s.room.ClientID
 	roodId := s.room.RoomID
 	defer s.room.RemoveGameSession(s.userID)
 
+	// Sessions ended by the room owner leave no record
+	kickCode, kicked := s.kicked()
+
 	if gameplayCtx != nil {
-		record := protocol.NewGameplayRecord(*gameplayCtx)
-		addRecordToLeaderboardErr := s.room.DB.AddRecordToLeaderboard(
+		// The session reaped meanwhile has been already recorded by the reaper
+		active, remUserDurationFromActiveSessionsErr := s.room.DB.RemoveUserDurationFromActiveSessions(
 			clientId,
 			roodId,
 			s.userID,
-			record,
-		)
-		remUserDurationFromActiveSessionsErr := s.room.DB.RemoveUserDurationFromActiveSessions(
-			clientId,
-			roodId,
-			s.userID,
-			*gameplayCtx.Timestamp,
 		)
+		reaped := remUserDurationFromActiveSessionsErr == nil && !active
+		var scoreErr error
+		if !kicked && !reaped {
+			record := protocol.NewGameplayRecord(*gameplayCtx)
+			scoreErr = s.room.mode.Score(s, record)
+			gameRecordPtr = &record
+		}
+		// The mode is released even if the hold was not scored
+		var releaseErr error
+		modeMsgPtr, releaseErr = s.room.mode.OnRelease(s, gameRecordPtr)
 		remUserPayloadErr := s.room.DB.RemoveUserPayload(
 			clientId,
 			roodId,
 			s.userID,
 		)
+		s.room.emit(protocol.WebhookSessionEnded, s.userID, gameRecordPtr)
 		err = errors.Join(
 			err,
-			addRecordToLeaderboardErr,
+			scoreErr,
+			releaseErr,
 			remUserDurationFromActiveSessionsErr,
 			remUserPayloadErr,
 		)
-		gameRecordPtr = &record
 	}
 
-	if err != nil {
+	if kicked {
+		gameErrorPtr = s.room.Mod.gameplayError(s.locale, kickCode)
+	} else if err != nil {
 		gameError := protocol.NewGameplayError(protocol.GameMessage(err.Error()))
 		gameErrorPtr = &gameError
+	} else if modeMsgPtr != nil {
+		return s.writeGameplayMessage(*modeMsgPtr)
+	} else {
+		return nil
 	}
 
 	err = s.writeNetworkMessage(
 		nil,
-		gameRecordPtr,
+		nil,
 		gameErrorPtr,
 		nil,
 	)
@@ -346,20 +607,46 @@ func (s *GameSession) closeGameSession() error {
 	return err
 }
 
+// discardGameSession removes the session failed to start from active sessions.
+func (s *GameSession) discardGameSession() error {
+	_, remUserDurationFromActiveSessionsErr := s.room.DB.RemoveUserDurationFromActiveSessions(
+		s.room.ClientID,
+		s.room.RoomID,
+		s.userID,
+	)
+	return errors.Join(
+		remUserDurationFromActiveSessionsErr,
+		s.room.DB.RemoveUserPayload(
+			s.room.ClientID,
+			s.room.RoomID,
+			s.userID,
+		),
+	)
+}
+
 // startGameSession starts a new game session.
 func (s *GameSession) startGameSession() (*protocol.GameplayContext, error) {
 	if s.room.HasGameSession(s.userID) {
 		return nil, ErrGameSessionAlreadyExists
 	}
+	if event := s.room.Event(); event != nil && event.Status != protocol.EventOpen {
+		return nil, ErrEventNotOpen
+	}
+	banned, err := s.room.DB.IsRoomUserBanned(s.room.ClientID, s.room.RoomID, s.userID)
+	if err != nil {
+		return nil, err
+	}
+	if banned {
+		return nil, ErrRoomUserBanned
+	}
 
 	gameplayCtx := protocol.NewGameplayContext()
 	clientId := s.room.ClientID
 	roomId := s.room.RoomID
-	err := s.room.DB.SetUserDurationToActiveSessions(
+	s.placeActive, s.countActive, err = s.room.DB.StartUserActiveSession(
 		clientId,
 		roomId,
 		s.userID,
-		*gameplayCtx.Duration,
 		*gameplayCtx.Timestamp,
 	)
 	if err != nil {
@@ -373,13 +660,18 @@ func (s *GameSession) startGameSession() (*protocol.GameplayContext, error) {
 			s.userID,
 			s.payload,
 		)
-		if err != nil {
-			return nil, err
-		}
+	}
+	// The mode admits the holder last, so the session failed to start leaves no mode state
+	if err == nil {
+		err = s.room.mode.OnJoin(s)
+	}
+	if err != nil {
+		return nil, errors.Join(err, s.discardGameSession())
 	}
 	// Add session to room
 	s.ctx = &gameplayCtx
 	s.room.AddGameSession(s.userID, s)
+	s.room.emit(protocol.WebhookSessionStarted, s.userID, nil)
 
 	err = s.writeNetworkMessage(
 		&gameplayCtx,
@@ -397,8 +689,16 @@ func (s *GameSession) MaintainGameSession() error {
 	var updatedGameplayCtx *protocol.GameplayContext
 
 	s.ctx, err = s.startGameSession()
-	if err != nil {
+	if errors.Is(err, ErrEventNotOpen) {
+		// Holders coming before or after the event get the countdown instead of the error
+		err = s.writeGameplayMessage(s.gameplayCountdown(*s.room.Event()))
+	} else if err != nil {
 		gameError := protocol.NewGameplayError(protocol.GameMessage(err.Error()))
+		if errors.Is(err, ErrRoomUserBanned) {
+			gameError = *s.room.Mod.gameplayError(s.locale, protocol.RoomBanned)
+		} else if code, ok := modeErrorCode(err); ok {
+			gameError = *s.room.Mod.gameplayError(s.locale, code)
+		}
 		err_ := s.writeNetworkMessage(
 			nil,
 			nil,
@@ -407,19 +707,29 @@ func (s *GameSession) MaintainGameSession() error {
 		)
 		err = errors.Join(err, err_)
 	} else {
+		// Push room chat and reactions to the client while holding
+		roomDone := make(chan struct{})
+		go s.pushRoomMessages(roomDone)
 		for {
 			if err_ := s.ws.ReadJSON(&updatedGameplayCtx); err_ != nil {
-				err = errors.Join(err, ErrFailedToReadGameSessionUpdate)
+				// Sessions ended by the room are interrupted while waiting for the update
+				if _, kicked := s.kicked(); !kicked && !s.finished.Load() {
+					err = errors.Join(err, ErrFailedToReadGameSessionUpdate)
+				}
+				break
+			}
+			if _, kicked := s.kicked(); kicked || s.finished.Load() {
 				break
 			}
 			s.ctx, err = s.updateGameSession(
 				s.ctx,
 				updatedGameplayCtx,
 			)
-			if err != nil || s.ctx.ButtonPhase == protocol.Release || s.room.closed {
+			if err != nil || s.ctx.ButtonPhase == protocol.Release || s.room.Closed() {
 				break
 			}
 		}
+		close(roomDone)
 	}
 
 	return errors.Join(err, s.closeGameSession())
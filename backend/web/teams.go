@@ -0,0 +1,146 @@
+package web
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"buttonmania.win/protocol"
+)
+
+// Define limits of room teams
+const (
+	minRoomTeams         = 2
+	maxRoomTeams         = 8
+	maxTeamNameLength    = 24
+	teamStandingsRefresh = time.Second
+)
+
+// Define teams errors
+var (
+	ErrRoomNotTeams = errors.New("room is not in the teams mode")
+	ErrTeamNotFound = errors.New("team does not exist in the room")
+)
+
+// Teams aggregates holders of the room teams, standings are shared by holders of the room
+// and refreshed at most once per second.
+type Teams struct {
+	classicMode
+	names     []string
+	mu        sync.Mutex
+	standings []protocol.TeamStanding
+	updated   time.Time
+}
+
+// NewTeams creates a new Teams instance.
+func NewTeams(room *GameRoom, names []string) *Teams {
+	return &Teams{
+		classicMode: classicMode{room: room},
+		names:       names,
+	}
+}
+
+// Name returns the name of the mode.
+func (t *Teams) Name() protocol.RoomMode {
+	return protocol.ModeTeams
+}
+
+// OnJoin counts the hold for the team chosen by the holder.
+func (t *Teams) OnJoin(session *GameSession) error {
+	return t.join(session.userID, session.team)
+}
+
+// OnUpdate sends live standings of the teams, stale standings are still worth showing.
+func (t *Teams) OnUpdate(session *GameSession, update *protocol.GameplayMessage) {
+	update.Teams, _ = t.Standings()
+}
+
+// OnRelease adds the finished hold to the team and sends the record.
+func (t *Teams) OnRelease(
+	session *GameSession,
+	record *protocol.GameplayRecord,
+) (*protocol.GameplayMessage, error) {
+	if err := t.leave(session.userID, session.team, record); err != nil {
+		return nil, err
+	}
+	return t.classicMode.OnRelease(session, record)
+}
+
+// OnReaped adds the reaped hold to the team of the holder and to the room leaderboard.
+func (t *Teams) OnReaped(
+	userID protocol.UserID,
+	record *protocol.GameplayRecord,
+	end int64,
+) (bool, error) {
+	team, err := t.room.DB.GetUserTeam(t.room.ClientID, t.room.RoomID, userID)
+	if err != nil {
+		return false, err
+	} else if team != "" {
+		if err := t.leave(userID, team, record); err != nil {
+			return false, err
+		}
+	}
+	return t.classicMode.OnReaped(userID, record, end)
+}
+
+// Has checks if the team exists in the room.
+func (t *Teams) Has(team string) bool {
+	return slices.Contains(t.names, team)
+}
+
+// Standings returns live standings of every team of the room, the longest total hold time first.
+func (t *Teams) Standings() ([]protocol.TeamStanding, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if time.Since(t.updated) < teamStandingsRefresh {
+		return t.standings, nil
+	}
+	stored, err := t.room.DB.GetTeamStandings(t.room.ClientID, t.room.RoomID)
+	if err != nil {
+		return t.standings, err
+	}
+	// Teams without holds yet are listed too
+	standings := make([]protocol.TeamStanding, 0, len(t.names))
+	for _, name := range t.names {
+		standing := protocol.TeamStanding{Team: name}
+		if i := slices.IndexFunc(stored, func(s protocol.TeamStanding) bool { return s.Team == name }); i >= 0 {
+			standing = stored[i]
+		}
+		standings = append(standings, standing)
+	}
+	slices.SortStableFunc(standings, func(a, b protocol.TeamStanding) int {
+		return cmp.Compare(b.Duration, a.Duration)
+	})
+	t.standings = standings
+	t.updated = time.Now()
+	return standings, nil
+}
+
+// join stores the team the user holds the button for.
+func (t *Teams) join(userID protocol.UserID, team string) error {
+	if !t.Has(team) {
+		return ErrTeamNotFound
+	}
+	return t.room.DB.JoinTeam(t.room.ClientID, t.room.RoomID, userID, team)
+}
+
+// leave removes the user from the team, the finished hold is added to the team leaderboard.
+func (t *Teams) leave(userID protocol.UserID, team string, record *protocol.GameplayRecord) error {
+	return t.room.DB.LeaveTeam(t.room.ClientID, t.room.RoomID, userID, team, record)
+}
+
+// TeamStats returns live standings and the leaderboard of the room teams.
+func (r *GameRoom) TeamStats() (protocol.TeamStats, error) {
+	teams, ok := r.mode.(*Teams)
+	if !ok {
+		return protocol.TeamStats{}, ErrRoomNotTeams
+	}
+	standings, standingsErr := teams.Standings()
+	leaderboard, leaderboardErr := r.DB.GetTeamLeaderboard(r.ClientID, r.RoomID)
+	return protocol.TeamStats{
+		Standings:   standings,
+		Leaderboard: leaderboard,
+	}, errors.Join(standingsErr, leaderboardErr)
+}
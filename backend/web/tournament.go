@@ -0,0 +1,239 @@
+package web
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"buttonmania.win/db"
+	"buttonmania.win/protocol"
+)
+
+// Define limits of tournament schedule
+const (
+	minTournamentGrace     = 5
+	maxTournamentGrace     = 5 * 60
+	maxTournamentSchedule  = 30 * 24 * time.Hour
+	tournamentResultsLimit = 10
+)
+
+// Define tournament errors
+var ErrRoomNotTournament = errors.New("room is not in the tournament mode")
+
+// Tournament runs the last holder standing tournament of the room.
+// Players are eliminated on release and the last holder wins,
+// the instance noticing the end first announces the final standing.
+type Tournament struct {
+	classicMode
+	stop func() error
+}
+
+// NewTournament creates a new Tournament instance.
+func NewTournament(room *GameRoom) *Tournament {
+	updates, stop := room.DB.SubscribeTournamentUpdates(room.ClientID, room.RoomID)
+	t := &Tournament{
+		classicMode: classicMode{room: room},
+		stop:        stop,
+	}
+	go t.broadcastUpdates(updates)
+	return t
+}
+
+// Name returns the name of the mode.
+func (t *Tournament) Name() protocol.RoomMode {
+	return protocol.ModeTournament
+}
+
+// OnJoin starts holding of the registered player within the grace period.
+func (t *Tournament) OnJoin(session *GameSession) error {
+	return t.push(session.userID)
+}
+
+// Score keeps tournament holds out of the leaderboard, they end up in the final standing.
+func (t *Tournament) Score(session *GameSession, record protocol.GameplayRecord) error {
+	return nil
+}
+
+// OnRelease eliminates the player, the winner has already received the final standing.
+func (t *Tournament) OnRelease(
+	session *GameSession,
+	record *protocol.GameplayRecord,
+) (*protocol.GameplayMessage, error) {
+	update, err := t.eliminate(session.profile, time.Now().Unix())
+	if update == nil {
+		return nil, err
+	}
+	msg := session.gameplayTournament(*update)
+	return &msg, err
+}
+
+// OnReaped eliminates the player at the end of the reaped hold, the hold is never scored.
+func (t *Tournament) OnReaped(
+	userID protocol.UserID,
+	record *protocol.GameplayRecord,
+	end int64,
+) (bool, error) {
+	// The elimination is announced even without the profile
+	profile := protocol.UserProfile{UserID: userID}
+	if profiles, err := t.room.DB.GetUserProfiles([]protocol.UserID{userID}); err == nil && len(profiles) > 0 {
+		profile = profiles[0].Public()
+	}
+	_, err := t.eliminate(profile, end)
+	return false, err
+}
+
+// OnTick finishes the tournament once the grace period is over, even if nobody releases.
+func (t *Tournament) OnTick(now time.Time) error {
+	return t.finish()
+}
+
+// broadcastUpdates delivers tournament events of the room to every holder.
+func (t *Tournament) broadcastUpdates(updates <-chan protocol.TournamentUpdate) {
+	for update := range updates {
+		t.room.mu.RLock()
+		for _, session := range t.room.sessions {
+			session.deliverTournamentUpdate(update)
+		}
+		t.room.mu.RUnlock()
+	}
+}
+
+// push starts holding of the registered player.
+func (t *Tournament) push(userID protocol.UserID) error {
+	return t.room.DB.PushTournament(
+		t.room.ClientID,
+		t.room.RoomID,
+		userID,
+		time.Now().Unix(),
+	)
+}
+
+// eliminate eliminates the player released the button at given time and announces it to the room,
+// returns nil if the player was not holding in the running tournament.
+func (t *Tournament) eliminate(profile protocol.UserProfile, now int64) (*protocol.TournamentUpdate, error) {
+	tournament, err := t.room.DB.GetTournament(t.room.ClientID, t.room.RoomID)
+	if err != nil || tournament == nil {
+		return nil, err
+	}
+	remaining, eliminated, err := t.room.DB.EliminateTournamentPlayer(
+		t.room.ClientID,
+		t.room.RoomID,
+		profile.UserID,
+		now,
+	)
+	if err != nil || !eliminated {
+		return nil, err
+	}
+	update := protocol.TournamentUpdate{
+		ID:         tournament.ID,
+		Status:     protocol.TournamentRunning,
+		Remaining:  remaining,
+		Eliminated: &profile,
+	}
+	err = t.room.DB.PublishTournamentUpdate(t.room.ClientID, t.room.RoomID, update)
+	return &update, errors.Join(err, t.finish())
+}
+
+// finish finishes the tournament if it is over and announces the final standing with public profiles.
+func (t *Tournament) finish() error {
+	tournament, err := t.room.DB.GetTournament(t.room.ClientID, t.room.RoomID)
+	if err != nil || tournament == nil || tournament.Status == protocol.TournamentFinished {
+		return err
+	}
+	now := time.Now().Unix()
+	if now <= tournament.Starts+tournament.Grace {
+		return nil
+	}
+	standings, finished, err := t.room.DB.FinishTournament(
+		t.room.ClientID,
+		t.room.RoomID,
+		tournament.ID,
+		now,
+	)
+	if err != nil || !finished {
+		return err
+	}
+	userIDs := make([]protocol.UserID, len(standings))
+	for i, standing := range standings {
+		userIDs[i] = standing.User.UserID
+	}
+	profiles, err := t.room.DB.GetUserProfiles(userIDs)
+	if err != nil {
+		// Standings are announced even without profiles
+		log.Println("Failed to get tournament players profiles:", err)
+	}
+	byUserID := make(map[protocol.UserID]protocol.UserProfile, len(profiles))
+	for _, profile := range profiles {
+		byUserID[profile.UserID] = profile.Public()
+	}
+	for i := range standings {
+		if profile, exists := byUserID[standings[i].User.UserID]; exists {
+			standings[i].User = profile
+		}
+	}
+	return t.room.DB.PublishTournamentUpdate(t.room.ClientID, t.room.RoomID, protocol.TournamentUpdate{
+		ID:        tournament.ID,
+		Status:    protocol.TournamentFinished,
+		Standings: standings,
+	})
+}
+
+// Close stops the tournament of the closed room, the state is kept in the database.
+func (t *Tournament) Close() error {
+	return t.stop()
+}
+
+// ScheduleTournament schedules a new tournament of the room, players register until it starts.
+func (r *GameRoom) ScheduleTournament(ownerID protocol.UserID, starts int64, grace int64) (*protocol.Tournament, error) {
+	if err := r.checkRole(ownerID, "", protocol.RoleOwner); err != nil {
+		return nil, err
+	} else if _, ok := r.mode.(*Tournament); !ok {
+		return nil, ErrRoomNotTournament
+	}
+	if err := r.DB.ScheduleTournament(r.ClientID, r.RoomID, starts, grace); err != nil {
+		return nil, err
+	}
+	return r.DB.GetTournament(r.ClientID, r.RoomID)
+}
+
+// Tournament returns the state of the last tournament of the room.
+func (r *GameRoom) Tournament() (*protocol.Tournament, error) {
+	if _, ok := r.mode.(*Tournament); !ok {
+		return nil, ErrRoomNotTournament
+	}
+	tournament, err := r.DB.GetTournament(r.ClientID, r.RoomID)
+	if err != nil {
+		return nil, err
+	} else if tournament == nil {
+		return nil, db.ErrTournamentNotScheduled
+	}
+	return tournament, nil
+}
+
+// RegisterTournamentPlayer registers the user in the scheduled tournament of the room.
+func (r *GameRoom) RegisterTournamentPlayer(userID protocol.UserID) error {
+	if _, ok := r.mode.(*Tournament); !ok {
+		return ErrRoomNotTournament
+	}
+	banned, err := r.DB.IsRoomUserBanned(r.ClientID, r.RoomID, userID)
+	if err != nil {
+		return err
+	} else if banned {
+		return ErrRoomUserBanned
+	}
+	return r.DB.RegisterTournamentPlayer(r.ClientID, r.RoomID, userID, time.Now().Unix())
+}
+
+// TournamentResults returns final standings of the latest tournaments of the room with public profiles.
+func (r *GameRoom) TournamentResults(count int64) ([]protocol.TournamentResult, error) {
+	if _, ok := r.mode.(*Tournament); !ok {
+		return nil, ErrRoomNotTournament
+	}
+	results, err := r.DB.GetTournamentResults(r.ClientID, r.RoomID, count)
+	for i := range results {
+		for j := range results[i].Standings {
+			results[i].Standings[j].User = results[i].Standings[j].User.Public()
+		}
+	}
+	return results, err
+}
@@ -2,14 +2,18 @@ package web
 
 import (
 	"context"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"buttonmania.win/conf"
 	"buttonmania.win/db"
 	"buttonmania.win/localization"
 	"buttonmania.win/protocol"
+	"buttonmania.win/webhook"
 	"github.com/barweiss/go-tuple"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/gzip"
@@ -49,6 +53,11 @@ type Web struct {
 	upgrader websocket.Upgrader
 	clients  []protocol.ClientID
 	rooms    map[protocol.RoomKey]*GameRoom
+	roomsMu  sync.RWMutex
+	mods     map[protocol.ClientID]*ChatModerator
+	reacts   map[protocol.ClientID]*ReactionSet
+	hooks    *webhook.Dispatcher
+	reserved map[protocol.ClientID]map[protocol.RoomID]bool
 }
 
 // NewWeb creates a new Web instance.
@@ -57,6 +66,7 @@ func NewWeb(
 	conf conf.Conf,
 	engine *gin.Engine,
 	db *db.DB,
+	hooks *webhook.Dispatcher,
 	debug bool,
 ) (*Web, error) {
 	sessionName := ctx.Value(KeySessionName).(string)
@@ -68,6 +78,12 @@ func NewWeb(
 	store := cookie.NewStore([]byte(sessionSecret))
 	rooms := make(map[protocol.RoomKey]*GameRoom)
 	clients := make([]protocol.ClientID, 0)
+	mods := make(map[protocol.ClientID]*ChatModerator)
+	reacts := make(map[protocol.ClientID]*ReactionSet)
+	modLoc, err := localization.NewModerationLocalization()
+	if err != nil {
+		return nil, err
+	}
 
 	// Initialize WebSocket upgrader
 	originChecker := glob.MustCompile(allowedOrigins)
@@ -93,13 +109,36 @@ func NewWeb(
 
 	// Initialize predefined game rooms
 	for _, c := range conf.Clients {
+		mods[c.ClientId] = NewChatModerator(c.ClientId, c.Chat, db, modLoc)
+		reacts[c.ClientId] = NewReactionSet(c.Reactions)
 		for _, r := range c.Rooms {
 			msgLoc, err := localization.NewMessagesLocalization(c.ClientId, r)
 			if err != nil {
 				return nil, err
 			}
+			// Tournaments need the room owner, so predefined rooms can't host them
+			modeConf := c.Modes[r]
+			if modeConf.Mode == protocol.ModeTournament {
+				return nil, fmt.Errorf("room %s: tournament mode is only available in custom rooms", r)
+			}
+			if modeConf.Mode == protocol.ModeTeams {
+				if apiErr := checkTeams(modeConf.Teams); apiErr != nil {
+					return nil, fmt.Errorf("room %s: %s", r, apiErr.Message)
+				}
+			}
 			roomKey := protocol.RoomKey(tuple.New2(c.ClientId, r))
-			rooms[roomKey], _ = NewGameRoom(c.ClientId, r, db, msgLoc)
+			rooms[roomKey], err = NewGameRoom(c.ClientId, r, db, msgLoc, mods[c.ClientId], reacts[c.ClientId], hooks, modeConf.Mode, modeConf.Teams)
+			if err != nil {
+				return nil, fmt.Errorf("room %s: %w", r, err)
+			}
+			// Event rooms are open only within configured windows
+			if events, ok := c.Events[r]; ok {
+				event, err := NewEvent(events)
+				if err != nil {
+					return nil, fmt.Errorf("room %s: %w", r, err)
+				}
+				rooms[roomKey].SetEvent(event)
+			}
 		}
 		clients = append(clients, c.ClientId)
 	}
@@ -107,7 +146,18 @@ func NewWeb(
 	// Initialize user created game rooms
 	customRooms, err := db.ListCustomGameRooms()
 	for _, roomKey := range customRooms {
-		rooms[roomKey], _ = NewGameRoom(roomKey.V1, roomKey.V2, db, nil)
+		// Rooms of removed clients get default chat policy and reactions
+		if _, exists := mods[roomKey.V1]; !exists {
+			mods[roomKey.V1] = NewDefaultChatModerator(roomKey.V1, db, modLoc)
+			reacts[roomKey.V1] = NewDefaultReactionSet()
+		}
+		room, roomErr := NewCustomGameRoom(roomKey.V1, roomKey.V2, db, mods[roomKey.V1], reacts[roomKey.V1], hooks)
+		if roomErr != nil {
+			log.Println("Failed to load room:", roomErr)
+		}
+		if room != nil {
+			rooms[roomKey] = room
+		}
 	}
 
 	// Apply middlewares and other router parameters
@@ -140,6 +190,10 @@ func NewWeb(
 		upgrader: upgrader,
 		clients:  clients,
 		rooms:    rooms,
+		mods:     mods,
+		reacts:   reacts,
+		hooks:    hooks,
+		reserved: newReservedRoomIds(conf),
 	}, err
 }
 
@@ -154,12 +208,67 @@ func (w *Web) Run() error {
 	serverTLSCert := w.ctx.Value(KeyServerTLSCert).(string)
 	serverTLSKey := w.ctx.Value(KeyServerTLSKey).(string)
 
+	go w.tickRooms()
+
 	w.engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	w.engine.GET("/ws", w.wsHandler)
-	w.engine.GET("/api/room/create", w.createRoomHandler)
-	w.engine.GET("/api/room/delete", w.deleteRoomHandler)
-	w.engine.GET("/api/room/stats", w.statsRoomHandler)
-	w.engine.GET("/api/stats", w.statsHandler)
+	v1 := w.engine.Group("/api/v1")
+	v1.GET("/rooms", w.listRoomsV1Handler)
+	v1.POST("/rooms", w.createRoomV1Handler)
+	v1.GET("/rooms/:clientId/:roomId", w.infoRoomV1Handler)
+	v1.PATCH("/rooms/:clientId/:roomId", w.updateRoomV1Handler)
+	v1.DELETE("/rooms/:clientId/:roomId", w.deleteRoomV1Handler)
+	v1.GET("/rooms/:clientId/:roomId/stats", w.statsRoomV1Handler)
+	v1.GET("/rooms/:clientId/:roomId/chat", w.chatRoomV1Handler)
+	v1.GET("/rooms/:clientId/:roomId/holders", w.holdersRoomV1Handler)
+	v1.POST("/rooms/:clientId/:roomId/kicks", w.kickRoomV1Handler)
+	v1.POST("/rooms/:clientId/:roomId/bans", w.banRoomV1Handler)
+	v1.DELETE("/rooms/:clientId/:roomId/bans/:userId", w.unbanRoomV1Handler)
+	v1.POST("/rooms/:clientId/:roomId/mutes", w.muteRoomV1Handler)
+	v1.DELETE("/rooms/:clientId/:roomId/mutes/:userId", w.unmuteRoomV1Handler)
+	v1.GET("/rooms/:clientId/:roomId/moderators", w.moderatorsRoomV1Handler)
+	v1.POST("/rooms/:clientId/:roomId/moderators", w.addModeratorRoomV1Handler)
+	v1.DELETE("/rooms/:clientId/:roomId/moderators/:userId", w.removeModeratorRoomV1Handler)
+	v1.PUT("/rooms/:clientId/:roomId/owner", w.transferRoomV1Handler)
+	v1.GET("/rooms/:clientId/:roomId/webhooks/deliveries", w.webhookDeliveriesRoomV1Handler)
+	v1.GET("/rooms/:clientId/:roomId/teams", w.teamsRoomV1Handler)
+	v1.GET("/rooms/:clientId/:roomId/chains", w.chainsRoomV1Handler)
+	v1.GET("/rooms/:clientId/:roomId/event", w.eventRoomV1Handler)
+	v1.POST("/rooms/:clientId/:roomId/tournament", w.scheduleTournamentV1Handler)
+	v1.GET("/rooms/:clientId/:roomId/tournament", w.tournamentRoomV1Handler)
+	v1.POST("/rooms/:clientId/:roomId/tournament/players", w.registerTournamentV1Handler)
+	v1.GET("/rooms/:clientId/:roomId/tournaments", w.tournamentsRoomV1Handler)
+	v1.POST("/rooms/:clientId/:roomId/invites", w.createInviteV1Handler)
+	v1.GET("/rooms/:clientId/:roomId/invites", w.inviteRoomV1Handler)
+	v1.DELETE("/rooms/:clientId/:roomId/invites", w.revokeInviteV1Handler)
+	v1.POST("/rooms/:clientId/:roomId/members", w.joinRoomV1Handler)
+	v1.GET("/rooms/:clientId/:roomId/messages", w.messagesRoomV1Handler)
+	v1.PUT("/rooms/:clientId/:roomId/messages", w.setMessagesRoomV1Handler)
+	v1.DELETE("/rooms/:clientId/:roomId/messages", w.removeMessagesRoomV1Handler)
+	v1.GET("/clients/:clientId/stats", w.statsClientV1Handler)
+	v1.GET("/clients/:clientId/invites/:code", w.resolveInviteV1Handler)
+	v1.GET("/clients/:clientId/archive", w.archiveClientV1Handler)
+	v1.GET("/clients/:clientId/archive/:roomId", w.archivedRoomV1Handler)
+	v1.POST("/clients/:clientId/chat/reports", w.reportChatV1Handler)
+	v1.PUT("/users/privacy", w.privacyUserV1Handler)
+
+	// Room directory is also served at the route it was announced at
+	w.engine.GET("/api/rooms", w.listRoomsV1Handler)
+
+	// Deprecated routes kept as aliases of the versioned API
+	w.engine.GET("/api/room/create", deprecatedRoute("/api/v1/rooms"), w.createRoomHandler)
+	w.engine.GET("/api/room/delete", deprecatedRoute("/api/v1/rooms/{clientId}/{roomId}"), w.deleteRoomHandler)
+	w.engine.GET("/api/room/stats", deprecatedRoute("/api/v1/rooms/{clientId}/{roomId}/stats"), w.statsRoomHandler)
+	w.engine.GET("/api/room/chat", deprecatedRoute("/api/v1/rooms/{clientId}/{roomId}/chat"), w.chatRoomHandler)
+	w.engine.GET("/api/room/kick", deprecatedRoute("/api/v1/rooms/{clientId}/{roomId}/kicks"), w.kickRoomHandler)
+	w.engine.GET("/api/room/ban", deprecatedRoute("/api/v1/rooms/{clientId}/{roomId}/bans"), w.banRoomHandler)
+	w.engine.GET("/api/room/unban", deprecatedRoute("/api/v1/rooms/{clientId}/{roomId}/bans/{userId}"), w.unbanRoomHandler)
+	w.engine.GET("/api/room/mute", deprecatedRoute("/api/v1/rooms/{clientId}/{roomId}/mutes"), w.muteRoomHandler)
+	w.engine.GET("/api/room/unmute", deprecatedRoute("/api/v1/rooms/{clientId}/{roomId}/mutes/{userId}"), w.unmuteRoomHandler)
+	w.engine.GET("/api/room/holders", deprecatedRoute("/api/v1/rooms/{clientId}/{roomId}/holders"), w.holdersRoomHandler)
+	w.engine.GET("/api/stats", deprecatedRoute("/api/v1/clients/{clientId}/stats"), w.statsHandler)
+	w.engine.GET("/api/chat/report", deprecatedRoute("/api/v1/clients/{clientId}/chat/reports"), w.reportChatHandler)
+	w.engine.GET("/api/user/privacy", deprecatedRoute("/api/v1/users/privacy"), w.privacyUserHandler)
 
 	if len(serverTLSCert) > 0 && len(serverTLSKey) > 0 {
 		return w.engine.RunTLS(
@@ -0,0 +1,320 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"buttonmania.win/conf"
+	"buttonmania.win/db"
+	"buttonmania.win/protocol"
+)
+
+const (
+	// Headers of webhook requests
+	HeaderEvent     = "X-Buttonmania-Event"
+	HeaderDelivery  = "X-Buttonmania-Delivery"
+	HeaderTimestamp = "X-Buttonmania-Timestamp"
+	HeaderSignature = "X-Buttonmania-Signature"
+	signaturePrefix = "sha256="
+	// Delivery parameters
+	queueSize      = 1024
+	workers        = 4
+	maxAttempts    = 6
+	initialBackoff = time.Second
+	maxBackoff     = 5 * time.Minute
+	requestTimeout = 10 * time.Second
+	// Persisted retries polling
+	retryInterval = time.Second
+	retryBatch    = 100
+	// Delivery log retention
+	logRetention         = 30 * 24 * time.Hour
+	logRetentionInterval = time.Hour
+)
+
+// Define supported webhook events
+var eventTypes = []protocol.WebhookEventType{
+	protocol.WebhookRecord,
+	protocol.WebhookWorldRecord,
+	protocol.WebhookRoomCreated,
+	protocol.WebhookRoomDeleted,
+	protocol.WebhookSessionStarted,
+	protocol.WebhookSessionEnded,
+}
+
+// Define transport errors of deliveries, the delivery log keeps these instead of errors
+// naming the webhook host
+var (
+	ErrDeliveryTimeout = errors.New("timeout")
+	ErrDeliveryRefused = errors.New("connection refused")
+	ErrDeliveryDNS     = errors.New("dns lookup failed")
+	ErrDeliveryTLS     = errors.New("tls handshake failed")
+	ErrDeliveryFailed  = errors.New("connection failed")
+)
+
+// delivery represents the event queued for sending to the webhook.
+type delivery struct {
+	event   protocol.WebhookEvent
+	hook    conf.WebhookConf
+	attempt int
+}
+
+// Dispatcher delivers game events to webhooks of clients, failed deliveries are retried with backoff.
+// Every instance delivers events happened on it, retries are persisted and claimed by one instance,
+// so nothing is delivered twice and retries survive restarts.
+type Dispatcher struct {
+	ctx    context.Context
+	db     *db.DB
+	client *http.Client
+	hooks  map[protocol.ClientID][]conf.WebhookConf
+	queue  chan delivery
+}
+
+// NewDispatcher creates a new instance of Dispatcher.
+func NewDispatcher(ctx context.Context, cfg conf.Conf, db *db.DB) (*Dispatcher, error) {
+	hooks := make(map[protocol.ClientID][]conf.WebhookConf)
+	for _, c := range cfg.Clients {
+		for _, hook := range c.Webhooks {
+			if u, err := url.Parse(hook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return nil, fmt.Errorf("invalid webhook url of client %s: %s", c.ClientId, hook.URL)
+			}
+			if hook.Secret == "" {
+				return nil, fmt.Errorf("missing webhook secret of client %s: %s", c.ClientId, hook.URL)
+			}
+			for _, eventType := range hook.Events {
+				if !slices.Contains(eventTypes, eventType) {
+					return nil, fmt.Errorf("invalid webhook event of client %s: %s", c.ClientId, eventType)
+				}
+			}
+			hooks[c.ClientId] = append(hooks[c.ClientId], hook)
+		}
+	}
+	return &Dispatcher{
+		ctx:    ctx,
+		db:     db,
+		client: &http.Client{Timeout: requestTimeout},
+		hooks:  hooks,
+		queue:  make(chan delivery, queueSize),
+	}, nil
+}
+
+// Sign computes the signature of the webhook request body sent at the timestamp.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of the webhook request body sent at the timestamp.
+func Verify(secret string, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// subscribed checks if the webhook is subscribed to the event.
+func subscribed(hook conf.WebhookConf, event protocol.WebhookEvent) bool {
+	return (len(hook.Events) == 0 || slices.Contains(hook.Events, event.Type)) &&
+		(len(hook.Rooms) == 0 || slices.Contains(hook.Rooms, event.RoomID))
+}
+
+// backoff returns the delay before the next attempt, doubled after every failed attempt.
+func backoff(attempt int) time.Duration {
+	return min(initialBackoff<<(attempt-1), maxBackoff)
+}
+
+// Emit queues the event for every subscribed webhook of the client, the event is dropped
+// if the queue is full. The nil dispatcher emits nothing.
+func (d *Dispatcher) Emit(event protocol.WebhookEvent) {
+	if d == nil || len(d.hooks[event.ClientID]) == 0 {
+		return
+	}
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		log.Println("Failed to create webhook event id:", err)
+		return
+	}
+	event.ID = hex.EncodeToString(idBytes)
+	event.Timestamp = time.Now().Unix()
+	for _, hook := range d.hooks[event.ClientID] {
+		if subscribed(hook, event) {
+			d.enqueue(delivery{event: event, hook: hook, attempt: 1})
+		}
+	}
+}
+
+// enqueue queues the delivery without blocking the caller.
+func (d *Dispatcher) enqueue(dl delivery) {
+	select {
+	case d.queue <- dl:
+	default:
+		log.Println("Webhook queue is full, dropped event:", dl.event.ID)
+	}
+}
+
+// send posts the signed event to the webhook, returns the response status.
+func (d *Dispatcher) send(dl delivery) (int, error) {
+	body, err := json.Marshal(dl.event)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, dl.hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, string(dl.event.Type))
+	req.Header.Set(HeaderDelivery, dl.event.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(dl.hook.Secret, timestamp, body))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		// Delivery log is shown to room owners, the webhook url is kept private
+		return 0, transportError(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// transportError maps the failed request to the category of the failure.
+func transportError(err error) error {
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var certErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	switch {
+	case errors.As(err, &dnsErr):
+		return ErrDeliveryDNS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrDeliveryTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrDeliveryRefused
+	case errors.As(err, &recordErr), errors.As(err, &alertErr), errors.As(err, &certErr),
+		errors.As(err, &authorityErr), errors.As(err, &hostnameErr), errors.As(err, &invalidErr):
+		return ErrDeliveryTLS
+	}
+	return ErrDeliveryFailed
+}
+
+// deliver sends the event and logs the attempt, failed attempts are retried unless
+// the webhook rejected the event.
+func (d *Dispatcher) deliver(dl delivery) {
+	status, err := d.send(dl)
+	errStr := ""
+	if err != nil {
+		errStr = err.Error()
+	}
+	if logErr := d.db.AddWebhookDelivery(dl.event, dl.hook.URL, dl.attempt, status, errStr); logErr != nil {
+		log.Println("Failed to log webhook delivery:", logErr)
+	}
+	if err == nil {
+		return
+	}
+	rejected := status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests
+	if rejected || dl.attempt >= maxAttempts {
+		log.Println("Failed to deliver webhook event:", dl.event.ID, err)
+		return
+	}
+	if err := d.db.AddWebhookRetry(dl.event, dl.hook.URL, dl.attempt+1, time.Now().Add(backoff(dl.attempt))); err != nil {
+		log.Println("Failed to schedule webhook retry:", dl.event.ID, err)
+	}
+}
+
+// hook finds the webhook of the client by its url, the webhook may be removed from the config
+// while its retries wait.
+func (d *Dispatcher) hook(clientId protocol.ClientID, hookURL string) (conf.WebhookConf, bool) {
+	for _, hook := range d.hooks[clientId] {
+		if hook.URL == hookURL {
+			return hook, true
+		}
+	}
+	return conf.WebhookConf{}, false
+}
+
+// retry queues due retries claimed by this instance until the context is done.
+func (d *Dispatcher) retry() {
+	ticker := time.NewTicker(retryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		retries, err := d.db.ClaimWebhookRetries(retryBatch)
+		if err != nil {
+			log.Println("Failed to claim webhook retries:", err)
+			continue
+		}
+		for _, r := range retries {
+			hook, exists := d.hook(r.Event.ClientID, r.URL)
+			if !exists {
+				log.Println("Dropped retry of removed webhook:", r.Event.ID)
+				continue
+			}
+			// Claimed retries wait for free workers instead of being dropped
+			select {
+			case <-d.ctx.Done():
+				return
+			case d.queue <- delivery{event: r.Event, hook: hook, attempt: r.Attempt}:
+			}
+		}
+	}
+}
+
+// work delivers queued events until the context is done.
+func (d *Dispatcher) work() {
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case dl := <-d.queue:
+			d.deliver(dl)
+		}
+	}
+}
+
+// Run delivers queued events and due retries and removes expired delivery log entries
+// until the context is done.
+func (d *Dispatcher) Run() error {
+	for range workers {
+		go d.work()
+	}
+	go d.retry()
+	ticker := time.NewTicker(logRetentionInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.db.RemoveExpiredWebhookDeliveries(logRetention); err != nil {
+			log.Println("Failed to remove expired webhook deliveries:", err)
+		}
+		select {
+		case <-d.ctx.Done():
+			return d.ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}